@@ -0,0 +1,48 @@
+package builder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	"github.com/catnovelapi/builder/pkg/files"
+	"github.com/klauspost/compress/zstd"
+)
+
+// SaveGzip 方法用于把 HTTP 响应的字节结果以 gzip 格式压缩后保存到文件。它接收一个 string 类型的参数，该参数表示文件路径。
+// 与 SaveFile 一样, 文件以原子方式写入, 并会校验 Request.SetExpectedChecksum 设置的校验和 (针对压缩前的内容)。
+func (response *Response) SaveGzip(path string) error {
+	return response.saveCompressed(path, "SaveGzip", func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	})
+}
+
+// SaveZstd 方法用于把 HTTP 响应的字节结果以 zstd 格式压缩后保存到文件。它接收一个 string 类型的参数，该参数表示文件路径。
+// zstd 的压缩率和速度通常优于 gzip, 适合保存大规模抓取的 HTML 存档。
+func (response *Response) SaveZstd(path string) error {
+	return response.saveCompressed(path, "SaveZstd", func(w io.Writer) (io.WriteCloser, error) {
+		return zstd.NewWriter(w)
+	})
+}
+
+// saveCompressed 方法用于把响应体经过 compress 返回的压缩器流式压缩后写入文件。
+func (response *Response) saveCompressed(path, funcName string, compress func(io.Writer) (io.WriteCloser, error)) error {
+	reader := response.RequestSource.newChecksumReader(bytes.NewReader(response.GetByte()))
+	pr, pw := io.Pipe()
+	go func() {
+		writer, err := compress(pw)
+		if err == nil {
+			if _, err = io.Copy(writer, reader); err == nil {
+				err = writer.Close()
+			}
+		}
+		pw.CloseWithError(err)
+	}()
+	err := files.WriteAtomic(path, pr)
+	// WriteAtomic 提前返回时关闭读取端, 避免压缩 goroutine 阻塞
+	pr.CloseWithError(err)
+	if err != nil {
+		response.RequestSource.LogError(err, path, "archive.go", funcName)
+	}
+	return err
+}
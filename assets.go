@@ -0,0 +1,88 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Asset 类型用于存储 DownloadAssets 下载的单个资源的结果。
+type Asset struct {
+	URL  *url.URL // 资源的绝对地址
+	Path string   // 资源保存到本地的路径
+	Err  error    // 下载失败时的错误
+}
+
+// DownloadAssets 方法用于下载 HTML 响应中引用的资源, 例如插图章节中的图片。它接收三个 string 类型的参数，
+// 分别表示 CSS 选择器 (例如 img[src])、保存资源地址的属性 (为空时依次尝试 src 和 href) 以及保存目录。
+// 资源通过同一个 Client 使用 Batch 并发下载, 并发数和速率受 SetMaxConcurrent、限流等设置的限制,
+// 请求会携带当前页面的地址作为 Referer。返回的清单与页面中资源出现的顺序一致, 所有下载失败的错误合并后返回。
+func (response *Response) DownloadAssets(selector, attr, destDir string) ([]Asset, error) {
+	attrs := []string{"src", "href"}
+	if attr != "" {
+		attrs = []string{attr}
+	}
+	links := response.resolveLinks(selector, attrs...)
+	if len(links) == 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		response.RequestSource.LogError(err, destDir, "assets.go", "DownloadAssets")
+		return nil, err
+	}
+	client := response.RequestSource.client
+	assets := make([]Asset, len(links))
+	requests := make([]*Request, len(links))
+	used := make(map[string]bool)
+	for i, link := range links {
+		assets[i] = Asset{URL: link, Path: filepath.Join(destDir, assetFileName(link, used))}
+		requests[i] = client.R().Prepare(MethodGet, link.String())
+		if ctx := response.RequestSource.ctx; ctx != nil {
+			requests[i].SetContext(ctx)
+		}
+		if page := response.finalURL(); page != nil {
+			requests[i].SetHeader("Referer", page.String())
+		}
+	}
+	var errs []error
+	for i, result := range client.Batch(requests...) {
+		err := result.Err
+		if err == nil && (result.Response.GetStatusCode() < 200 || result.Response.GetStatusCode() > 299) {
+			err = fmt.Errorf("unexpected status %s", result.Response.GetStatus())
+		}
+		if err == nil {
+			err = result.Response.SaveFile(assets[i].Path)
+		}
+		if err != nil {
+			assets[i].Err = fmt.Errorf("download %s: %w", assets[i].URL, err)
+			errs = append(errs, assets[i].Err)
+		}
+	}
+	return assets, errors.Join(errs...)
+}
+
+// assetFileName 方法用于根据资源地址生成本地文件名, 与已使用的文件名重复时添加序号。
+func assetFileName(link *url.URL, used map[string]bool) string {
+	name := path.Base(link.Path)
+	if name == "/" || name == "." || name == "" {
+		name = "index"
+	}
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`\/:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 1; used[name]; i++ {
+		name = stem + "-" + strconv.Itoa(i) + ext
+	}
+	used[name] = true
+	return name
+}
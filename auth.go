@@ -0,0 +1,43 @@
+package builder
+
+import (
+	"encoding/base64"
+	"net/http"
+)
+
+// basicAuth 方法用于生成 HTTP Basic 认证的 Authorization 请求头的值。
+func basicAuth(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+// SetAuthToken 方法用于设置当前请求的认证令牌, 覆盖客户端的 Authorization 请求头, 例如使用另一个账号调用接口。
+// 它接收一个 string 类型的参数，该参数与 Client.SetAuthorizationKey 的参数相同, 原样作为请求头的值,
+// 需要 Bearer 等认证方案时请包含在参数中, 例如 "Bearer " + token。
+func (request *Request) SetAuthToken(token string) *Request {
+	request.removeAuthorization()
+	return request.SetHeader(request.client.HeaderAuthorizationKey, token)
+}
+
+// SetBasicAuth 方法用于设置当前请求的 Basic 认证, 覆盖客户端的 Authorization 请求头。它接收两个 string 类型的参数，
+// 分别表示用户名和密码。
+func (request *Request) SetBasicAuth(username, password string) *Request {
+	request.removeAuthorization()
+	return request.SetHeader(request.client.HeaderAuthorizationKey, basicAuth(username, password))
+}
+
+// NoAuth 方法用于让当前请求不发送从客户端继承的 Authorization 请求头, 适合调用不需要登录的公开接口。
+func (request *Request) NoAuth() *Request {
+	request.removeAuthorization()
+	return request
+}
+
+// removeAuthorization 方法用于删除当前请求中不区分大小写匹配的 Authorization 请求头。
+func (request *Request) removeAuthorization() {
+	key := http.CanonicalHeaderKey(request.client.HeaderAuthorizationKey)
+	request.Header.Range(func(k, _ any) bool {
+		if name, ok := k.(string); ok && http.CanonicalHeaderKey(name) == key {
+			request.Header.Delete(k)
+		}
+		return true
+	})
+}
@@ -0,0 +1,38 @@
+package builder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthTokenMatchesClientAuthorization(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	client := NewClient().SetAuthorizationKey("Bearer client-token")
+	tests := []struct {
+		name    string
+		request *Request
+		want    string
+	}{
+		{"client", client.R(), "Bearer client-token"},
+		{"request token", client.R().SetAuthToken("Bearer other-token"), "Bearer other-token"},
+		{"raw token", client.R().SetAuthToken("raw"), "raw"},
+		{"basic", client.R().SetBasicAuth("user", "pass"), "Basic dXNlcjpwYXNz"},
+		{"no auth", client.R().NoAuth(), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := tt.request.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := response.String(); got != tt.want {
+				t.Errorf("Authorization = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package builder
+
+import "sync"
+
+// BatchResult 类型用于存储批量请求中单个请求的执行结果。
+type BatchResult struct {
+	Request  *Request  // 指向被执行的 Request 的指针
+	Response *Response // 请求成功时的响应
+	Err      error     // 请求失败时的错误
+}
+
+// Batch 方法用于并发执行一组通过 Prepare 准备好的请求, 并发数受 MaxConcurrent 的容量限制。
+// 返回的结果与传入的请求一一对应, 顺序保持不变。
+func (client *Client) Batch(requests ...*Request) []BatchResult {
+	results := make([]BatchResult, len(requests))
+	var wg sync.WaitGroup
+	semaphore := client.MaxConcurrent
+	for i, request := range requests {
+		// 先占用并发名额再启动 goroutine, 使同时存在的 goroutine 数量也受到限制
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func(i int, request *Request) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			var response *Response
+			err := safeCall("Batch", func() (err error) {
+				response, err = request.Send()
+				return err
+			})
+			results[i] = BatchResult{Request: request, Response: response, Err: err}
+		}(i, request)
+	}
+	wg.Wait()
+	return results
+}
+
+// SetMaxConcurrent 方法用于设置 Batch 等并发执行请求时的最大并发数。它接收一个 int 类型的参数，该参数表示最大并发数。
+func (client *Client) SetMaxConcurrent(n int) *Client {
+	if n <= 0 {
+		client.LogInfo("max concurrent must be greater than 0", n, "SetMaxConcurrent")
+	} else {
+		client.MaxConcurrent = make(chan struct{}, n)
+	}
+	return client
+}
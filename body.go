@@ -0,0 +1,190 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// bodyKind 类型用于表示请求体的编码方式。
+type bodyKind int
+
+const (
+	bodyAuto bodyKind = iota // bodyAuto 表示根据 Body 的类型和 Content-Type 推断编码方式
+	bodyJSON
+	bodyXML
+	bodyForm
+	bodyRaw
+)
+
+const xmlContentType = "application/xml"
+
+// SetBodyJson 方法用于设置以 JSON 编码的请求体。它接收一个 any 类型的参数，该参数会使用 SetJSONMarshaler 设置的函数
+// 或者 Client 的 JSONMarshal 编码, 并把 Content-Type 设置为 application/json。编码失败时请求返回错误。
+func (request *Request) SetBodyJson(v any) *Request {
+	request.Body, request.bodyKind = v, bodyJSON
+	return request.SetHeaderContentType(jsonContentType)
+}
+
+// SetBodyXml 方法用于设置以 XML 编码的请求体。它接收一个 any 类型的参数，该参数会使用 Client 的 XMLMarshal 编码,
+// 并把 Content-Type 设置为 application/xml。编码失败时请求返回错误。
+func (request *Request) SetBodyXml(v any) *Request {
+	request.Body, request.bodyKind = v, bodyXML
+	return request.SetHeaderContentType(xmlContentType)
+}
+
+// SetBodyFormString 方法用于设置表单请求体。它接收一个 string 类型的参数，该参数表示已编码的表单 (例如 a=1&b=2),
+// 并把 Content-Type 设置为 application/x-www-form-urlencoded。表单格式不正确时请求返回错误。
+func (request *Request) SetBodyFormString(form string) *Request {
+	request.Body, request.bodyKind = form, bodyForm
+	return request.SetHeaderContentType(formContentType)
+}
+
+// SetBodyRaw 方法用于设置原始的请求体。它接收一个 []byte 类型的参数表示请求体,
+// 以及一个 string 类型的参数表示 Content-Type, 为空时不修改 Content-Type。
+func (request *Request) SetBodyRaw(body []byte, contentType string) *Request {
+	request.Body, request.bodyKind = body, bodyRaw
+	if contentType != "" {
+		request.SetHeaderContentType(contentType)
+	}
+	return request
+}
+
+// setBody 方法用于生成请求体, 所有 Body 都经过这一个流程。通过 SetBodyJson 等方法指定了编码方式时按指定的方式编码,
+// 否则 Content-Type 匹配 RegisterCodec 注册的编解码器时使用该编解码器, 其余情况由 detectBodyKind 推断编码方式。
+func (request *Request) setBody() error {
+	kind := request.bodyKind
+	if kind == bodyAuto {
+		if handled, err := request.setCodecBody(); handled {
+			return err
+		}
+		kind = request.detectBodyKind()
+	}
+	switch kind {
+	case bodyJSON:
+		if request.GetHeaderContentType() == "" {
+			request.SetHeaderContentType(jsonContentType)
+		}
+		return request.marshalBody("JSONMarshal", request.jsonMarshaler())
+	case bodyXML:
+		return request.marshalBody("XMLMarshal", request.client.XMLMarshal)
+	case bodyForm:
+		if request.GetHeaderContentType() == "" {
+			request.SetHeaderContentType(formContentType)
+		}
+		return request.setFormBody()
+	case bodyRaw:
+		return request.setRawBody()
+	}
+	return nil
+}
+
+// detectBodyKind 方法用于根据 Body 的类型和 Content-Type 推断编码方式:
+// string、[]byte 和 io.Reader 原样发送, Content-Type 为表单时按表单处理;
+// url.Values 按表单编码; 其他类型 (map、结构体、切片等) 在 Content-Type 为 XML 时按 XML 编码,
+// 为表单时转换为表单参数, 否则按 JSON 编码, 未设置 Content-Type 时设置为 application/json。
+func (request *Request) detectBodyKind() bodyKind {
+	mediaType, _, _ := mime.ParseMediaType(request.GetHeaderContentType())
+	isForm := request.isFormBody()
+	switch request.Body.(type) {
+	case string, []byte, io.Reader:
+		if isForm {
+			return bodyForm
+		}
+		return bodyRaw
+	case url.Values:
+		return bodyForm
+	}
+	switch {
+	case isForm:
+		return bodyForm
+	case mediaType == xmlContentType || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return bodyXML
+	}
+	return bodyJSON
+}
+
+// isFormBody 方法用于判断请求的 Content-Type 是否为 application/x-www-form-urlencoded, 忽略 charset 等参数。
+func (request *Request) isFormBody() bool {
+	mediaType, _, _ := mime.ParseMediaType(request.GetHeaderContentType())
+	return mediaType == formContentType
+}
+
+// setFormBody 方法用于生成表单请求体。已编码的表单字符串原样发送, url.Values 编码后发送;
+// JSON 字符串、map 和结构体会被转换为表单参数, 与 Query 参数一起编码。
+func (request *Request) setFormBody() error {
+	var form string
+	switch body := request.Body.(type) {
+	case url.Values:
+		request.bodyBytes = []byte(body.Encode())
+		return nil
+	case string:
+		form = body
+	case []byte:
+		form = string(body)
+	case io.Reader:
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		form = string(data)
+	default:
+		var data []byte
+		if err := safeCall("JSONMarshal", func() (err error) {
+			data, err = request.jsonMarshaler()(body)
+			return err
+		}); err != nil {
+			return err
+		}
+		form = string(data)
+	}
+	if gjson.Valid(form) {
+		request.SetQueryParams(request.jsonToMap(form))
+		return nil
+	}
+	if _, err := url.ParseQuery(form); err != nil {
+		return fmt.Errorf("invalid form body: %w", err)
+	}
+	request.bodyBytes = []byte(form)
+	return nil
+}
+
+// setRawBody 方法用于原样写入 string、[]byte 和 io.Reader 类型的请求体。
+// io.Reader 会被完整读取, 以便重试时可以重新发送, 发送大文件请使用 SetBodyFile。
+func (request *Request) setRawBody() error {
+	switch body := request.Body.(type) {
+	case string:
+		request.bodyBytes = []byte(body)
+	case []byte:
+		// 复制一份, 发送之前调用方修改切片不会影响请求体
+		request.bodyBytes = bytes.Clone(body)
+	case io.Reader:
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		request.bodyBytes = data
+	default:
+		return fmt.Errorf("unsupported raw body type %T", body)
+	}
+	return nil
+}
+
+// marshalBody 方法用于使用 marshal 编码请求体, 编码结果直接作为请求体, marshal 发生 panic 时返回 *PanicError。
+func (request *Request) marshalBody(name string, marshal func(v any) ([]byte, error)) error {
+	var body []byte
+	err := safeCall(name, func() (err error) {
+		body, err = marshal(request.Body)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	request.bodyBytes = body
+	return nil
+}
@@ -0,0 +1,94 @@
+package builder
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// SetBodyFile 方法用于把文件作为 HTTP 请求的请求体。它接收一个 string 类型的参数，该参数表示文件路径。
+// 文件在发送时以流的方式读取, 不会一次性读入内存; Content-Length 为文件大小, 未设置 Content-Type 时根据扩展名
+// 或者文件内容自动检测。使用文件作为请求体时, Query 参数总是编码到 URL 中, 且不会经过 SetBodyEncoder 设置的编码器。
+func (request *Request) SetBodyFile(path string) *Request {
+	request.bodyFile = path
+	return request
+}
+
+// statBodyFile 方法用于获取 SetBodyFile 设置的文件的大小, 并在未设置 Content-Type 时自动检测。
+func (request *Request) statBodyFile() (int64, error) {
+	file, err := os.Open(request.bodyFile)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if request.GetHeaderContentType() == "" {
+		contentType, err := detectFileContentType(file)
+		if err != nil {
+			return 0, err
+		}
+		request.SetHeaderContentType(contentType)
+	}
+	return info.Size(), nil
+}
+
+// setBodyFile 方法用于把文件设置为 http.Request 的请求体, 重试时通过 GetBody 重新读取文件。
+func (request *Request) setBodyFile(req *http.Request, size int64) {
+	req.ContentLength = size
+	if size == 0 {
+		// 空文件与没有请求体等价
+		req.Body, req.GetBody = http.NoBody, nil
+		return
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		return &fileBody{path: request.bodyFile}, nil
+	}
+	req.Body, _ = req.GetBody()
+}
+
+// fileBody 类型用于在第一次读取时才打开文件, 请求在发送前失败时不会留下打开的文件。
+type fileBody struct {
+	path string
+	file *os.File
+}
+
+// Read 方法用于读取文件内容, 第一次调用时打开文件。
+func (body *fileBody) Read(p []byte) (int, error) {
+	if body.file == nil {
+		file, err := os.Open(body.path)
+		if err != nil {
+			return 0, err
+		}
+		body.file = file
+	}
+	return body.file.Read(p)
+}
+
+// Close 方法用于关闭已打开的文件。
+func (body *fileBody) Close() error {
+	if body.file == nil {
+		return nil
+	}
+	return body.file.Close()
+}
+
+// detectFileContentType 方法用于根据扩展名检测文件的 Content-Type, 无法识别时读取文件开头的 512 字节进行检测。
+func detectFileContentType(file *os.File) (string, error) {
+	if contentType := mime.TypeByExtension(filepath.Ext(file.Name())); contentType != "" {
+		return contentType, nil
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
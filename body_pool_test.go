@@ -0,0 +1,66 @@
+package builder
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRequestBodyNotPooled 确认发送的请求体不会因为缓冲区放回 bufPool 而被其他请求改写。
+func TestRequestBodyNotPooled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	response, err := client.R().SetBodyRaw([]byte("first"), "text/plain").Post(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 复用 bufPool 中的缓冲区写入其他内容
+	buf := acquireBuffer()
+	buf.WriteString(strings.Repeat("x", 16))
+	releaseBuffer(buf)
+
+	if response.Request.GetBody == nil {
+		t.Fatal("GetBody is nil")
+	}
+	body, err := response.Request.GetBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	if string(data) != "first" {
+		t.Errorf("request body = %q, want %q", data, "first")
+	}
+	if response.String() != "first" {
+		t.Errorf("response = %q, want %q", response.String(), "first")
+	}
+}
+
+// BenchmarkBuildRequestBody 统计生成 JSON 和原始请求体时的内存分配。
+func BenchmarkBuildRequestBody(b *testing.B) {
+	client := NewClient().SetBaseURL("http://127.0.0.1")
+	book := map[string]any{"id": 1, "title": strings.Repeat("三体", 256), "tags": []string{"科幻", "长篇"}}
+	raw := []byte(strings.Repeat("x", 4096))
+	b.Run("json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.R().SetBodyJson(book).Prepare(MethodPost, "/books").Build(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("raw", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.R().SetBodyRaw(raw, "text/plain").Prepare(MethodPost, "/books").Build(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
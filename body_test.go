@@ -0,0 +1,101 @@
+package builder
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// newBodyEchoServer 返回一个把请求的 Content-Type 和请求体以 "Content-Type\n请求体" 格式返回的测试服务器。
+func newBodyEchoServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(r.Header.Get("Content-Type") + "\n" + string(body)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+type bodyBook struct {
+	XMLName xml.Name `json:"-" xml:"book"`
+	ID      int      `json:"id" xml:"id"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+func TestRequestBodyEncoding(t *testing.T) {
+	server := newBodyEchoServer(t)
+	client := NewClient()
+	book := bodyBook{ID: 1, Name: "三体"}
+	tests := []struct {
+		name  string
+		setup func(*Request) *Request
+		want  string
+	}{
+		{"json struct", func(r *Request) *Request { return r.SetBody(book) },
+			"application/json\n" + `{"id":1,"name":"三体"}`},
+		{"SetBodyJson", func(r *Request) *Request { return r.SetBodyJson(map[string]int{"id": 1}) },
+			"application/json\n" + `{"id":1}`},
+		{"SetBodyXml", func(r *Request) *Request { return r.SetBodyXml(book) },
+			"application/xml\n<book><id>1</id><name>三体</name></book>"},
+		{"xml by content type", func(r *Request) *Request {
+			return r.SetHeaderContentType("application/atom+xml").SetBody(book)
+		}, "application/atom+xml\n<book><id>1</id><name>三体</name></book>"},
+		{"raw bytes", func(r *Request) *Request { return r.SetBody([]byte{0x01, 0x02}) },
+			"\n\x01\x02"},
+		{"SetBodyRaw", func(r *Request) *Request { return r.SetBodyRaw([]byte("<a/>"), "text/xml") },
+			"text/xml\n<a/>"},
+		{"io.Reader", func(r *Request) *Request { return r.SetBody(strings.NewReader("plain")) },
+			"\nplain"},
+		{"form string", func(r *Request) *Request { return r.SetBodyFormString("a=1&b=2") },
+			"application/x-www-form-urlencoded\na=1&b=2"},
+		{"url.Values", func(r *Request) *Request { return r.SetBody(url.Values{"a": {"1"}}) },
+			"application/x-www-form-urlencoded\na=1"},
+		{"map as form", func(r *Request) *Request {
+			return r.SetHeaderContentType(formContentType).SetBody(map[string]any{"id": 1})
+		}, "application/x-www-form-urlencoded\nid=1"},
+		{"form merged with query", func(r *Request) *Request {
+			return r.SetBodyFormString("a=1").SetQueryParam("b", "2")
+		}, "application/x-www-form-urlencoded\na=1&b=2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := tt.setup(client.R()).Post(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := response.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestBodyCodec(t *testing.T) {
+	server := newBodyEchoServer(t)
+	client := NewClient().RegisterCodec("application/vnd.*+text", func(v any) ([]byte, error) {
+		return []byte(strings.ToUpper(v.(bodyBook).Name + "!")), nil
+	}, nil)
+	response, err := client.R().SetHeaderContentType("application/vnd.book+text").
+		SetBody(bodyBook{Name: "abc"}).Post(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := response.String(), "application/vnd.book+text\nABC!"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRequestBodyInvalid(t *testing.T) {
+	server := newBodyEchoServer(t)
+	client := NewClient()
+	if _, err := client.R().SetBodyFormString("%zz").Post(server.URL); err == nil {
+		t.Error("invalid form body: expected error")
+	}
+	if _, err := client.R().SetBodyJson(make(chan int)).Post(server.URL); err == nil {
+		t.Error("unsupported json value: expected error")
+	}
+}
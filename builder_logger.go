@@ -1,20 +1,77 @@
 package builder
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/sirupsen/logrus"
+	"mime"
 	"net/http"
+	"strings"
 )
 
-// indentJson 方法用于格式化 JSON 字符串成为 map[string]*json.RawMessage 类型。
-func indentJson(a string) (map[string]*json.RawMessage, error) {
-	var objmap map[string]*json.RawMessage
-	err := json.Unmarshal([]byte(a), &objmap)
-	if err != nil {
-		return nil, err
+// defaultDebugBodyLimit 为调试日志中请求体和响应体的默认最大字节数
+const defaultDebugBodyLimit = 64 * 1024
+
+// SetDebugBodyLimit 方法用于设置调试日志中请求体和响应体的最大字节数。它接收一个 int 类型的参数，
+// 超出部分会被截断, 默认为 64KB, 0 表示不限制。图片、压缩包等二进制内容不会写入日志, 只记录其类型和大小。
+func (client *Client) SetDebugBodyLimit(limit int) *Client {
+	client.debugBodyLimit = limit
+	return client
+}
+
+// isBinaryBody 方法用于根据 Content-Type 和内容判断请求体或响应体是否为二进制数据。
+func isBinaryBody(body []byte, contentType string) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch {
+		case strings.HasPrefix(mediaType, "text/"), strings.HasSuffix(mediaType, "json"), strings.HasSuffix(mediaType, "xml"),
+			mediaType == formContentType, mediaType == "application/javascript":
+			return false
+		case strings.HasPrefix(mediaType, "image/"), strings.HasPrefix(mediaType, "audio/"), strings.HasPrefix(mediaType, "video/"),
+			strings.HasPrefix(mediaType, "font/"), mediaType == "application/octet-stream", mediaType == "application/pdf",
+			strings.Contains(mediaType, "zip"), strings.Contains(mediaType, "compressed"):
+			return true
+		}
 	}
-	return objmap, nil
+	// 无法从 Content-Type 判断时根据内容检测, GBK 等非 UTF-8 编码的文本同样被视为文本
+	return !strings.HasPrefix(http.DetectContentType(body), "text/")
 }
+
+// formatDebugBody 方法用于按 SetDebugBodyLimit 的设置格式化写入调试日志的请求体或响应体。
+// JSON 内容会被缩进后写入日志, 超出限制时按行截断。
+func (client *Client) formatDebugBody(body []byte, contentType string) any {
+	if isBinaryBody(body, contentType) {
+		if contentType == "" {
+			contentType = http.DetectContentType(body)
+		}
+		return fmt.Sprintf("[binary body: %d bytes, %s]", len(body), contentType)
+	}
+	if indented := indentJson(body); indented != nil {
+		if client.debugBodyLimit > 0 && len(indented) > client.debugBodyLimit {
+			truncated := fmt.Sprintf("%s...[truncated, %d bytes total]", indented[:client.debugBodyLimit], len(body))
+			return strings.Split(truncated, "\n")
+		}
+		return json.RawMessage(indented)
+	}
+	if client.debugBodyLimit > 0 && len(body) > client.debugBodyLimit {
+		return fmt.Sprintf("%s...[truncated, %d bytes total]", body[:client.debugBodyLimit], len(body))
+	}
+	return string(body)
+}
+
+// indentJson 方法用于缩进 JSON 内容, 内容不是 JSON 对象或数组时返回 nil。
+func indentJson(body []byte) []byte {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') || !json.Valid(trimmed) {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, trimmed, "", "  "); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
+
 func header2Map(header http.Header) map[string]string {
 	h := make(map[string]string)
 	for k, v := range header {
@@ -25,13 +82,15 @@ func header2Map(header http.Header) map[string]string {
 
 // newFormatRequestLogText 方法用于格式化 HTTP 请求的日志信息。
 func newFormatRequestLogText(request *Request) logrus.Fields {
-	var body string
-	if body = request.GetQueryParamsEncode(); body == "" {
-		if request.bodyBytes != nil {
-			body = string(request.bodyBytes)
-		} else {
-			body = "this request has no body"
-		}
+	var body any
+	if query := request.GetQueryParamsEncode(); query != "" {
+		body = query
+	} else if request.bodyFile != "" {
+		body = "file: " + request.bodyFile
+	} else if request.bodyBytes != nil {
+		body = request.client.formatDebugBody(request.bodyBytes, request.GetHeaderContentType())
+	} else {
+		body = "this request has no body"
 	}
 	fields := logrus.Fields{
 		"Method":  request.GetMethod(),
@@ -45,7 +104,7 @@ func newFormatRequestLogText(request *Request) logrus.Fields {
 	} else {
 		fields["Cookie"] = "this request has no cookies"
 	}
-	return fields
+	return request.logFields(fields)
 }
 
 // newFormatResponseLogText 方法用于格式化 HTTP 响应的日志信息。
@@ -63,11 +122,11 @@ func newFormatResponseLogText(response *Response) logrus.Fields {
 		}
 		fields["Header"] = header
 	}
-	result := response.String()
-	if objmap, err := indentJson(result); err != nil {
-		fields["Result"] = result
+	if response.RequestSource.doNotParse {
+		// 以流的方式读取的响应体由调用者读取, 日志不能提前消耗
+		fields["Result"] = "response body is streamed"
 	} else {
-		fields["Result"] = objmap
+		fields["Result"] = response.RequestSource.client.formatDebugBody(response.GetByte(), response.GetHeader().Get("Content-Type"))
 	}
-	return fields
+	return response.RequestSource.logFields(fields)
 }
@@ -0,0 +1,561 @@
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheMode 类型用于表示缓存模式, 多个模式可以按位组合使用。
+type CacheMode int
+
+const (
+	// ServeStaleOnError 模式下请求失败或服务器返回 5xx 时, 返回已缓存的响应。
+	ServeStaleOnError CacheMode = 1 << iota
+
+	// StaleWhileRevalidate 模式下存在缓存时立即返回缓存的响应, 缓存过期时在后台重新请求并刷新缓存。
+	StaleWhileRevalidate
+
+	// OfflineOnly 模式下只使用缓存响应请求, 不发送任何网络请求。
+	OfflineOnly
+)
+
+// ErrCacheMiss 表示 OfflineOnly 模式下请求的 URL 没有可用的缓存。
+var ErrCacheMiss = errors.New("cache Error: no cached response for this request")
+
+// maxCacheEntries 为 EnableConditionalRequest 和 SetCacheMode 使用的缓存的最大条目数, 达到上限时清除最早保存的条目, 直到低于上限的 90%。
+const maxCacheEntries = 4096
+
+// credentialHeaders 为区分不同账号的请求头, 携带这些请求头的请求按照它们的值分别缓存。
+var credentialHeaders = []string{"Authorization", "Cookie"}
+
+// cacheEntry 类型用于存储一条已缓存的 HTTP 响应。
+type cacheEntry struct {
+	status       string
+	statusCode   int
+	proto        string
+	header       http.Header
+	body         []byte
+	etag         string      // etag 用于存储响应的 ETag 校验值
+	lastModified string      // lastModified 用于存储响应的 Last-Modified 校验值
+	vary         http.Header // vary 用于存储响应的 Vary 头部列出的请求头在保存时的值
+	storedAt     time.Time
+	expiresAt    time.Time // expiresAt 用于存储缓存的过期时间, 零值表示缓存总是需要重新验证
+}
+
+// isFresh 方法用于判断缓存条目是否仍在有效期内。
+func (entry *cacheEntry) isFresh() bool {
+	return !entry.expiresAt.IsZero() && time.Now().Before(entry.expiresAt)
+}
+
+// hasValidator 方法用于判断缓存条目是否携带可用于条件请求的校验值。
+func (entry *cacheEntry) hasValidator() bool {
+	return entry.etag != "" || entry.lastModified != ""
+}
+
+// Cache 类型用于存储 HTTP 响应缓存, 以请求的 Method 和完整 URL 作为键, 例如 "GET https://example.com/book?id=1",
+// 携带 Authorization 或 Cookie 请求头的请求在键的末尾加上空格和它们的摘要, 不同账号的响应不会混用。
+type Cache struct {
+	sync.RWMutex
+	entries      map[string]*cacheEntry
+	revalidating map[string]bool          // revalidating 用于记录正在后台重新验证的缓存键
+	hits         atomic.Int64             // hits 用于统计使用缓存响应的请求数
+	misses       atomic.Int64             // misses 用于统计没有可用缓存的请求数
+	inflight     map[string]chan struct{} // inflight 用于记录 SetCacheTTL 正在从网络获取的缓存键, 请求完成时关闭对应的 channel
+}
+
+// newCache 方法用于创建一个新的 Cache 对象。
+func newCache() *Cache {
+	return &Cache{entries: map[string]*cacheEntry{}, revalidating: map[string]bool{}, inflight: map[string]chan struct{}{}}
+}
+
+// cacheKey 方法用于生成 HTTP 请求的缓存键, 请求携带 credentialHeaders 时加上它们的值的摘要。
+func cacheKey(req *http.Request) string {
+	key := req.Method + " " + req.URL.String()
+	hash := sha256.New()
+	found := false
+	for _, name := range credentialHeaders {
+		for _, value := range req.Header.Values(name) {
+			found = true
+			hash.Write([]byte(name + ": " + value + "\n"))
+		}
+	}
+	if !found {
+		return key
+	}
+	return key + " " + hex.EncodeToString(hash.Sum(nil)[:8])
+}
+
+// cacheKeyURL 方法用于从缓存键中取出 URL。
+func cacheKeyURL(key string) string {
+	u := key[strings.IndexByte(key, ' ')+1:]
+	if i := strings.IndexByte(u, ' '); i >= 0 {
+		u = u[:i]
+	}
+	return u
+}
+
+func (cache *Cache) get(key string) (*cacheEntry, bool) {
+	cache.RLock()
+	defer cache.RUnlock()
+	entry, ok := cache.entries[key]
+	return entry, ok
+}
+
+func (cache *Cache) set(key string, entry *cacheEntry) {
+	cache.Lock()
+	defer cache.Unlock()
+	if _, ok := cache.entries[key]; !ok && len(cache.entries) >= maxCacheEntries {
+		cache.evictLocked(maxCacheEntries*9/10, func(a, b *cacheEntry) bool { return a.storedAt.Before(b.storedAt) })
+	}
+	cache.entries[key] = entry
+}
+
+// evictLocked 方法用于按照 before 的顺序清除排在前面的条目, 直到条目数不超过 target, 调用者需要持有写锁。
+func (cache *Cache) evictLocked(target int, before func(a, b *cacheEntry) bool) {
+	if len(cache.entries) <= target {
+		return
+	}
+	keys := make([]string, 0, len(cache.entries))
+	for k := range cache.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return before(cache.entries[keys[i]], cache.entries[keys[j]])
+	})
+	for _, k := range keys[:len(keys)-target] {
+		delete(cache.entries, k)
+	}
+}
+
+// record 方法用于统计一次缓存命中或未命中。
+func (cache *Cache) record(hit bool) {
+	if hit {
+		cache.hits.Add(1)
+	} else {
+		cache.misses.Add(1)
+	}
+}
+
+// Cache 方法用于获取 Client 的 HTTP 响应缓存 (EnableConditionalRequest 和 SetCacheMode 使用的缓存),
+// 可以在已知上游数据更新后清除过期的条目。未开启缓存时返回一个空的缓存。
+func (client *Client) Cache() *Cache {
+	client.Lock()
+	defer client.Unlock()
+	if client.cache == nil {
+		client.cache = newCache()
+	}
+	return client.cache
+}
+
+// MemoCache 方法用于获取 Request.SetCacheTTL 使用的内存缓存。
+func (client *Client) MemoCache() *Cache {
+	client.Lock()
+	defer client.Unlock()
+	if client.memo == nil {
+		client.memo = newCache()
+	}
+	return client.memo
+}
+
+// Invalidate 方法用于删除 URL 匹配 urlPattern 的缓存条目。它接收一个 string 类型的参数，该参数表示 URL 的匹配模式,
+// 其中 * 匹配任意字符 (包括 /), 例如 https://example.com/catalog/* 或 *book_id=42*; 不含 * 时只删除 URL 完全相同的条目。
+// 它返回一个 int 类型的参数，表示删除的条目数。
+func (cache *Cache) Invalidate(urlPattern string) int {
+	cache.Lock()
+	defer cache.Unlock()
+	removed := 0
+	for key := range cache.entries {
+		if matchWildcard(urlPattern, cacheKeyURL(key)) {
+			delete(cache.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Len 方法用于获取缓存条目的数量。
+func (cache *Cache) Len() int {
+	cache.RLock()
+	defer cache.RUnlock()
+	return len(cache.entries)
+}
+
+// Keys 方法用于获取所有缓存条目的键, 键的格式为 Method 加空格加完整 URL, 携带凭据的请求再加上空格和凭据的摘要, 按字典序排序。
+func (cache *Cache) Keys() []string {
+	cache.RLock()
+	keys := make([]string, 0, len(cache.entries))
+	for key := range cache.entries {
+		keys = append(keys, key)
+	}
+	cache.RUnlock()
+	sort.Strings(keys)
+	return keys
+}
+
+// Hits 方法用于获取使用缓存响应的请求数, 包括服务器返回 304 后使用缓存响应体的请求。
+func (cache *Cache) Hits() int64 {
+	return cache.hits.Load()
+}
+
+// Misses 方法用于获取查找缓存时没有可用条目、需要从网络获取完整响应的请求数。
+func (cache *Cache) Misses() int64 {
+	return cache.misses.Load()
+}
+
+// matchWildcard 方法用于判断 s 是否匹配只包含 * 通配符的 pattern。
+func matchWildcard(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
+
+// newCacheEntry 方法用于根据 HTTP 请求、响应和响应体创建缓存条目。
+func newCacheEntry(req *http.Request, raw *http.Response, body []byte) *cacheEntry {
+	entry := &cacheEntry{
+		status:       raw.Status,
+		statusCode:   raw.StatusCode,
+		proto:        raw.Proto,
+		header:       raw.Header.Clone(),
+		body:         body,
+		etag:         raw.Header.Get("ETag"),
+		lastModified: raw.Header.Get("Last-Modified"),
+		storedAt:     time.Now(),
+	}
+	if maxAge, ok := parseMaxAge(raw.Header.Get("Cache-Control")); ok {
+		entry.expiresAt = entry.storedAt.Add(maxAge)
+	}
+	for _, name := range varyHeaders(raw.Header) {
+		if entry.vary == nil {
+			entry.vary = http.Header{}
+		}
+		entry.vary[name] = req.Header.Values(name)
+	}
+	return entry
+}
+
+// varyHeaders 方法用于获取 Vary 头部列出的请求头名称。
+func varyHeaders(header http.Header) []string {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+// matchVary 方法用于判断请求的 Vary 请求头是否与保存缓存条目时的请求相同, 不同时不能使用该条目。
+func (entry *cacheEntry) matchVary(req *http.Request) bool {
+	for name, values := range entry.vary {
+		if strings.Join(req.Header.Values(name), ", ") != strings.Join(values, ", ") {
+			return false
+		}
+	}
+	return true
+}
+
+// isStorable 方法用于判断响应是否允许被缓存: Cache-Control 包含 no-store 或 private, 或者 Vary 为 * 时不缓存。
+// Client 可能被多个账号共用, 因此按照共享缓存的规则处理 private。
+func (entry *cacheEntry) isStorable() bool {
+	for _, directive := range strings.Split(entry.header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		if directive == "no-store" || directive == "private" || strings.HasPrefix(directive, "private=") {
+			return false
+		}
+	}
+	_, wildcard := entry.vary["*"]
+	return !wildcard
+}
+
+// parseMaxAge 方法用于从 Cache-Control 头部中解析 max-age 指令。
+func parseMaxAge(cacheControl string) (time.Duration, bool) {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(strings.ToLower(directive))
+		if !strings.HasPrefix(directive, "max-age=") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(directive, "max-age="), `"`))
+		if err != nil || seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	return 0, false
+}
+
+// setConditionalHeader 方法用于根据缓存条目为请求设置 If-None-Match 和 If-Modified-Since 请求头。
+func (entry *cacheEntry) setConditionalHeader(req *http.Request) {
+	// 调用方显式设置的条件请求头优先
+	if entry.etag != "" && req.Header.Get("If-None-Match") == "" {
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+	if entry.lastModified != "" && req.Header.Get("If-Modified-Since") == "" {
+		req.Header.Set("If-Modified-Since", entry.lastModified)
+	}
+}
+
+// newResponseRaw 方法用于根据缓存条目构造一个 http.Response, notModified 为服务器返回的 304 响应。
+func (entry *cacheEntry) newResponseRaw(notModified *http.Response) *http.Response {
+	header := entry.header.Clone()
+	if notModified != nil {
+		// 304 响应中携带的头部会更新已缓存的头部
+		for key, values := range notModified.Header {
+			header[key] = values
+		}
+	}
+	raw := &http.Response{
+		Status:        entry.status,
+		StatusCode:    entry.statusCode,
+		Proto:         entry.proto,
+		Header:        header,
+		Body:          http.NoBody,
+		ContentLength: int64(len(entry.body)),
+	}
+	if notModified != nil {
+		raw.ProtoMajor, raw.ProtoMinor = notModified.ProtoMajor, notModified.ProtoMinor
+		raw.Request = notModified.Request
+		raw.TLS = notModified.TLS
+	}
+	return raw
+}
+
+// EnableConditionalRequest 方法用于开启条件请求。开启后 GET 请求的响应会按 URL 缓存其 ETag 和 Last-Modified,
+// 之后对同一 URL 的请求会自动携带 If-None-Match 和 If-Modified-Since, 服务器返回 304 时透明地返回缓存的响应体。
+func (client *Client) EnableConditionalRequest() *Client {
+	client.Lock()
+	defer client.Unlock()
+	client.conditionalRequest = true
+	if client.cache == nil {
+		client.cache = newCache()
+	}
+	return client
+}
+
+// SetCacheMode 方法用于设置缓存模式。它接收一个 CacheMode 类型的参数，多个模式可以使用 | 组合。
+// 设置缓存模式后, 状态码为 200 的 GET 响应都会被缓存, Cache-Control 包含 no-store 或 private 的响应除外,
+// 响应的 Vary 头部列出的请求头不同时不会使用缓存。
+func (client *Client) SetCacheMode(mode CacheMode) *Client {
+	client.Lock()
+	defer client.Unlock()
+	client.cacheMode = mode
+	if client.cache == nil {
+		client.cache = newCache()
+	}
+	return client
+}
+
+// cacheSettings 方法用于在读锁的保护下获取缓存、缓存模式以及是否开启了条件请求。
+func (client *Client) cacheSettings() (*Cache, CacheMode, bool) {
+	client.RLock()
+	defer client.RUnlock()
+	return client.cache, client.cacheMode, client.conditionalRequest
+}
+
+// doWithCache 方法用于在缓存的参与下执行 HTTP 请求。
+func (request *Request) doWithCache() (*Response, error) {
+	client := request.client
+	cache, mode, _ := client.cacheSettings()
+	if request.doNotParse {
+		// 缓存需要读取完整的响应体, 以流的方式读取的响应不经过缓存
+		return request.newDoRequest()
+	}
+	if request.cacheTTL > 0 && request.NewRequest.Method == MethodGet {
+		return request.doWithMemo()
+	}
+	key, entry := request.prepareCache()
+	if mode&OfflineOnly != 0 {
+		if key != "" {
+			cache.record(entry != nil)
+		}
+		if entry == nil {
+			return nil, ErrCacheMiss
+		}
+		return request.newCacheResponse(entry), nil
+	}
+	if entry != nil && mode&StaleWhileRevalidate != 0 {
+		cache.record(true)
+		if !entry.isFresh() {
+			// 原请求不会被发送, 请求体 (如果有) 是不属于 bufPool 的副本, 克隆后即可脱离原请求的上下文在后台独立发送, Client 关闭时取消
+			req := request.NewRequest.Clone(client.closeCtx)
+			client.goBackground(func() {
+				request.revalidate(key, entry, req)
+			})
+		}
+		return request.newCacheResponse(entry), nil
+	}
+	response, err := request.newDoRequest()
+	if entry != nil && mode&ServeStaleOnError != 0 {
+		if err != nil {
+			request.LogError(err, key, "cache.go", "ServeStaleOnError")
+			cache.record(true)
+			return request.newCacheResponse(entry), nil
+		}
+		if response.GetStatusCode() >= http.StatusInternalServerError {
+			// 读取并关闭失败响应的响应体后返回缓存
+			_, _ = response.readBody()
+			cache.record(true)
+			return request.newCacheResponse(entry), nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = request.updateCache(key, entry, response); err != nil {
+		return nil, err
+	}
+	if key != "" {
+		cache.record(response.fromCache)
+	}
+	return response, nil
+}
+
+// prepareCache 方法用于在发送请求前查找缓存条目, 并在开启条件请求时设置条件请求头。
+func (request *Request) prepareCache() (string, *cacheEntry) {
+	cache, mode, conditional := request.client.cacheSettings()
+	if cache == nil || (mode == 0 && !conditional) || request.NewRequest.Method != MethodGet {
+		return "", nil
+	}
+	key := cacheKey(request.NewRequest)
+	entry, ok := cache.get(key)
+	if !ok || !entry.matchVary(request.NewRequest) {
+		return key, nil
+	}
+	if conditional && entry.hasValidator() {
+		entry.setConditionalHeader(request.NewRequest)
+	}
+	return key, entry
+}
+
+// newCacheResponse 方法用于根据缓存条目创建一个 Response 对象。
+func (request *Request) newCacheResponse(entry *cacheEntry) *Response {
+	raw := entry.newResponseRaw(nil)
+	raw.Request = request.NewRequest
+	return &Response{
+		Request:       request.NewRequest,
+		ResponseRaw:   raw,
+		RequestSource: request,
+		body:          entry.body,
+		bodyRead:      true,
+		fromCache:     true,
+	}
+}
+
+// updateCache 方法用于在收到响应后更新缓存, 服务器返回 304 时使用缓存条目替换响应。
+func (request *Request) updateCache(key string, entry *cacheEntry, response *Response) error {
+	if key == "" {
+		return nil
+	}
+	cache, _, _ := request.client.cacheSettings()
+	raw := response.ResponseRaw
+	if raw.StatusCode != http.StatusOK && raw.StatusCode != http.StatusNotModified {
+		return nil
+	}
+	// 先读取并关闭响应体, 304 响应的空响应体同样需要关闭以复用连接
+	body, err := response.readBody()
+	if err != nil {
+		return err
+	}
+	if raw.StatusCode == http.StatusNotModified {
+		if entry != nil {
+			response.ResponseRaw = entry.newResponseRaw(raw)
+			response.body, response.bodyRead = entry.body, true
+			response.fromCache = true
+			cache.refresh(key, entry, raw)
+		}
+		return nil
+	}
+	cache.store(key, newCacheEntry(request.NewRequest, raw, body), request.client)
+	return nil
+}
+
+// store 方法用于按客户端的缓存配置保存缓存条目。
+func (cache *Cache) store(key string, entry *cacheEntry, client *Client) {
+	if !entry.isStorable() {
+		cache.Lock()
+		delete(cache.entries, key)
+		cache.Unlock()
+		return
+	}
+	// 仅开启条件请求时, 只有携带校验值的响应才值得缓存
+	if _, mode, conditional := client.cacheSettings(); mode != 0 || (conditional && entry.hasValidator()) {
+		cache.set(key, entry)
+	}
+}
+
+// refresh 方法用于在服务器返回 304 后刷新缓存条目的头部和有效期。
+func (cache *Cache) refresh(key string, entry *cacheEntry, notModified *http.Response) {
+	refreshed := *entry
+	refreshed.header = entry.newResponseRaw(notModified).Header
+	refreshed.storedAt = time.Now()
+	refreshed.expiresAt = time.Time{}
+	if maxAge, ok := parseMaxAge(refreshed.header.Get("Cache-Control")); ok {
+		refreshed.expiresAt = refreshed.storedAt.Add(maxAge)
+	}
+	cache.set(key, &refreshed)
+}
+
+// revalidate 方法用于在后台重新请求已过期的缓存条目并刷新缓存, 同一个缓存键同时只会有一个后台请求。
+func (request *Request) revalidate(key string, entry *cacheEntry, req *http.Request) {
+	cache, _, _ := request.client.cacheSettings()
+	cache.Lock()
+	if cache.revalidating[key] {
+		cache.Unlock()
+		return
+	}
+	cache.revalidating[key] = true
+	cache.Unlock()
+	defer func() {
+		cache.Lock()
+		delete(cache.revalidating, key)
+		cache.Unlock()
+	}()
+
+	if entry.hasValidator() {
+		entry.setConditionalHeader(req)
+	}
+	raw, err := request.client.httpClientRaw.Do(req)
+	if err != nil {
+		request.LogError(err, key, "cache.go", "revalidate")
+		return
+	}
+	response := &Response{Request: req, ResponseRaw: raw, RequestSource: request}
+	body, err := response.readBody()
+	if err != nil {
+		request.LogError(err, key, "cache.go", "revalidate")
+		return
+	}
+	switch raw.StatusCode {
+	case http.StatusNotModified:
+		cache.refresh(key, entry, raw)
+	case http.StatusOK:
+		cache.store(key, newCacheEntry(req, raw, body), request.client)
+	}
+}
+
+// IsFromCache 方法用于判断 HTTP 响应是否来自缓存。
+func (response *Response) IsFromCache() bool {
+	return response.fromCache
+}
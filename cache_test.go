@@ -0,0 +1,109 @@
+package builder
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRevalidateRacesClose 确认后台重新验证与 Close 并发时不会在 Wait 之后调用 WaitGroup.Add。
+func TestRevalidateRacesClose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=0")
+		_, _ = w.Write([]byte("catalog"))
+	}))
+	defer server.Close()
+
+	for i := 0; i < 20; i++ {
+		client := NewClient().SetCacheMode(StaleWhileRevalidate)
+		if _, err := client.R().Get(server.URL); err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = client.R().Get(server.URL)
+			}()
+		}
+		if err := client.Close(); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+	}
+}
+
+// TestCacheSeparatesCredentials 确认不同 Authorization 和 Cookie 的响应分别缓存, 并且遵守 Vary、no-store 和 private。
+func TestCacheSeparatesCredentials(t *testing.T) {
+	var hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+		default:
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Authorization") + r.Header.Get("Cookie") + r.Header.Get("Accept-Language")))
+	}))
+	defer server.Close()
+	client := NewClient().SetCacheMode(StaleWhileRevalidate)
+
+	get := func(path string, set func(*Request)) string {
+		request := client.R()
+		if set != nil {
+			set(request)
+		}
+		response, err := request.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response.String()
+	}
+	alice := func(r *Request) { r.SetAuthToken("alice") }
+	bob := func(r *Request) { r.SetAuthToken("bob") }
+	if get("/shelf", alice) != "alice" || get("/shelf", bob) != "bob" || get("/shelf", alice) != "alice" {
+		t.Error("a response cached for one account was served to another")
+	}
+	if get("/shelf", func(r *Request) { r.SetCookie(&http.Cookie{Name: "uid", Value: "2"}) }) != "uid=2" {
+		t.Error("a response cached without cookies was served to a request with cookies")
+	}
+	if got := hits.Load(); got != 3 {
+		t.Errorf("server hits = %d, want 3", got)
+	}
+
+	zh := func(r *Request) { r.SetHeader("Accept-Language", "zh") }
+	en := func(r *Request) { r.SetHeader("Accept-Language", "en") }
+	if get("/vary", zh) != "zh" || get("/vary", en) != "en" {
+		t.Error("Vary was ignored")
+	}
+	hits.Store(0)
+	get("/no-store", nil)
+	get("/no-store", nil)
+	get("/private", nil)
+	get("/private", nil)
+	if got := hits.Load(); got != 4 {
+		t.Errorf("no-store/private server hits = %d, want 4", got)
+	}
+}
+
+// TestCacheBounded 确认缓存的条目数不超过上限。
+func TestCacheBounded(t *testing.T) {
+	cache := newCache()
+	for i := 0; i < maxCacheEntries+10; i++ {
+		cache.set(fmt.Sprintf("GET https://example.com/%d", i), &cacheEntry{storedAt: time.Now()})
+	}
+	if got := cache.Len(); got > maxCacheEntries {
+		t.Errorf("Len = %d, want at most %d", got, maxCacheEntries)
+	}
+}
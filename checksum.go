@@ -0,0 +1,118 @@
+package builder
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// ChecksumError 类型用于表示响应体的校验和与期望值不一致。
+type ChecksumError struct {
+	Algorithm string // 校验算法
+	Expected  string // 期望的校验和 (十六进制)
+	Actual    string // 实际的校验和 (十六进制)
+}
+
+// Error 方法用于获取错误的描述。
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum Error: %s mismatch, expected %s, got %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+// newChecksumHash 方法用于根据算法名称创建 hash.Hash, 支持 md5、sha1、sha256 和 sha512。
+func newChecksumHash(algorithm string) (hash.Hash, error) {
+	switch strings.ReplaceAll(strings.ToLower(algorithm), "-", "") {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("checksum Error: unsupported algorithm %q", algorithm)
+}
+
+// checksum 类型用于存储期望的校验和。
+type checksum struct {
+	algorithm string
+	expected  []byte
+}
+
+// SetExpectedChecksum 方法用于设置响应体期望的校验和。它接收两个 string 类型的参数，分别表示算法
+// (md5、sha1、sha256 或 sha512) 和十六进制的校验和。设置后 Response.SaveFile 会在写入时计算校验和,
+// 不一致时删除已写入的内容并返回 *ChecksumError。配合 SetDoNotParseResponse 时响应体从网络直接写入文件, 不会保存在内存中。
+func (request *Request) SetExpectedChecksum(algorithm, hexSum string) *Request {
+	sum, err := newChecksum(algorithm, hexSum)
+	if err != nil {
+		request.LogError(err, hexSum, "checksum.go", "SetExpectedChecksum")
+		return request
+	}
+	request.checksum = sum
+	return request
+}
+
+// newChecksum 方法用于根据算法名称和十六进制的校验和创建 checksum, 算法不支持或者校验和格式错误时返回错误。
+func newChecksum(algorithm, hexSum string) (*checksum, error) {
+	expected, err := hex.DecodeString(strings.TrimSpace(hexSum))
+	if err != nil {
+		return nil, fmt.Errorf("checksum Error: %w", err)
+	}
+	if _, err = newChecksumHash(algorithm); err != nil {
+		return nil, err
+	}
+	return &checksum{algorithm: algorithm, expected: expected}, nil
+}
+
+// verify 方法用于比较 h 计算出的校验和与期望值, 不一致时返回 *ChecksumError。
+func (c *checksum) verify(h hash.Hash) error {
+	if actual := h.Sum(nil); !bytes.Equal(actual, c.expected) {
+		return &ChecksumError{
+			Algorithm: c.algorithm,
+			Expected:  hex.EncodeToString(c.expected),
+			Actual:    hex.EncodeToString(actual),
+		}
+	}
+	return nil
+}
+
+// checksumReader 类型用于在读取的同时计算校验和, 读取结束时校验和不一致则返回 *ChecksumError。
+type checksumReader struct {
+	reader   io.Reader
+	hash     hash.Hash
+	checksum *checksum
+}
+
+// Read 方法用于读取数据并更新校验和。
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF {
+		if sumErr := r.checksum.verify(r.hash); sumErr != nil {
+			return n, sumErr
+		}
+	}
+	return n, err
+}
+
+// newChecksumReader 方法用于在请求设置了期望的校验和时包装 reader, 否则直接返回 reader。
+func (request *Request) newChecksumReader(reader io.Reader) io.Reader {
+	if request.checksum == nil {
+		return reader
+	}
+	h, _ := newChecksumHash(request.checksum.algorithm)
+	return &checksumReader{reader: reader, hash: h, checksum: request.checksum}
+}
+
+// VerifyChecksum 方法用于校验响应体是否与请求设置的期望校验和一致, 未设置期望的校验和时返回 nil。
+func (response *Response) VerifyChecksum() error {
+	_, err := io.Copy(io.Discard, response.RequestSource.newChecksumReader(bytes.NewReader(response.GetByte())))
+	return err
+}
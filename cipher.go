@@ -0,0 +1,254 @@
+package builder
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// CipherMode 类型用于表示分组密码的工作模式。
+type CipherMode int
+
+const (
+	// CipherCBC 为 CBC 模式, 需要与分组长度相同的 IV
+	CipherCBC CipherMode = iota
+
+	// CipherECB 为 ECB 模式, 不使用 IV
+	CipherECB
+
+	// CipherCTR 为 CTR 模式, 需要与分组长度相同的 IV, 不使用填充
+	CipherCTR
+)
+
+// Padding 类型用于表示分组密码的填充方式。
+type Padding int
+
+const (
+	// PaddingPKCS7 为 PKCS#7 填充 (对 DES 而言即 PKCS#5 填充)
+	PaddingPKCS7 Padding = iota
+
+	// PaddingZero 为零字节填充
+	PaddingZero
+
+	// PaddingNone 表示不使用填充, 数据长度必须是分组长度的整数倍
+	PaddingNone
+)
+
+// Base64Decoder 为 base64 解码的 ResponseDecoder, 兼容标准和 URL 安全字符集以及省略填充的写法。
+func Base64Decoder(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if b, err := encoding.DecodeString(v); err == nil {
+			return string(b), nil
+		}
+	}
+	return "", fmt.Errorf("base64 Error: invalid base64 data")
+}
+
+// AESDecoder 方法用于创建一个 AES 解密的 ResponseDecoder。它接收工作模式、密钥、IV 和填充方式,
+// 密钥长度为 16、24 或 32 字节时分别对应 AES-128、AES-192 和 AES-256, ECB 模式下 IV 会被忽略。
+func AESDecoder(mode CipherMode, key, iv []byte, padding Padding) ResponseDecoder {
+	return func(v string) (string, error) {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return "", err
+		}
+		b, err := decryptBlocks(block, mode, iv, []byte(v), padding)
+		return string(b), err
+	}
+}
+
+// DESDecoder 方法用于创建一个 DES 解密的 ResponseDecoder。它接收工作模式、密钥、IV 和填充方式,
+// 密钥长度为 8 字节时使用 DES, 为 24 字节时使用 3DES, ECB 模式下 IV 会被忽略。
+func DESDecoder(mode CipherMode, key, iv []byte, padding Padding) ResponseDecoder {
+	return func(v string) (string, error) {
+		block, err := newDESCipher(key)
+		if err != nil {
+			return "", err
+		}
+		b, err := decryptBlocks(block, mode, iv, []byte(v), padding)
+		return string(b), err
+	}
+}
+
+// newDESCipher 方法用于根据密钥长度创建 DES 或 3DES 分组密码。
+func newDESCipher(key []byte) (cipher.Block, error) {
+	if len(key) == 24 {
+		return des.NewTripleDESCipher(key)
+	}
+	return des.NewCipher(key)
+}
+
+// SetAESDecrypt 方法用于为客户端的响应解码管道追加 base64 解码和 AES 解密。它接收密钥、IV 和填充方式,
+// IV 为空时使用 ECB 模式, 否则使用 CBC 模式; 其他模式可以通过 AddResponseDecoder(AESDecoder(...)) 设置。
+func (client *Client) SetAESDecrypt(key, iv []byte, padding Padding) *Client {
+	mode := CipherCBC
+	if len(iv) == 0 {
+		mode = CipherECB
+	}
+	return client.AddResponseDecoder(Base64Decoder).AddResponseDecoder(AESDecoder(mode, key, iv, padding))
+}
+
+// SetDESDecrypt 方法用于为客户端的响应解码管道追加 base64 解码和 DES 解密。它接收密钥、IV 和填充方式,
+// IV 为空时使用 ECB 模式, 否则使用 CBC 模式。
+func (client *Client) SetDESDecrypt(key, iv []byte, padding Padding) *Client {
+	mode := CipherCBC
+	if len(iv) == 0 {
+		mode = CipherECB
+	}
+	return client.AddResponseDecoder(Base64Decoder).AddResponseDecoder(DESDecoder(mode, key, iv, padding))
+}
+
+// BodyEncoder 类型用于对序列化后的请求体进行转换, 例如加密或混淆。
+type BodyEncoder func(body []byte) ([]byte, error)
+
+// SetBodyEncoder 方法用于设置请求体编码器。它接收一个或多个 BodyEncoder 类型的参数，
+// 请求体在序列化 (以及写入表单参数) 之后会按顺序经过每个编码器, 不传入参数时取消编码。
+func (client *Client) SetBodyEncoder(encoders ...BodyEncoder) *Client {
+	client.bodyEncoders = encoders
+	return client
+}
+
+// encodeBody 方法用于让请求体依次经过客户端的请求体编码器。
+func (request *Request) encodeBody(body []byte) ([]byte, error) {
+	var err error
+	for i, encoder := range request.client.bodyEncoders {
+		err = safeCall("BodyEncoder", func() (err error) {
+			body, err = encoder(body)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("encode Error: encoder %d: %w", i, err)
+		}
+	}
+	return body, nil
+}
+
+// Base64Encoder 为使用标准字符集进行 base64 编码的 BodyEncoder。
+func Base64Encoder(body []byte) ([]byte, error) {
+	out := make([]byte, base64.StdEncoding.EncodedLen(len(body)))
+	base64.StdEncoding.Encode(out, body)
+	return out, nil
+}
+
+// AESEncoder 方法用于创建一个 AES 加密的 BodyEncoder, 参数与 AESDecoder 相同。
+func AESEncoder(mode CipherMode, key, iv []byte, padding Padding) BodyEncoder {
+	return func(body []byte) ([]byte, error) {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return encryptBlocks(block, mode, iv, body, padding)
+	}
+}
+
+// DESEncoder 方法用于创建一个 DES 或 3DES 加密的 BodyEncoder, 参数与 DESDecoder 相同。
+func DESEncoder(mode CipherMode, key, iv []byte, padding Padding) BodyEncoder {
+	return func(body []byte) ([]byte, error) {
+		block, err := newDESCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return encryptBlocks(block, mode, iv, body, padding)
+	}
+}
+
+// encryptBlocks 方法用于按指定的工作模式和填充方式加密数据。
+func encryptBlocks(block cipher.Block, mode CipherMode, iv, data []byte, padding Padding) ([]byte, error) {
+	size := block.BlockSize()
+	if mode != CipherECB && len(iv) != size {
+		return nil, fmt.Errorf("cipher Error: iv length must be %d bytes", size)
+	}
+	if mode == CipherCTR {
+		out := make([]byte, len(data))
+		cipher.NewCTR(block, iv).XORKeyStream(out, data)
+		return out, nil
+	}
+	data, err := pad(data, size, padding)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data))
+	switch mode {
+	case CipherCBC:
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, data)
+	case CipherECB:
+		for i := 0; i < len(data); i += size {
+			block.Encrypt(out[i:i+size], data[i:i+size])
+		}
+	default:
+		return nil, fmt.Errorf("cipher Error: unsupported cipher mode %d", mode)
+	}
+	return out, nil
+}
+
+// pad 方法用于按填充方式把数据填充到分组长度的整数倍。
+func pad(data []byte, size int, padding Padding) ([]byte, error) {
+	n := size - len(data)%size
+	switch padding {
+	case PaddingPKCS7:
+		return append(append([]byte{}, data...), bytes.Repeat([]byte{byte(n)}, n)...), nil
+	case PaddingZero:
+		if n == size {
+			return data, nil
+		}
+		return append(append([]byte{}, data...), make([]byte, n)...), nil
+	default:
+		if n != size {
+			return nil, fmt.Errorf("cipher Error: plaintext is not a multiple of the block size")
+		}
+		return data, nil
+	}
+}
+
+// decryptBlocks 方法用于按指定的工作模式和填充方式解密数据。
+func decryptBlocks(block cipher.Block, mode CipherMode, iv, data []byte, padding Padding) ([]byte, error) {
+	size := block.BlockSize()
+	if mode != CipherECB && len(iv) != size {
+		return nil, fmt.Errorf("cipher Error: iv length must be %d bytes", size)
+	}
+	out := make([]byte, len(data))
+	switch mode {
+	case CipherCTR:
+		cipher.NewCTR(block, iv).XORKeyStream(out, data)
+		return out, nil
+	case CipherCBC:
+		if len(data)%size != 0 {
+			return nil, fmt.Errorf("cipher Error: ciphertext is not a multiple of the block size")
+		}
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+	case CipherECB:
+		if len(data)%size != 0 {
+			return nil, fmt.Errorf("cipher Error: ciphertext is not a multiple of the block size")
+		}
+		for i := 0; i < len(data); i += size {
+			block.Decrypt(out[i:i+size], data[i:i+size])
+		}
+	default:
+		return nil, fmt.Errorf("cipher Error: unsupported cipher mode %d", mode)
+	}
+	return unpad(out, size, padding)
+}
+
+// unpad 方法用于去除解密结果的填充。
+func unpad(data []byte, size int, padding Padding) ([]byte, error) {
+	switch padding {
+	case PaddingPKCS7:
+		if len(data) == 0 {
+			return nil, fmt.Errorf("cipher Error: invalid pkcs7 padding")
+		}
+		n := int(data[len(data)-1])
+		if n == 0 || n > size || n > len(data) || !bytes.Equal(data[len(data)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+			return nil, fmt.Errorf("cipher Error: invalid pkcs7 padding")
+		}
+		return data[:len(data)-n], nil
+	case PaddingZero:
+		return bytes.TrimRight(data, "\x00"), nil
+	default:
+		return data, nil
+	}
+}
@@ -1,14 +1,15 @@
 package builder
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"github.com/EDDYCJY/fake-useragent"
+	"github.com/catnovelapi/builder/pkg/files"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"golang.org/x/net/publicsuffix"
+	"io"
 	"net"
 	"net/http"
 	"net/http/cookiejar"
@@ -17,10 +18,12 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-func createTransport(localAddr net.Addr) *http.Transport {
+// newDialer 方法用于创建一个 net.Dialer, localAddr 不为 nil 时使用该地址作为本地地址。
+func newDialer(localAddr net.Addr) *net.Dialer {
 	dialer := &net.Dialer{
 		Timeout:   30 * time.Second,
 		KeepAlive: 30 * time.Second,
@@ -29,6 +32,10 @@ func createTransport(localAddr net.Addr) *http.Transport {
 	if localAddr != nil {
 		dialer.LocalAddr = localAddr
 	}
+	return dialer
+}
+
+func createTransport(dialer *net.Dialer) *http.Transport {
 	return &http.Transport{
 		Proxy:                 http.ProxyFromEnvironment,
 		DialContext:           dialer.DialContext,
@@ -42,6 +49,11 @@ func createTransport(localAddr net.Addr) *http.Transport {
 }
 
 // Client 类型用于存储 HTTP 请求的相关信息。
+//
+// Client 可以被多个 goroutine 同时使用。SetHeader、SetQueryParam、SetCookie 等修改默认 Header、Query 和 Cookies 的方法
+// 可以与 R() 并发调用: 这些配置采用写时复制, 每次修改都会替换为新的 map 或切片, 已创建的请求不受之后修改的影响。
+// 其他配置方法 (例如 SetBaseURL、SetProxy 和 SetTimeout) 应在发送请求之前调用。
+// 直接读写导出的 Header、QueryParam 和 Cookies 字段不是并发安全的, 请使用对应的方法。
 type Client struct {
 	sync.RWMutex                         // 用于保证线程安全
 	MaxConcurrent          chan struct{} // 用于限制并发数
@@ -51,7 +63,7 @@ type Client struct {
 	httpClientRaw          *http.Client      // httpClientRaw 用于存储 http.Client 的指针
 	Header                 map[string]string // Header 用于存储 HTTP 请求的 Header 部分
 	QueryParam             map[string]any    // QueryParam 用于存储 HTTP 请求的 Query 部分
-	setResultFunc          func(v string) (string, error)
+	responseDecoders       []ResponseDecoder // responseDecoders 用于存储响应解码管道
 	Token                  string
 	AuthScheme             string
 	Cookies                []*http.Cookie
@@ -63,11 +75,66 @@ type Client struct {
 	XMLMarshal             func(v interface{}) ([]byte, error)
 	XMLUnmarshal           func(data []byte, v interface{}) error
 	HeaderAuthorizationKey string
-	body                   interface{} // body 用于存储 HTTP 请求的 Body 部分
+	body                   interface{}           // body 用于存储 HTTP 请求的 Body 部分
+	cache                  *Cache                // cache 用于存储 HTTP 响应缓存
+	conditionalRequest     bool                  // conditionalRequest 用于标记是否自动发送条件请求
+	cacheMode              CacheMode             // cacheMode 用于存储缓存模式
+	scheduler              *scheduler            // scheduler 用于按优先级调度请求
+	crawlDelays            map[string]*hostDelay // crawlDelays 用于存储每个主机的请求间隔
+	robots                 *robotsCache          // robots 用于按站点缓存 robots.txt
+	robotsMode             RobotsMode            // robotsMode 用于存储 robots.txt 检查的处理方式
+	robotsUserAgent        string                // robotsUserAgent 用于存储匹配 robots.txt 时使用的爬虫名称
+	retryPolicy            RetryPolicy           // retryPolicy 用于存储客户端默认的重试策略
+	retryBudget            *retryBudget          // retryBudget 用于限制客户端的重试比例
+	bodyEncoders           []BodyEncoder         // bodyEncoders 用于存储请求体编码器
+	requestIDHeader        string                // requestIDHeader 用于存储请求 ID 的请求头名称, 为空表示未开启
+	requestIDGenerator     func() string
+	logOutput              io.Writer       // logOutput 用于存储日志的主输出
+	extraLogOutputs        []io.Writer     // extraLogOutputs 用于存储额外的日志输出
+	logFile                io.Closer       // logFile 用于存储当前的调试文件, 替换时关闭
+	memo                   *Cache          // memo 用于存储 SetCacheTTL 使用的内存缓存
+	dialer                 *net.Dialer     // dialer 用于存储建立连接时使用的 net.Dialer
+	localAddrs             []net.IP        // localAddrs 用于存储建立连接时轮流使用的本地地址
+	localAddrIndex         atomic.Uint64   // localAddrIndex 用于轮流选择本地地址
+	stats                  clientStats     // stats 用于累计连接和请求统计信息
+	closeCtx               context.Context // closeCtx 用于在 Close 时取消后台任务
+	closeCancel            context.CancelFunc
+	background             sync.WaitGroup            // background 用于等待后台任务结束
+	informationalFuncs     []InformationalFunc       // informationalFuncs 用于存储处理 1xx 信息响应的回调函数
+	uploadThrottle         *Throttle                 // uploadThrottle 用于限制所有请求的上传速度
+	downloadThrottle       *Throttle                 // downloadThrottle 用于限制所有请求的下载速度
+	burstSmoother          *burstSmoother            // burstSmoother 用于把突发的请求分散到时间窗口内
+	debugBodyLimit         int                       // debugBodyLimit 用于存储调试日志中请求体和响应体的最大字节数
+	verbose                *verboseWriter            // verbose 用于输出 curl 风格的请求跟踪
+	metricsHook            MetricsHook               // metricsHook 用于接收每次请求尝试的指标
+	events                 *eventBus                 // events 用于分发请求生命周期事件
+	codecs                 []codec                   // codecs 用于存储通过 RegisterCodec 注册的编解码器
+	acceptEncoding         string                    // acceptEncoding 用于存储请求的 Accept-Encoding 头部
+	disableDecompress      bool                      // disableDecompress 用于标记是否关闭响应体的自动解压
+	timeFormat             string                    // timeFormat 用于存储 Query 参数中时间的格式
+	boolFormat             BoolFormat                // boolFormat 用于存储 Query 参数中布尔值的格式
+	omitEmptyParams        bool                      // omitEmptyParams 用于标记是否忽略值为空的 Query 参数和表单参数
+	fingerprints           *fingerprintPool          // fingerprints 用于存储 SetFingerprintPool 设置的客户端指纹池
+	trace                  bool                      // trace 用于标记是否为所有请求开启耗时追踪
+	slowThreshold          time.Duration             // slowThreshold 用于存储 SetSlowRequestThreshold 设置的慢请求阈值
+	retryOnErrors          []func(err error) bool    // retryOnErrors 用于存储 SetRetryOnErrors 设置的会被重试的错误
+	specValidator          SpecValidator             // specValidator 用于按接口规范校验请求和响应
+	failedIPs              sync.Map                  // failedIPs 用于记录最近连接失败的 IP 及其失败时间, 建立连接时排在其他 IP 之后
+	resolveOverrides       map[string][]net.IP       // resolveOverrides 用于存储 SetResolveOverride 设置的主机到 IP 的映射, 写时复制
+	errorFuncs             []ErrorFunc               // errorFuncs 用于存储请求最终失败时调用的回调函数
+	envelopeCheck          EnvelopeCheck             // envelopeCheck 用于检查 JSON 响应外层的状态信息
+	autoReferer            bool                      // autoReferer 用于标记是否开启自动 Referer
+	referers               sync.Map                  // referers 用于记录每个主机上一次请求的 URL, 作为下一次请求的 Referer
+	templates              map[string]func(*Request) // templates 用于存储 RegisterTemplate 注册的请求模板, 写时复制
+	cookieChangeFuncs      []CookieChangeFunc        // cookieChangeFuncs 用于存储 CookieJar 变化时调用的回调函数
+	schemas                schemaCache               // schemas 用于缓存 SetResponseSchema 使用的已编译的 JSON Schema
 }
 
 const defaultRetryCount = 3
 
+// defaultDebugFileRotate 为 SetDebugFile 使用的调试文件轮转方式
+var defaultDebugFileRotate = files.RotateOptions{MaxSize: files.DefaultMaxSize, MaxBackups: 5, Compress: true}
+
 // NewClient 方法用于创建一个新的 Client 对象, 并返回该对象的指针。
 func NewClient() *Client {
 	cookieJar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
@@ -84,15 +151,21 @@ func NewClient() *Client {
 		HeaderAuthorizationKey: http.CanonicalHeaderKey("Authorization"),
 		AuthScheme:             "Bearer",
 		httpClientRaw:          &http.Client{Jar: cookieJar},
+		debugBodyLimit:         defaultDebugBodyLimit,
+		events:                 &eventBus{},
 	}
+	client.closeCtx, client.closeCancel = context.WithCancel(context.Background())
 
 	if client.httpClientRaw.Transport == nil {
-		client.httpClientRaw.Transport = createTransport(nil)
+		client.dialer = newDialer(nil)
+		transport := createTransport(client.dialer)
+		transport.DialContext = client.dialContext
+		client.httpClientRaw.Transport = transport
 	}
 
 	// 设置日志格式为json格式
 	client.log.SetFormatter(&logrus.JSONFormatter{PrettyPrint: true})
-	client.log.SetOutput(os.Stdout)
+	client.setLogOutput(os.Stdout)
 
 	// 设置日志级别为DebugLevel
 	client.log.SetLevel(logrus.DebugLevel)
@@ -114,21 +187,67 @@ func (client *Client) SetBaseURL(baseUrl string) *Client {
 
 // SetContentType 方法用于设置 HTTP 请求的 ContentType 部分。它接收一个 string 类型的参数，该参数表示 ContentType 的值。
 func (client *Client) SetContentType(contentType string) *Client {
-	client.Header["Content-Type"] = contentType
-	return client
+	return client.SetHeader("Content-Type", contentType)
 }
 
 // SetDebugFile 方法用于设置输出调试信息的文件。它接收一个 string 类型的参数，该参数表示文件名。
+// 调试文件达到 100MB 时会自动轮转, 保留最近 5 个使用 gzip 压缩的历史文件, 可以通过 SetDebugFileRotate 自定义。
 func (client *Client) SetDebugFile(name string) *Client {
+	return client.SetDebugFileRotate(name, defaultDebugFileRotate)
+}
+
+// SetDebugFileRotate 方法用于设置输出调试信息的文件及其轮转方式。它接收一个 string 类型的参数表示文件名,
+// 以及一个 files.RotateOptions 类型的参数表示轮转的大小、保留的历史文件数量以及是否压缩。
+func (client *Client) SetDebugFileRotate(name string, opts files.RotateOptions) *Client {
 	client.Debug = true
-	if file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666); err != nil {
-		client.LogError(err, name, "client.go", "SetDebugFile")
-	} else {
-		client.log.SetOutput(file)
+	writer, err := files.NewRotateWriter(name, opts)
+	if err != nil {
+		client.LogError(err, name, "client.go", "SetDebugFileRotate")
+		return client
+	}
+	client.setLogOutput(writer)
+	client.Lock()
+	previous := client.logFile
+	client.logFile = writer
+	client.Unlock()
+	if previous != nil {
+		if err = previous.Close(); err != nil {
+			client.LogError(err, name, "client.go", "SetDebugFileRotate")
+		}
 	}
 	return client
 }
 
+// AddLogOutput 方法用于添加一个额外的日志输出。它接收一个 io.Writer 类型的参数，
+// 日志会同时写入主输出 (默认为标准输出, 调用 SetDebugFile 后为调试文件) 和所有额外的输出。
+func (client *Client) AddLogOutput(w io.Writer) *Client {
+	client.Lock()
+	client.extraLogOutputs = append(client.extraLogOutputs, w)
+	client.Unlock()
+	client.updateLogOutput()
+	return client
+}
+
+// setLogOutput 方法用于替换日志的主输出, 额外的日志输出保持不变。
+func (client *Client) setLogOutput(w io.Writer) {
+	client.Lock()
+	client.logOutput = w
+	client.Unlock()
+	client.updateLogOutput()
+}
+
+// updateLogOutput 方法用于把主输出和额外的输出组合后设置为日志输出。
+func (client *Client) updateLogOutput() {
+	client.RLock()
+	defer client.RUnlock()
+	if len(client.extraLogOutputs) == 0 {
+		client.log.SetOutput(client.logOutput)
+		return
+	}
+	outputs := append([]io.Writer{client.logOutput}, client.extraLogOutputs...)
+	client.log.SetOutput(io.MultiWriter(outputs...))
+}
+
 // R 方法用于创建一个新的 Request 对象。它接收一个 string 类型的参数，该参数表示 HTTP 请求的 Path 部分。
 func (client *Client) R() *Request {
 	req := &Request{
@@ -138,22 +257,23 @@ func (client *Client) R() *Request {
 		Header:     sync.Map{},
 		QueryParam: sync.Map{},
 	}
-	cookies := make([]*http.Cookie, 0)
-	for i, cookie := range client.Cookies {
-		// 创建一个新的cookie实例
-		newCookie := new(http.Cookie)
-		// 使用一个结构体赋值，复制cookie的值到新的实例
-		*newCookie = *cookie
-		// 将新的cookie指针放入新的切片中
-		cookies[i] = newCookie
+	// Header、QueryParam 和 Cookies 采用写时复制, 读取到的 map 和切片之后不会再被修改
+	client.RLock()
+	header, params, clientCookies := client.Header, client.QueryParam, client.Cookies
+	client.RUnlock()
+
+	// 深拷贝 Cookies, 修改请求的 Cookie 不会影响客户端
+	cookies := make([]*http.Cookie, 0, len(clientCookies))
+	for _, cookie := range clientCookies {
+		newCookie := *cookie
+		cookies = append(cookies, &newCookie)
 	}
-	// 现在 cookies 切片包含了原始cookies的深拷贝
-	req.Cookies = cookies // 将深拷贝的cookies设置到请求中
+	req.Cookies = cookies
 
 	// 设置 Header
-	req.SetHeaders(client.Header)
+	req.SetHeaders(header)
 
-	req.SetQueryParams(client.QueryParam)
+	req.SetQueryParams(params)
 	return req
 }
 func (client *Client) LogError(err any, query any, fileName, funcName string) {
@@ -200,7 +320,10 @@ func (client *Client) SetCookieString(cookieStr string) *Client {
 	return client
 }
 func (client *Client) SetCookie(cookie *http.Cookie) *Client {
-	client.Cookies = append(client.Cookies, cookie)
+	client.Lock()
+	defer client.Unlock()
+	// 复制后再追加, 已经被 R() 读取的切片不会被修改
+	client.Cookies = append(client.Cookies[:len(client.Cookies):len(client.Cookies)], cookie)
 	return client
 }
 func (client *Client) SetCookies(cookie []*http.Cookie) *Client {
@@ -212,12 +335,15 @@ func (client *Client) SetCookies(cookie []*http.Cookie) *Client {
 
 // SetCookieJar 方法用于设置 HTTP 请求的 CookieJar 部分。它接收一个 http.CookieJar 类型的参数，该参数表示 CookieJar 的值。
 func (client *Client) SetCookieJar(cookieJar http.CookieJar) *Client {
-	client.httpClientRaw.Jar = cookieJar
+	client.httpClientRaw.Jar = client.wrapJar(cookieJar)
 	return client
 }
 
+// SetResultFunc 方法用于把响应解码管道替换为单个解码函数。
+//
+// Deprecated: 使用 AddResponseDecoder 组合多个解码器。
 func (client *Client) SetResultFunc(f func(v string) (string, error)) *Client {
-	client.setResultFunc = f
+	client.responseDecoders = []ResponseDecoder{f}
 	return client
 }
 
@@ -239,7 +365,14 @@ func (client *Client) SetRetryCount(count int) *Client {
 
 // SetHeader 方法用于设置 HTTP 请求的 Header 部分。它接收两个 string 类型的参数，
 func (client *Client) SetHeader(key string, value interface{}) *Client {
-	client.Header[key] = fmt.Sprintf("%v", value)
+	client.Lock()
+	defer client.Unlock()
+	header := make(map[string]string, len(client.Header)+1)
+	for k, v := range client.Header {
+		header[k] = v
+	}
+	header[key] = fmt.Sprintf("%v", value)
+	client.Header = header
 	return client
 }
 
@@ -261,7 +394,14 @@ func (client *Client) SetUserAgent(userAgent string) *Client {
 
 // SetQueryParam 方法用于设置 HTTP 请求的 Query 部分。它接收两个 string 类型的参数，
 func (client *Client) SetQueryParam(key string, value any) *Client {
-	client.QueryParam[key] = value
+	client.Lock()
+	defer client.Unlock()
+	params := make(map[string]any, len(client.QueryParam)+1)
+	for k, v := range client.QueryParam {
+		params[k] = v
+	}
+	params[key] = value
+	client.QueryParam = params
 	return client
 }
 
@@ -295,21 +435,23 @@ func (client *Client) SetProxy(proxy string) *Client {
 		client.LogError(err, proxy, "client.go", "SetProxy")
 		return client
 	}
-	// 设置 Transport 的 Proxy 字段
-	client.httpClientRaw.Transport = &http.Transport{Proxy: http.ProxyURL(u)}
+	// 只修改 Transport 的 Proxy 字段, 保留连接池和超时等其他配置
+	if transport := client.transport("SetProxy"); transport != nil {
+		transport.Proxy = http.ProxyURL(u)
+	}
 	return client
 }
 
 // SetTimeout 方法用于设置 HTTP 请求的 Timeout 部分, timeout 单位为秒。它接收一个 int 类型的参数，该参数表示 Timeout 的值。
+// 整体超时包括读取响应体的时间, 下载大文件时可以使用 SetTimeoutDuration(0) 关闭整体超时, 改用分阶段的超时。
 func (client *Client) SetTimeout(timeout int) *Client {
-	// 设置 httpClientRaw 的 Timeout 字段, timeout 单位为秒
-	client.httpClientRaw.Timeout = time.Duration(timeout * int(time.Second))
-	return client
+	// timeout 单位为秒
+	return client.SetTimeoutDuration(time.Duration(timeout) * time.Second)
 }
 
 // SetBasicAuth 方法用于设置 HTTP 请求的 BasicAuth 部分。它接收两个 string 类型的参数，分别表示用户名和密码。
 func (client *Client) SetBasicAuth(username, password string) *Client {
-	client.SetAuthorizationKey(client.AuthScheme + base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+	client.SetAuthorizationKey(basicAuth(username, password))
 	return client
 }
 
@@ -318,3 +460,44 @@ func (client *Client) SetAuthorizationKey(authToken string) *Client {
 	client.SetHeader(client.HeaderAuthorizationKey, authToken)
 	return client
 }
+
+// goBackground 方法用于在 Client 未关闭时启动一个后台任务, Close 会等待所有后台任务结束。Client 已关闭时不启动并返回 false。
+func (client *Client) goBackground(fn func()) bool {
+	client.Lock()
+	if client.closeCtx.Err() != nil {
+		client.Unlock()
+		return false
+	}
+	client.background.Add(1)
+	client.Unlock()
+	go func() {
+		defer client.background.Done()
+		fn()
+	}()
+	return true
+}
+
+// Close 方法用于关闭 Client。它会取消并等待后台任务 (例如缓存的后台重新验证) 结束, 关闭所有空闲连接和 Events 返回的 channel,
+// 并关闭 SetDebugFile 打开的调试文件, 之后的日志输出到标准输出。重复调用 Close 是安全的。
+func (client *Client) Close() error {
+	// 在锁内取消, 之后 goBackground 不会再启动新的后台任务, Wait 与 Add 不会并发
+	client.Lock()
+	client.closeCancel()
+	client.Unlock()
+	client.background.Wait()
+	client.httpClientRaw.CloseIdleConnections()
+	client.events.close()
+
+	client.Lock()
+	if client.fingerprints != nil {
+		client.fingerprints.closeIdleConnections()
+	}
+	logFile := client.logFile
+	client.logFile = nil
+	client.Unlock()
+	if logFile == nil {
+		return nil
+	}
+	client.setLogOutput(os.Stdout)
+	return logFile.Close()
+}
@@ -1,7 +1,11 @@
 package builder
 
-// GetClientQueryParams 方法用于获取 HTTP 请求的 Query 部分。它返回一个
+import "time"
+
+// GetClientQueryParams 方法用于获取 HTTP 请求的 Query 部分。它返回一个 map[string]any 类型的参数, 返回的 map 不应被修改。
 func (client *Client) GetClientQueryParams() map[string]any {
+	client.RLock()
+	defer client.RUnlock()
 	return client.QueryParam
 }
 
@@ -32,5 +36,17 @@ func (client *Client) GetClientTimeout() int {
 
 // GetClientCookie 方法用于获取 HTTP 请求的 Cookie 部分。它返回一个 string 类型的参数。
 func (client *Client) GetClientCookie() string {
+	client.RLock()
+	defer client.RUnlock()
 	return client.Header["Cookie"]
 }
+
+// GetClientTimeoutDuration 方法用于获取 HTTP 请求的整体超时时间。它返回一个 time.Duration 类型的参数。
+func (client *Client) GetClientTimeoutDuration() time.Duration {
+	return client.httpClientRaw.Timeout
+}
+
+// GetClientMetricsHook 方法用于获取通过 SetMetricsHook 设置的 MetricsHook。它返回一个 MetricsHook 类型的参数, 未设置时为 nil。
+func (client *Client) GetClientMetricsHook() MetricsHook {
+	return client.metricsHook
+}
@@ -0,0 +1,60 @@
+// Command builder provides code generation for the builder package.
+//
+// Usage:
+//
+//	builder gen openapi [-o file] [-package name] spec.yaml
+//
+// gen openapi emits typed wrapper methods over builder.Client for every
+// operation of an OpenAPI 3 spec. The generated code is written to stdout
+// unless -o is given.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/catnovelapi/builder/pkg/files"
+	"github.com/catnovelapi/builder/pkg/openapi"
+)
+
+const usage = "usage: builder gen openapi [-o file] [-package name] spec.yaml"
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "builder:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) < 2 || args[0] != "gen" || args[1] != "openapi" {
+		return errors.New(usage)
+	}
+	fs := flag.NewFlagSet("builder gen openapi", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("o", "", "write the generated code to `file` instead of stdout")
+	pkg := fs.String("package", "api", "`name` of the generated package")
+	if err := fs.Parse(args[2:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New(usage)
+	}
+	doc, err := openapi.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	source, err := openapi.Generate(doc, openapi.Options{Package: *pkg})
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = stdout.Write(source)
+		return err
+	}
+	return files.WriteAtomic(*output, bytes.NewReader(source))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// curlCommand renders req as an equivalent curl command line. The request
+// is expected to come from builder.Request.Build, so the URL, headers and
+// body are exactly what the client would send.
+func curlCommand(req *http.Request) (string, error) {
+	body, err := requestBody(req)
+	if err != nil {
+		return "", err
+	}
+	parts := []string{"curl"}
+	if req.Method != "GET" || len(body) > 0 {
+		parts = append(parts, "-X", req.Method)
+	}
+	header := req.Header.Clone()
+	// The client asks for gzip on its own and decompresses the response;
+	// --compressed makes curl do the same instead of printing gzip bytes.
+	if header.Get("Accept-Encoding") == "gzip" {
+		header.Del("Accept-Encoding")
+		parts = append(parts, "--compressed")
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		header.Set("Host", req.Host)
+	}
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			parts = append(parts, "-H", shellQuote(key+": "+value))
+		}
+	}
+	if len(body) > 0 {
+		parts = append(parts, "--data-raw", shellQuote(string(body)))
+	}
+	parts = append(parts, shellQuote(req.URL.String()))
+	return strings.Join(parts, " "), nil
+}
+
+// requestBody returns a copy of the request body without consuming it.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody == nil {
+		return nil, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// withQuery appends the encoded query to target.
+func withQuery(target string, query map[string]string) string {
+	if len(query) == 0 {
+		return target
+	}
+	values := url.Values{}
+	for key, value := range query {
+		values.Set(key, value)
+	}
+	separator := "?"
+	if strings.Contains(target, "?") {
+		separator = "&"
+	}
+	return target + separator + values.Encode()
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@,+%", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCurlUsesBuiltRequest(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), "profile.yaml")
+	err := os.WriteFile(profile, []byte(`base_url: https://api.example.com/v1
+headers:
+  Authorization: Bearer abc
+query:
+  app: reader
+user_agent: test-agent
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err = run([]string{"-profile", profile, "-curl", "-q", "page=2", "/books"}, &stdout, &stderr); err != nil {
+		t.Fatal(err, stderr.String())
+	}
+	// The spec query comes before the profile query, as the client sends them.
+	want := "curl --compressed -H 'Authorization: Bearer abc' -H 'User-Agent: test-agent' " +
+		"'https://api.example.com/v1/books?page=2&app=reader'\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("curl output\n got: %s\nwant: %s", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/catnovelapi/builder"
+	"gopkg.in/yaml.v3"
+)
+
+// Profile describes how to configure a builder.Client. Profiles are YAML
+// files (JSON is accepted too, since it is a subset of YAML).
+type Profile struct {
+	BaseURL   string            `yaml:"base_url"`
+	Headers   map[string]string `yaml:"headers"`
+	Query     map[string]string `yaml:"query"`
+	Cookie    string            `yaml:"cookie"`
+	UserAgent string            `yaml:"user_agent"`
+	Timeout   string            `yaml:"timeout"`
+	Retry     int               `yaml:"retry"`
+	Proxy     string            `yaml:"proxy"`
+	Debug     bool              `yaml:"debug"`
+}
+
+// RequestSpec describes a single request. Body may be a string, which is
+// sent as-is, or any YAML value, which is sent as JSON.
+type RequestSpec struct {
+	Method  string            `yaml:"method"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	Query   map[string]string `yaml:"query"`
+	Body    any               `yaml:"body"`
+}
+
+// loadYAML decodes the YAML file at path into v.
+func loadYAML(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err = yaml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// newClient builds a client configured from the profile.
+func (p *Profile) newClient() (*builder.Client, error) {
+	client := builder.NewClient()
+	if p.BaseURL != "" {
+		client.SetBaseURL(p.BaseURL)
+	}
+	if p.UserAgent != "" {
+		client.SetUserAgent(p.UserAgent)
+	}
+	for key, value := range p.Headers {
+		client.SetHeader(key, value)
+	}
+	for key, value := range p.Query {
+		client.SetQueryParam(key, value)
+	}
+	if p.Cookie != "" {
+		client.SetCookieString(p.Cookie)
+	}
+	if p.Timeout != "" {
+		timeout, err := time.ParseDuration(p.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", p.Timeout, err)
+		}
+		client.SetTimeoutDuration(timeout)
+	}
+	if p.Retry > 0 {
+		client.SetRetryCount(p.Retry)
+	}
+	if p.Proxy != "" {
+		client.SetProxy(p.Proxy)
+	}
+	if p.Debug {
+		client.SetDebug()
+	}
+	return client, nil
+}
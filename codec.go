@@ -0,0 +1,87 @@
+package builder
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// codec 类型用于存储一个 Content-Type 对应的编码和解码函数。
+type codec struct {
+	pattern   string
+	marshal   func(v any) ([]byte, error)
+	unmarshal func(data []byte, v any) error
+}
+
+// RegisterCodec 方法用于注册自定义 Content-Type 的编解码器。它接收一个 string 类型的参数表示媒体类型的匹配模式
+// (例如 application/x-amf 或 application/vnd.*+json, 语法与 path.Match 相同), 以及编码和解码函数, 不需要的一方可以为 nil。
+// 请求的 Content-Type 匹配时, 通过 SetBody 设置的非字符串请求体使用 marshal 编码; Response.Decode 根据响应的
+// Content-Type 选择 unmarshal 解码。后注册的编解码器优先, 未匹配时沿用内置的 JSON 和 XML 处理。
+func (client *Client) RegisterCodec(mimePattern string, marshal func(v any) ([]byte, error), unmarshal func(data []byte, v any) error) *Client {
+	if _, err := path.Match(mimePattern, ""); err != nil {
+		client.LogError(err, mimePattern, "codec.go", "RegisterCodec")
+		return client
+	}
+	client.Lock()
+	client.codecs = append(client.codecs, codec{pattern: strings.ToLower(mimePattern), marshal: marshal, unmarshal: unmarshal})
+	client.Unlock()
+	return client
+}
+
+// findCodec 方法用于查找与 Content-Type 匹配的已注册编解码器, 未找到时返回 nil。
+func (client *Client) findCodec(contentType string, match func(codec) bool) *codec {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	client.RLock()
+	defer client.RUnlock()
+	for i := len(client.codecs) - 1; i >= 0; i-- {
+		c := client.codecs[i]
+		if ok, _ := path.Match(c.pattern, mediaType); ok && match(c) {
+			return &c
+		}
+	}
+	return nil
+}
+
+// setCodecBody 方法用于在请求的 Content-Type 匹配已注册的编解码器时编码请求体, 返回值表示是否已处理。
+func (request *Request) setCodecBody() (bool, error) {
+	switch request.Body.(type) {
+	case string, []byte, io.Reader, url.Values:
+		return false, nil
+	}
+	c := request.client.findCodec(request.GetHeaderContentType(), func(c codec) bool { return c.marshal != nil })
+	if c == nil {
+		return false, nil
+	}
+	return true, request.marshalBody("Codec", c.marshal)
+}
+
+// Decode 方法用于根据响应的 Content-Type 把响应体解码到 v。它接收一个 any 类型的参数，该参数必须是指针类型。
+// 优先使用 RegisterCodec 注册的编解码器, 其次是 JSON (application/json 及 +json) 和 XML (application/xml、text/xml 及 +xml)。
+func (response *Response) Decode(v any) error {
+	client := response.RequestSource.client
+	contentType := response.GetHeader().Get("Content-Type")
+	unmarshal := response.RequestSource.jsonUnmarshaler()
+	if c := client.findCodec(contentType, func(c codec) bool { return c.unmarshal != nil }); c != nil {
+		unmarshal = c.unmarshal
+	} else {
+		switch mediaType := response.ContentType(); {
+		case mediaType == jsonContentType || strings.HasSuffix(mediaType, "+json"):
+		case mediaType == xmlContentType || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+			unmarshal = client.XMLUnmarshal
+		default:
+			return response.newDecodeError(fmt.Errorf("no codec registered for Content-Type %q", contentType))
+		}
+	}
+	if err := safeCall("Codec", func() error {
+		return unmarshal(response.GetByte(), v)
+	}); err != nil {
+		return response.newDecodeError(err)
+	}
+	return nil
+}
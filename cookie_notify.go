@@ -0,0 +1,54 @@
+package builder
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// CookieChangeFunc 类型用于处理 CookieJar 的变化, domain 为设置 Cookie 的主机名, cookies 为响应 Set-Cookie 头部中的 Cookie。
+type CookieChangeFunc func(domain string, cookies []*http.Cookie)
+
+// notifyJar 类型用于包装 http.CookieJar, 在响应的 Set-Cookie 写入 CookieJar 之后调用 OnCookieChange 添加的回调函数。
+type notifyJar struct {
+	http.CookieJar
+	client *Client
+}
+
+// SetCookies 方法用于把 Cookie 写入被包装的 CookieJar, 然后依次调用回调函数。
+func (jar *notifyJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	jar.CookieJar.SetCookies(u, cookies)
+	jar.client.RLock()
+	funcs := jar.client.cookieChangeFuncs
+	jar.client.RUnlock()
+	for _, fn := range funcs {
+		fn := fn
+		if err := safeCall("CookieChangeFunc", func() error {
+			fn(u.Hostname(), cookies)
+			return nil
+		}); err != nil {
+			jar.client.LogError(err, u.String(), "cookie_notify.go", "SetCookies")
+		}
+	}
+}
+
+// OnCookieChange 方法用于添加 CookieJar 变化时调用的回调函数。它接收一个 CookieChangeFunc 类型的参数，
+// 每当响应的 Set-Cookie 更新 CookieJar 时调用 (包括重定向过程中的响应), 可用于及时持久化刷新后的会话 Cookie,
+// 而不是只在程序退出时保存。通过 SetCookie 等方法设置的 Cookie 不会触发回调。
+func (client *Client) OnCookieChange(fn CookieChangeFunc) *Client {
+	client.Lock()
+	client.cookieChangeFuncs = append(append([]CookieChangeFunc{}, client.cookieChangeFuncs...), fn)
+	client.Unlock()
+	client.httpClientRaw.Jar = client.wrapJar(client.httpClientRaw.Jar)
+	return client
+}
+
+// wrapJar 方法用于在添加了 OnCookieChange 回调函数时包装 CookieJar, 已经包装过或者为 nil 的 CookieJar 原样返回。
+func (client *Client) wrapJar(jar http.CookieJar) http.CookieJar {
+	if jar == nil || len(client.cookieChangeFuncs) == 0 {
+		return jar
+	}
+	if _, ok := jar.(*notifyJar); ok {
+		return jar
+	}
+	return &notifyJar{CookieJar: jar, client: client}
+}
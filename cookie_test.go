@@ -0,0 +1,74 @@
+package builder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newCookieEchoServer 返回一个把请求的 Cookie 头部作为响应体返回的测试服务器。
+func newCookieEchoServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Join(r.Header.Values("Cookie"), "|")))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRequestInheritsClientCookies(t *testing.T) {
+	server := newCookieEchoServer(t)
+	client := NewClient().SetCookieString("token=abc; uid=1")
+
+	response, err := client.R().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := response.String(); got != "token=abc; uid=1" {
+		t.Errorf("Cookie = %q, want %q", got, "token=abc; uid=1")
+	}
+}
+
+func TestRequestCookiesAreCopied(t *testing.T) {
+	server := newCookieEchoServer(t)
+	client := NewClient().SetCookie(&http.Cookie{Name: "token", Value: "abc"})
+
+	request := client.R()
+	request.Cookies[0].Value = "changed"
+	request.SetCookie(&http.Cookie{Name: "page", Value: "2"})
+	response, err := request.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := response.String(); got != "token=changed; page=2" {
+		t.Errorf("first request Cookie = %q, want %q", got, "token=changed; page=2")
+	}
+
+	// 修改请求的 Cookie 不影响客户端, 请求的 Cookie 也不会出现在之后的请求中
+	response, err = client.R().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := response.String(); got != "token=abc" {
+		t.Errorf("second request Cookie = %q, want %q", got, "token=abc")
+	}
+	if got := client.Cookies[0].Value; got != "abc" {
+		t.Errorf("client cookie = %q, want %q", got, "abc")
+	}
+}
+
+func TestRequestClearCookies(t *testing.T) {
+	server := newCookieEchoServer(t)
+	client := NewClient().SetCookie(&http.Cookie{Name: "token", Value: "abc"})
+
+	response, err := client.R().ClearCookies().SetCookie(&http.Cookie{Name: "guest", Value: "1"}).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := response.String(); got != "guest=1" {
+		t.Errorf("Cookie = %q, want %q", got, "guest=1")
+	}
+	if len(client.Cookies) != 1 {
+		t.Errorf("client has %d cookies, want 1", len(client.Cookies))
+	}
+}
@@ -0,0 +1,78 @@
+package builder
+
+import (
+	"golang.org/x/net/context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostDelay 类型用于记录单个主机的请求间隔和下一次允许请求的时间。
+type hostDelay struct {
+	sync.Mutex
+	delay time.Duration
+	next  time.Time
+}
+
+// reserve 方法用于预约一次请求, 返回本次请求需要等待的时长。
+// 每次预约都会把下一次允许请求的时间推后 delay 加上最多 delay/4 的随机抖动。
+func (hd *hostDelay) reserve() time.Duration {
+	hd.Lock()
+	defer hd.Unlock()
+	now := time.Now()
+	start := hd.next
+	if start.Before(now) {
+		start = now
+	}
+	gap := hd.delay
+	if jitter := int64(hd.delay / 4); jitter > 0 {
+		gap += time.Duration(rand.Int63n(jitter))
+	}
+	hd.next = start.Add(gap)
+	return start.Sub(now)
+}
+
+// SetCrawlDelay 方法用于设置对同一主机两次请求之间的最小间隔。它接收一个 string 类型的参数表示主机名
+// (可以带端口), 以及一个 time.Duration 类型的参数表示间隔, 间隔为 0 时取消该主机的限制。
+// 该限制与并发数无关, 同一主机的请求会依次错开发送, 每次间隔附带随机抖动。
+func (client *Client) SetCrawlDelay(host string, d time.Duration) *Client {
+	host = strings.ToLower(strings.TrimSpace(host))
+	client.Lock()
+	defer client.Unlock()
+	if d <= 0 {
+		delete(client.crawlDelays, host)
+		return client
+	}
+	if client.crawlDelays == nil {
+		client.crawlDelays = map[string]*hostDelay{}
+	}
+	client.crawlDelays[host] = &hostDelay{delay: d}
+	return client
+}
+
+// waitCrawlDelay 方法用于在发送请求前等待目标主机的请求间隔, ctx 结束时提前返回 ctx 的错误。
+func (request *Request) waitCrawlDelay(ctx context.Context) error {
+	client := request.client
+	client.RLock()
+	hd, ok := client.crawlDelays[strings.ToLower(request.URL.Host)]
+	if !ok {
+		hd, ok = client.crawlDelays[strings.ToLower(request.URL.Hostname())]
+	}
+	client.RUnlock()
+	if !ok {
+		return nil
+	}
+	wait := hd.reserve()
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,43 @@
+package builder
+
+import "fmt"
+
+// ResponseDecoder 类型用于对响应结果进行转换, 例如 base64 解码、解密和解压缩。
+type ResponseDecoder func(v string) (string, error)
+
+// AddResponseDecoder 方法用于在客户端的响应解码管道末尾追加一个解码器。
+// 响应结果会按添加顺序依次经过每个解码器, 例如 base64 解码 → AES 解密 → 解压缩。
+func (client *Client) AddResponseDecoder(decoder ResponseDecoder) *Client {
+	client.responseDecoders = append(client.responseDecoders, decoder)
+	return client
+}
+
+// SetResponseDecoders 方法用于替换当前请求的响应解码管道, 不传入任何解码器时当前请求不进行解码。
+func (request *Request) SetResponseDecoders(decoders ...ResponseDecoder) *Request {
+	request.responseDecoders = decoders
+	request.overrideDecoders = true
+	return request
+}
+
+// getResponseDecoders 方法用于获取当前请求生效的响应解码管道。
+func (request *Request) getResponseDecoders() []ResponseDecoder {
+	if request.overrideDecoders {
+		return request.responseDecoders
+	}
+	return request.client.responseDecoders
+}
+
+// decodeResult 方法用于让响应结果依次经过解码管道中的每个解码器。
+func (request *Request) decodeResult(result string) (string, error) {
+	var err error
+	for i, decoder := range request.getResponseDecoders() {
+		err = safeCall("ResponseDecoder", func() (err error) {
+			result, err = decoder(result)
+			return err
+		})
+		if err != nil {
+			return "", fmt.Errorf("decode Error: decoder %d: %w", i, err)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,106 @@
+package builder
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/catnovelapi/builder/pkg/files"
+)
+
+// deviceModels 为 NewDeviceIdentity 随机选择的设备型号和系统版本
+var deviceModels = []struct{ model, osVersion string }{
+	{"Pixel 7", "Android 14"},
+	{"Pixel 6a", "Android 13"},
+	{"SM-S9180", "Android 14"},
+	{"SM-G9910", "Android 13"},
+	{"M2102K1C", "Android 13"},
+	{"22081212C", "Android 14"},
+	{"PGT-AN10", "HarmonyOS 4.0"},
+	{"V2254A", "Android 13"},
+	{"PHB110", "Android 14"},
+}
+
+// DeviceIdentity 类型用于存储模拟移动端 App 请求时使用的设备信息。设备信息应当持久保存并在每次运行时复用,
+// 频繁更换设备 ID 容易触发服务端的风控。DeviceIdentity 可以直接使用 encoding/json 序列化, 也可以使用 Save 和 LoadDeviceIdentity。
+type DeviceIdentity struct {
+	DeviceID      string    `json:"device_id"`      // 设备 ID
+	AppVersion    string    `json:"app_version"`    // App 版本, NewDeviceIdentity 不会设置, 需要按照目标 App 填写
+	Model         string    `json:"model"`          // 设备型号
+	OSVersion     string    `json:"os_version"`     // 系统版本
+	SignatureSalt string    `json:"signature_salt"` // 用于计算请求签名的随机盐值, 不会作为请求头发送
+	CreatedAt     time.Time `json:"created_at"`     // 设备信息的生成时间
+}
+
+// NewDeviceIdentity 方法用于生成一个新的设备信息, 包括随机的设备 ID、设备型号、系统版本和签名盐值。
+func NewDeviceIdentity() *DeviceIdentity {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		panic(fmt.Sprintf("builder: failed to read random bytes: %v", err))
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(deviceModels))))
+	if err != nil {
+		panic(fmt.Sprintf("builder: failed to read random bytes: %v", err))
+	}
+	device := deviceModels[n.Int64()]
+	return &DeviceIdentity{
+		DeviceID:      newUUID(),
+		Model:         device.model,
+		OSVersion:     device.osVersion,
+		SignatureSalt: hex.EncodeToString(salt),
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// LoadDeviceIdentity 方法用于从文件读取通过 Save 保存的设备信息。它接收一个 string 类型的参数，该参数表示文件路径。
+func LoadDeviceIdentity(path string) (*DeviceIdentity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var identity DeviceIdentity
+	if err = json.Unmarshal(data, &identity); err != nil {
+		return nil, fmt.Errorf("load device identity %s: %w", path, err)
+	}
+	return &identity, nil
+}
+
+// Save 方法用于把设备信息以 JSON 格式保存到文件。它接收一个 string 类型的参数，该参数表示文件路径, 文件以原子方式写入。
+func (identity *DeviceIdentity) Save(path string) error {
+	data, err := json.MarshalIndent(identity, "", "  ")
+	if err != nil {
+		return err
+	}
+	return files.WriteAtomic(path, bytes.NewReader(data))
+}
+
+// Headers 方法用于获取设备信息对应的请求头, 值为空的字段不会生成请求头。
+func (identity *DeviceIdentity) Headers() map[string]string {
+	headers := make(map[string]string)
+	for key, value := range map[string]string{
+		"X-Device-Id":    identity.DeviceID,
+		"X-App-Version":  identity.AppVersion,
+		"X-Device-Model": identity.Model,
+		"X-OS-Version":   identity.OSVersion,
+	} {
+		if value != "" {
+			headers[key] = value
+		}
+	}
+	return headers
+}
+
+// SetDeviceIdentity 方法用于设置客户端模拟的设备。它接收一个 *DeviceIdentity 类型的参数，
+// 设备信息通过 X-Device-Id、X-App-Version、X-Device-Model 和 X-OS-Version 请求头发送,
+// 目标 App 使用其他字段名时可以根据 DeviceIdentity 的字段自行调用 SetHeader。
+func (client *Client) SetDeviceIdentity(identity *DeviceIdentity) *Client {
+	for key, value := range identity.Headers() {
+		client.SetHeader(key, value)
+	}
+	return client
+}
@@ -0,0 +1,199 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffOptions 类型用于配置 DiffResponses 比较的内容。
+type DiffOptions struct {
+	// IgnoreHeaders 为比较时忽略的响应头名称, 不区分大小写; Date 和 Content-Length 头部总是被忽略
+	IgnoreHeaders []string
+	// IgnoreAllHeaders 为 true 时不比较响应头
+	IgnoreAllHeaders bool
+	// IgnorePaths 为比较 JSON 响应体时忽略的 gjson 风格路径, 例如 data.updated_at 或 data.list.0.id
+	IgnorePaths []string
+}
+
+// Difference 类型用于表示两个响应之间的一处差异。
+type Difference struct {
+	Path string // Path 为差异所在的位置, 例如 status、header.Content-Type 或 body.data.id
+	A    string // A 为第一个响应中的值, 不存在时为空字符串
+	B    string // B 为第二个响应中的值, 不存在时为空字符串
+}
+
+// ResponseDiff 类型用于存储 DiffResponses 的比较结果。
+type ResponseDiff struct {
+	Differences []Difference
+}
+
+// Equal 方法用于判断两个响应是否没有差异。
+func (diff *ResponseDiff) Equal() bool {
+	return len(diff.Differences) == 0
+}
+
+// String 方法用于把差异格式化为每行一处的文本。
+func (diff *ResponseDiff) String() string {
+	lines := make([]string, 0, len(diff.Differences))
+	for _, d := range diff.Differences {
+		lines = append(lines, fmt.Sprintf("%s: %q != %q", d.Path, d.A, d.B))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DiffResponses 方法用于比较两个 HTTP 响应的状态码、响应头和响应体, 可用于对比上游接口在不同版本之间的行为。
+// 两个响应体都是 JSON 时按解析后的值比较, 不受字段顺序和空白的影响; 否则按原始内容比较。
+func DiffResponses(a, b *Response, opts DiffOptions) *ResponseDiff {
+	diff := &ResponseDiff{}
+	if a.GetStatusCode() != b.GetStatusCode() {
+		diff.add("status", strconv.Itoa(a.GetStatusCode()), strconv.Itoa(b.GetStatusCode()))
+	}
+	if !opts.IgnoreAllHeaders {
+		diff.diffHeader(a.GetHeader(), b.GetHeader(), opts.IgnoreHeaders)
+	}
+	ignored := make(map[string]bool, len(opts.IgnorePaths))
+	for _, path := range opts.IgnorePaths {
+		ignored[path] = true
+	}
+	bodyA, okA := normalizeJson(a.GetByte())
+	bodyB, okB := normalizeJson(b.GetByte())
+	if okA && okB {
+		diff.diffValue("body", "", bodyA, bodyB, ignored)
+	} else if !bytes.Equal(a.GetByte(), b.GetByte()) {
+		diff.add("body", a.String(), b.String())
+	}
+	return diff
+}
+
+func (diff *ResponseDiff) add(path, a, b string) {
+	diff.Differences = append(diff.Differences, Difference{Path: path, A: a, B: b})
+}
+
+// diffHeader 方法用于比较响应头, 同名头部的多个值按顺序拼接后比较。
+func (diff *ResponseDiff) diffHeader(a, b http.Header, ignoreHeaders []string) {
+	ignored := map[string]bool{"Date": true, "Content-Length": true}
+	for _, key := range ignoreHeaders {
+		ignored[http.CanonicalHeaderKey(key)] = true
+	}
+	keys := map[string]bool{}
+	for key := range a {
+		keys[http.CanonicalHeaderKey(key)] = true
+	}
+	for key := range b {
+		keys[http.CanonicalHeaderKey(key)] = true
+	}
+	for _, key := range sortedKeys(keys) {
+		if ignored[key] {
+			continue
+		}
+		valueA, valueB := strings.Join(a.Values(key), ", "), strings.Join(b.Values(key), ", ")
+		if valueA != valueB {
+			diff.add("header."+key, valueA, valueB)
+		}
+	}
+}
+
+// diffValue 方法用于递归比较两个解析后的 JSON 值。
+func (diff *ResponseDiff) diffValue(prefix, path string, a, b any, ignored map[string]bool) {
+	if ignored[path] {
+		return
+	}
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	switch valueA := a.(type) {
+	case map[string]any:
+		if valueB, ok := b.(map[string]any); ok {
+			keys := map[string]bool{}
+			for key := range valueA {
+				keys[key] = true
+			}
+			for key := range valueB {
+				keys[key] = true
+			}
+			for _, key := range sortedKeys(keys) {
+				diff.diffValue(prefix, join(key), valueA[key], valueB[key], ignored)
+			}
+			return
+		}
+	case []any:
+		if valueB, ok := b.([]any); ok {
+			for i := 0; i < len(valueA) || i < len(valueB); i++ {
+				var itemA, itemB any
+				if i < len(valueA) {
+					itemA = valueA[i]
+				}
+				if i < len(valueB) {
+					itemB = valueB[i]
+				}
+				diff.diffValue(prefix, join(strconv.Itoa(i)), itemA, itemB, ignored)
+			}
+			return
+		}
+	}
+	if numberA, ok := a.(json.Number); ok {
+		// 1 与 1.0 视为相同的数字
+		if numberB, ok := b.(json.Number); ok && numberEqual(numberA, numberB) {
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		full := prefix
+		if path != "" {
+			full += "." + path
+		}
+		diff.add(full, jsonString(a), jsonString(b))
+	}
+}
+
+// normalizeJson 方法用于把 JSON 解析为可比较的值, 数字保留原始的文本表示。
+func normalizeJson(body []byte) (any, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return nil, false
+	}
+	return value, true
+}
+
+// jsonString 方法用于把解析后的 JSON 值格式化为紧凑的 JSON 文本, 不存在的值返回空字符串。
+func jsonString(value any) string {
+	if value == nil {
+		return ""
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(b)
+}
+
+// numberEqual 方法用于判断两个 JSON 数字的值是否相等。
+func numberEqual(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+	x, _, errA := big.ParseFloat(string(a), 10, 256, big.ToNearestEven)
+	y, _, errB := big.ParseFloat(string(b), 10, 256, big.ToNearestEven)
+	return errA == nil && errB == nil && x.Cmp(y) == 0
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,263 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/context"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ipFailureCooldown 为连接失败的 IP 被排在其他 IP 之后的时长。
+const ipFailureCooldown = 30 * time.Second
+
+// minDialTimeout 为域名有多个 IP 时分配给每个 IP 的最短连接超时。
+const minDialTimeout = 2 * time.Second
+
+// defaultFallbackDelay 为 dialer 没有设置 FallbackDelay 时, 首选协议族开始连接后到备用协议族开始连接的时长, 与 net 包相同。
+const defaultFallbackDelay = 300 * time.Millisecond
+
+// dialFailover 方法用于连接 address。address 中的主机是域名且解析出多个 IP 时, 按照 net.Dialer 的方式连接:
+// IP 按照协议族分为首选和备用两组, 每组内依次连接, 首选组开始 FallbackDelay 后或者首选组全部失败时备用组同时开始连接 (Happy Eyeballs),
+// 先成功的连接被使用。与 net.Dialer 的区别是最近连接失败的 IP 会排在同组其他 IP 之后, 避免每个新连接都先等待不可用的 IP 超时。
+// 实际连接的 IP 可以通过 TraceInfo.RemoteIP 获取。
+func (client *Client) dialFailover(ctx context.Context, dialer *net.Dialer, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, address)
+	}
+	ips, err := client.lookupIP(ctx, dialer, network, host, port)
+	if err != nil {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: err}
+	}
+	if len(ips) == 1 {
+		return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].String(), port))
+	}
+	client.orderIPs(ips)
+	if dialer.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, dialer.Timeout)
+		defer cancel()
+	}
+	primaries, fallbacks := partitionIPs(ips)
+	if len(fallbacks) == 0 || dialer.FallbackDelay < 0 {
+		return client.dialSerial(ctx, dialer, network, host, port, append(primaries, fallbacks...))
+	}
+	return client.dialParallel(ctx, dialer, network, host, port, primaries, fallbacks)
+}
+
+// partitionIPs 方法用于把 IP 分为与第一个 IP 协议族相同的首选组和其余的备用组, 组内保持原来的顺序。
+func partitionIPs(ips []net.IP) (primaries, fallbacks []net.IP) {
+	isIPv4 := ips[0].To4() != nil
+	for _, ip := range ips {
+		if (ip.To4() != nil) == isIPv4 {
+			primaries = append(primaries, ip)
+		} else {
+			fallbacks = append(fallbacks, ip)
+		}
+	}
+	return primaries, fallbacks
+}
+
+// dialParallel 方法用于同时连接首选组和备用组, 备用组在 FallbackDelay 后或者首选组失败时开始, 返回先成功的连接,
+// 都失败时返回首选组的错误。
+func (client *Client) dialParallel(ctx context.Context, dialer *net.Dialer, network, host, port string, primaries, fallbacks []net.IP) (net.Conn, error) {
+	type dialResult struct {
+		conn    net.Conn
+		err     error
+		primary bool
+		done    bool
+	}
+	results := make(chan dialResult)
+	returned := make(chan struct{})
+	defer close(returned)
+	start := func(ctx context.Context, primary bool) {
+		ips := primaries
+		if !primary {
+			ips = fallbacks
+		}
+		conn, err := client.dialSerial(ctx, dialer, network, host, port, ips)
+		select {
+		case results <- dialResult{conn: conn, err: err, primary: primary, done: true}:
+		case <-returned:
+			if conn != nil {
+				_ = conn.Close()
+			}
+		}
+	}
+	primaryCtx, primaryCancel := context.WithCancel(ctx)
+	defer primaryCancel()
+	go start(primaryCtx, true)
+	delay := dialer.FallbackDelay
+	if delay == 0 {
+		delay = defaultFallbackDelay
+	}
+	fallbackTimer := time.NewTimer(delay)
+	defer fallbackTimer.Stop()
+	fallbackCtx, fallbackCancel := context.WithCancel(ctx)
+	defer fallbackCancel()
+	var primary, fallback dialResult
+	for {
+		select {
+		case <-fallbackTimer.C:
+			go start(fallbackCtx, false)
+		case res := <-results:
+			if res.err == nil {
+				return res.conn, nil
+			}
+			if res.primary {
+				primary = res
+			} else {
+				fallback = res
+			}
+			if primary.done && fallback.done {
+				return nil, primary.err
+			}
+			// 首选组已经失败且备用组还没有开始时立即开始备用组
+			if res.primary && fallbackTimer.Stop() {
+				fallbackTimer.Reset(0)
+			}
+		}
+	}
+}
+
+// dialSerial 方法用于依次连接 ips 中的每个 IP, 连接失败时立即尝试下一个。ctx 的剩余时间在剩余的 IP 之间平均分配,
+// 每个 IP 至少 minDialTimeout。因为另一组已经连接成功或者请求被取消而中止的连接不会被记录为失败。
+func (client *Client) dialSerial(ctx context.Context, dialer *net.Dialer, network, host, port string, ips []net.IP) (net.Conn, error) {
+	perIP := *dialer
+	perIP.Timeout = 0
+	var err error
+	for i, ip := range ips {
+		dialCtx, cancel := ctx, context.CancelFunc(func() {})
+		if deadline, ok := ctx.Deadline(); ok {
+			remaining := time.Until(deadline)
+			timeout := remaining / time.Duration(len(ips)-i)
+			if timeout < minDialTimeout {
+				timeout = minDialTimeout
+				if remaining < minDialTimeout {
+					timeout = remaining
+				}
+			}
+			if timeout <= 0 {
+				break
+			}
+			dialCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		var conn net.Conn
+		conn, err = perIP.DialContext(dialCtx, network, net.JoinHostPort(ip.String(), port))
+		cancel()
+		if err == nil {
+			client.failedIPs.Delete(ip.String())
+			return conn, nil
+		}
+		if ctx.Err() == context.Canceled {
+			break
+		}
+		client.failedIPs.Store(ip.String(), time.Now())
+		if client.GetClientDebug() {
+			client.LogDebug("dial " + ip.String() + " for " + host + " failed, trying the next address: " + err.Error())
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = &net.OpError{Op: "dial", Net: network, Err: os.ErrDeadlineExceeded}
+	}
+	return nil, err
+}
+
+// lookupIP 方法用于解析 host, 优先使用 SetResolveOverride 设置的 IP, 并只返回与 network 协议族相同的 IP。
+func (client *Client) lookupIP(ctx context.Context, dialer *net.Dialer, network, host, port string) ([]net.IP, error) {
+	ipNetwork := "ip"
+	switch network {
+	case "tcp4", "udp4":
+		ipNetwork = "ip4"
+	case "tcp6", "udp6":
+		ipNetwork = "ip6"
+	}
+	client.RLock()
+	overrides := client.resolveOverrides
+	client.RUnlock()
+	host = strings.ToLower(host)
+	override, ok := overrides[net.JoinHostPort(host, port)]
+	if !ok {
+		override, ok = overrides[host]
+	}
+	if !ok {
+		resolver := dialer.Resolver
+		if resolver == nil {
+			resolver = net.DefaultResolver
+		}
+		return resolver.LookupIP(ctx, ipNetwork, host)
+	}
+	ips := make([]net.IP, 0, len(override))
+	for _, ip := range override {
+		if ipNetwork == "ip" || (ipNetwork == "ip4") == (ip.To4() != nil) {
+			ips = append(ips, ip)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no override address for " + network, Name: host, IsNotFound: true}
+	}
+	return ips, nil
+}
+
+// SetResolveOverride 方法用于把主机固定解析到指定的 IP, 与 curl --resolve 相同。它接收一个 string 类型的参数表示主机,
+// 可以带端口 (例如 api.example.com:443, 只对该端口生效) 或不带端口 (对所有端口生效), 以及任意个 string 类型的参数表示 IP,
+// 有多个 IP 时按顺序尝试, 不传 IP 时取消该主机的设置。请求的 Host 请求头和 TLS 的 SNI、证书校验仍然使用原来的主机名,
+// 适用于公共 DNS 被污染或者按地区屏蔽的场景。设置了代理时只影响与代理服务器的连接。
+func (client *Client) SetResolveOverride(host string, ips ...string) *Client {
+	if client.dialer == nil || client.GetTransport() == nil {
+		client.LogError(errors.New("dialer is not managed by builder"), host, "dns.go", "SetResolveOverride")
+		return client
+	}
+	key := strings.ToLower(strings.TrimSpace(host))
+	if h, port, err := net.SplitHostPort(key); err == nil {
+		key = net.JoinHostPort(h, port)
+	}
+	parsed := make([]net.IP, 0, len(ips))
+	for _, value := range ips {
+		ip := net.ParseIP(strings.TrimSpace(value))
+		if ip == nil {
+			client.LogError(fmt.Errorf("invalid IP address %q", value), host, "dns.go", "SetResolveOverride")
+			return client
+		}
+		parsed = append(parsed, ip)
+	}
+	client.Lock()
+	defer client.Unlock()
+	// 已建立的连接可能连接的是原来的 IP
+	defer client.GetTransport().CloseIdleConnections()
+	overrides := make(map[string][]net.IP, len(client.resolveOverrides)+1)
+	for k, v := range client.resolveOverrides {
+		overrides[k] = v
+	}
+	if len(parsed) == 0 {
+		delete(overrides, key)
+	} else {
+		overrides[key] = parsed
+	}
+	client.resolveOverrides = overrides
+	return client
+}
+
+// orderIPs 方法用于把最近连接失败的 IP 排到其他 IP 之后, 其余的 IP 保持解析结果的顺序。
+func (client *Client) orderIPs(ips []net.IP) {
+	failed := make(map[string]bool, len(ips))
+	for _, ip := range ips {
+		if value, ok := client.failedIPs.Load(ip.String()); ok {
+			if time.Since(value.(time.Time)) < ipFailureCooldown {
+				failed[ip.String()] = true
+			} else {
+				client.failedIPs.Delete(ip.String())
+			}
+		}
+	}
+	sort.SliceStable(ips, func(i, j int) bool {
+		return !failed[ips[i].String()] && failed[ips[j].String()]
+	})
+}
@@ -0,0 +1,58 @@
+package builder
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestPartitionIPs(t *testing.T) {
+	ips := []net.IP{net.ParseIP("::1"), net.ParseIP("10.0.0.1"), net.ParseIP("::2"), net.ParseIP("10.0.0.2")}
+	primaries, fallbacks := partitionIPs(ips)
+	if len(primaries) != 2 || !primaries[0].Equal(ips[0]) || !primaries[1].Equal(ips[2]) {
+		t.Errorf("primaries = %v, want [::1 ::2]", primaries)
+	}
+	if len(fallbacks) != 2 || !fallbacks[0].Equal(ips[1]) || !fallbacks[1].Equal(ips[3]) {
+		t.Errorf("fallbacks = %v, want [10.0.0.1 10.0.0.2]", fallbacks)
+	}
+}
+
+func TestDialFailoverFallsBackToOtherFamily(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	_, port, _ := net.SplitHostPort(u.Host)
+
+	// 首选组 ::1 上没有监听该端口, 失败后备用组的 127.0.0.1 应当立即开始连接, 而不必等待 FallbackDelay
+	client := NewClient().EnableTrace().SetResolveOverride("failover.test", "::1", "127.0.0.1")
+	response, err := client.R().Get("http://failover.test:" + port + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := response.String(); got != "ok" {
+		t.Errorf("body = %q, want %q", got, "ok")
+	}
+	if got := response.TraceInfo().RemoteIP; got != "127.0.0.1" {
+		t.Errorf("RemoteIP = %q, want %q", got, "127.0.0.1")
+	}
+	if _, ok := client.failedIPs.Load("::1"); !ok {
+		t.Error("::1 was not recorded as failed")
+	}
+	if _, ok := client.failedIPs.Load("127.0.0.1"); ok {
+		t.Error("127.0.0.1 was recorded as failed")
+	}
+
+	// 最近失败的 IP 排在同组之后, 两组只剩 127.0.0.1 可用, 第二次请求同样成功
+	ips := []net.IP{net.ParseIP("::1"), net.ParseIP("::2")}
+	client.orderIPs(ips)
+	if !ips[0].Equal(net.ParseIP("::2")) {
+		t.Errorf("orderIPs = %v, want ::2 first", ips)
+	}
+	if _, err = client.R().Get("http://failover.test:" + port + "/"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,281 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"github.com/catnovelapi/builder/pkg/files"
+	"golang.org/x/net/context"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DownloadOptions 类型用于存储多镜像下载的配置。
+type DownloadOptions struct {
+	// StallTimeout 为连续没有收到数据的最长时间, 超过后切换到下一个镜像继续下载, 默认为 30 秒
+	StallTimeout time.Duration
+	// MaxAttempts 为所有镜像的总尝试次数, 默认为镜像数量的 3 倍
+	MaxAttempts int
+	// Progress 在每次写入数据后被调用, downloaded 为已下载的字节数, total 为文件总大小, 未知时为 -1
+	Progress func(downloaded, total int64)
+	// ChecksumAlgorithm 为 Checksum 使用的校验算法, 支持 md5、sha1、sha256 和 sha512
+	ChecksumAlgorithm string
+	// Checksum 为文件期望的十六进制校验和, 设置后在下载的同时计算校验和, 不一致时删除 .part 文件并返回 *ChecksumError
+	Checksum string
+}
+
+const defaultStallTimeout = 30 * time.Second
+
+// Download 方法用于使用默认的 Client 从多个镜像下载文件, 详见 Client.Download。
+func Download(urls []string, path string) error {
+	return NewClient().Download(urls, path)
+}
+
+// Download 方法用于从多个镜像下载文件。它接收一个 []string 类型的参数，该参数表示按优先级排列的镜像地址,
+// 以及一个 string 类型的参数，该参数表示保存文件的路径。
+// 下载的数据先写入 path + ".part" 文件, 某个镜像失败或者停滞时会通过 Range 请求在下一个镜像上继续下载,
+// 下载完成后再重命名为 path。
+func (client *Client) Download(urls []string, path string) error {
+	return client.DownloadWithOptions(urls, path, DownloadOptions{})
+}
+
+// DownloadWithOptions 方法用于按指定的配置从多个镜像下载文件。它接收一个 DownloadOptions 类型的参数，该参数表示下载的配置。
+func (client *Client) DownloadWithOptions(urls []string, path string, opts DownloadOptions) error {
+	if len(urls) == 0 {
+		return errors.New("download Error: no mirror urls")
+	}
+	if opts.StallTimeout <= 0 {
+		opts.StallTimeout = defaultStallTimeout
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = len(urls) * 3
+	}
+	d := &downloader{client: client, total: -1, opts: opts}
+	if opts.Checksum != "" {
+		sum, err := newChecksum(opts.ChecksumAlgorithm, opts.Checksum)
+		if err != nil {
+			client.LogError(err, opts.Checksum, "download.go", "Download")
+			return err
+		}
+		d.checksum = sum
+		d.hash, _ = newChecksumHash(sum.algorithm)
+	}
+	part := path + ".part"
+	file, err := os.OpenFile(part, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		client.LogError(err, part, "download.go", "Download")
+		return err
+	}
+	d.file = file
+	// 已存在的 .part 文件来自上一次未完成的下载, 从它的末尾继续, 校验和需要包含已下载的部分
+	if d.offset, err = file.Seek(0, io.SeekEnd); err == nil && d.hash != nil {
+		_, err = io.Copy(d.hash, io.NewSectionReader(file, 0, d.offset))
+	}
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	for attempt := 0; attempt < opts.MaxAttempts; attempt++ {
+		mirror := urls[attempt%len(urls)]
+		if err = d.fetch(mirror); err == nil {
+			if err = file.Close(); err != nil {
+				return err
+			}
+			// 校验和在重命名之前比较, 不一致的内容不会出现在 path, 也不会被下一次下载继续使用
+			if d.checksum != nil {
+				if err = d.checksum.verify(d.hash); err != nil {
+					_ = os.Remove(part)
+					client.LogError(err, path, "download.go", "Download")
+					return err
+				}
+			}
+			return os.Rename(part, path)
+		}
+		client.LogError(err, mirror, "download.go", "Download")
+	}
+	_ = file.Close()
+	return fmt.Errorf("download Error: all mirrors failed after %d attempts: %w", opts.MaxAttempts, err)
+}
+
+// DownloadIfNewer 方法用于在服务器上的文件比本地文件新时下载并覆盖本地文件。它接收两个 string 类型的参数，分别表示文件的 URL
+// (可以是相对于 BaseURL 的路径) 和本地文件的路径。本地文件存在时根据其修改时间发送 If-Modified-Since, 服务器返回 304 时不写入文件;
+// 响应体以流的方式原子写入文件, 并把修改时间设置为响应的 Last-Modified, 适合定期刷新封面图片和目录。
+// 请求通过 client.R() 发送, 使用客户端的 BaseURL、Query 参数、Cookie、重试和限速等设置, 只跳过响应缓存, 以服务器的结果为准。
+// 它返回一个 bool 类型的参数，表示本地文件是否被更新。
+func (client *Client) DownloadIfNewer(url, path string) (bool, error) {
+	// SetDoNotParseResponse 使请求跳过响应缓存, 并让 SaveFile 直接把原始响应体写入文件
+	request := client.R().SetDoNotParseResponse()
+	modTime, err := files.LastModifiedTime(path)
+	if err == nil {
+		request.SetHeader("If-Modified-Since", modTime.UTC().Format(http.TimeFormat))
+	} else if !os.IsNotExist(err) {
+		client.LogError(err, path, "download.go", "DownloadIfNewer")
+		return false, err
+	}
+	response, err := request.Get(url)
+	if err != nil {
+		return false, err
+	}
+	defer response.Close()
+	switch response.GetStatusCode() {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+	default:
+		return false, fmt.Errorf("download Error: %s", response.ResponseRaw.Status)
+	}
+	if err = response.SaveFile(path); err != nil {
+		return false, err
+	}
+	// 使用服务器的时间作为修改时间, 下一次比较不受本地时钟的影响
+	if lastModified, err := http.ParseTime(response.GetHeader().Get("Last-Modified")); err == nil {
+		if err = os.Chtimes(path, lastModified, lastModified); err != nil {
+			client.LogError(err, path, "download.go", "DownloadIfNewer")
+		}
+	}
+	return true, nil
+}
+
+// downloader 类型用于存储一次多镜像下载的状态。
+type downloader struct {
+	client *Client
+	file   *os.File
+	offset int64 // offset 为已写入 .part 文件的字节数
+	total  int64 // total 为文件总大小, 未知时为 -1
+	opts   DownloadOptions
+	// checksum 和 hash 用于在设置了 DownloadOptions.Checksum 时计算已写入 .part 文件的内容的校验和
+	checksum *checksum
+	hash     hash.Hash
+}
+
+// fetch 方法用于从一个镜像下载文件的剩余部分, 下载完成时返回 nil。
+func (d *downloader) fetch(mirror string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	request := d.client.R()
+	if d.offset > 0 {
+		request.SetHeader("Range", fmt.Sprintf("bytes=%d-", d.offset))
+	}
+	req, err := http.NewRequestWithContext(ctx, MethodGet, mirror, nil)
+	if err != nil {
+		return err
+	}
+	req.Header = request.GetRequestHeader()
+	// 下载耗时取决于文件大小, 不使用客户端的整体超时, 而是由 StallTimeout 检测停滞
+	httpClient := *d.client.httpClientRaw
+	httpClient.Timeout = 0
+	// 计时器在发送请求之前启动, 接受连接却一直不返回响应头的镜像同样会被判定为停滞
+	timer := time.AfterFunc(d.opts.StallTimeout, cancel)
+	defer timer.Stop()
+	raw, err := httpClient.Do(d.client.stats.traceRequest(req))
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("download Error: no response received for %v", d.opts.StallTimeout)
+		}
+		return err
+	}
+	throttleResponse(raw, d.client.downloadThrottle)
+	defer raw.Body.Close()
+
+	switch raw.StatusCode {
+	case http.StatusPartialContent:
+		start, total, ok := parseContentRange(raw.Header.Get("Content-Range"))
+		if !ok || start != d.offset {
+			return fmt.Errorf("download Error: unexpected Content-Range %q", raw.Header.Get("Content-Range"))
+		}
+		d.total = total
+	case http.StatusOK:
+		// 镜像不支持 Range 请求, 从头开始下载
+		if d.offset > 0 {
+			if err = d.file.Truncate(0); err != nil {
+				return err
+			}
+			if _, err = d.file.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			d.offset = 0
+			if d.hash != nil {
+				d.hash.Reset()
+			}
+		}
+		d.total = raw.ContentLength
+	case http.StatusRequestedRangeNotSatisfiable:
+		// .part 文件已经包含完整的内容
+		if _, total, ok := parseContentRange(raw.Header.Get("Content-Range")); ok && total == d.offset {
+			return nil
+		}
+		return fmt.Errorf("download Error: %s", raw.Status)
+	default:
+		return fmt.Errorf("download Error: %s", raw.Status)
+	}
+
+	timer.Reset(d.opts.StallTimeout)
+	body := io.Reader(raw.Body)
+	if d.hash != nil {
+		body = io.TeeReader(raw.Body, d.hash)
+	}
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := body.Read(buf)
+		if n > 0 {
+			timer.Reset(d.opts.StallTimeout)
+			if _, err = d.file.Write(buf[:n]); err != nil {
+				return err
+			}
+			d.offset += int64(n)
+			d.client.stats.bytesReceived.Add(int64(n))
+			if d.opts.Progress != nil {
+				d.opts.Progress(d.offset, d.total)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("download Error: no data received for %v", d.opts.StallTimeout)
+			}
+			return readErr
+		}
+	}
+	if d.total >= 0 && d.offset != d.total {
+		return fmt.Errorf("download Error: incomplete body, got %d of %d bytes", d.offset, d.total)
+	}
+	return nil
+}
+
+// parseContentRange 方法用于解析 Content-Range 头部, 返回起始位置和文件总大小, 总大小未知时为 -1。
+func parseContentRange(value string) (start, total int64, ok bool) {
+	value, found := strings.CutPrefix(strings.TrimSpace(value), "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rangePart, totalPart, found := strings.Cut(value, "/")
+	if !found {
+		return 0, 0, false
+	}
+	total = -1
+	if totalPart != "*" {
+		var err error
+		if total, err = strconv.ParseInt(totalPart, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	if rangePart == "*" {
+		return 0, total, true
+	}
+	first, _, found := strings.Cut(rangePart, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, total, true
+}
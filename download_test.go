@@ -0,0 +1,152 @@
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDownloadHeaderStall 确认镜像接受连接却不返回响应头时会在 StallTimeout 后切换到下一个镜像。
+func TestDownloadHeaderStall(t *testing.T) {
+	release := make(chan struct{})
+	stalled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer stalled.Close()
+	defer close(release)
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("chapter"))
+	}))
+	defer healthy.Close()
+
+	path := filepath.Join(t.TempDir(), "chapter.txt")
+	done := make(chan error, 1)
+	go func() {
+		done <- NewClient().DownloadWithOptions([]string{stalled.URL, healthy.URL}, path,
+			DownloadOptions{StallTimeout: 100 * time.Millisecond})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("download did not fail over from a mirror that never sends headers")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "chapter" {
+		t.Errorf("downloaded %q, want %q", data, "chapter")
+	}
+}
+
+// TestDownloadIfNewerWithCache 确认开启响应缓存时, 服务器返回的 304 不会被当作文件已更新。
+func TestDownloadIfNewerWithCache(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		http.ServeContent(w, r, "cover.jpg", modified, strings.NewReader("cover"))
+	}))
+	defer server.Close()
+
+	client := NewClient().EnableConditionalRequest().SetCacheMode(StaleWhileRevalidate)
+	if _, err := client.R().Get(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "cover.jpg")
+	for i, want := range []bool{true, false, false} {
+		updated, err := client.DownloadIfNewer(server.URL, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated != want {
+			t.Errorf("call %d: updated = %v, want %v", i+1, updated, want)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(modified) {
+		t.Errorf("mtime = %v, want %v", info.ModTime(), modified)
+	}
+}
+
+// TestDownloadIfNewerUsesClientSettings 确认 DownloadIfNewer 使用客户端的 BaseURL、Query 参数和 Cookie。
+func TestDownloadIfNewerUsesClientSettings(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, _ := r.Cookie("session")
+		got = r.URL.Path + "?" + r.URL.RawQuery
+		if cookie != nil {
+			got += " session=" + cookie.Value
+		}
+		_, _ = w.Write([]byte("cover"))
+	}))
+	defer server.Close()
+
+	client := NewClient().SetBaseURL(server.URL).SetQueryParam("token", "abc").SetCookie(&http.Cookie{Name: "session", Value: "s1"})
+	path := filepath.Join(t.TempDir(), "cover.jpg")
+	updated, err := client.DownloadIfNewer("/covers/1.jpg", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/covers/1.jpg?token=abc session=s1"; !updated || got != want {
+		t.Errorf("updated = %v, request = %q, want true and %q", updated, got, want)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "cover" {
+		t.Errorf("downloaded %q, want %q", data, "cover")
+	}
+}
+
+// TestDownloadChecksum 确认下载时计算的校验和包含续传前已下载的部分, 不一致时不留下文件。
+func TestDownloadChecksum(t *testing.T) {
+	content := "volume one chapter one"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "book.txt", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+	sum := sha256.Sum256([]byte(content))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "book.txt")
+	if err := os.WriteFile(path+".part", []byte(content[:7]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := NewClient().DownloadWithOptions([]string{server.URL}, path,
+		DownloadOptions{ChecksumAlgorithm: "sha256", Checksum: hex.EncodeToString(sum[:])})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("downloaded %q, want %q", data, content)
+	}
+
+	path = filepath.Join(dir, "bad.txt")
+	err = NewClient().DownloadWithOptions([]string{server.URL}, path,
+		DownloadOptions{ChecksumAlgorithm: "sha256", Checksum: strings.Repeat("00", sha256.Size)})
+	var checksumErr *ChecksumError
+	if !errors.As(err, &checksumErr) {
+		t.Fatalf("err = %v, want *ChecksumError", err)
+	}
+	if checksumErr.Actual != hex.EncodeToString(sum[:]) {
+		t.Errorf("Actual = %s, want %s", checksumErr.Actual, hex.EncodeToString(sum[:]))
+	}
+	for _, name := range []string{path, path + ".part"} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("%s exists after checksum mismatch", filepath.Base(name))
+		}
+	}
+}
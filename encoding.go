@@ -0,0 +1,156 @@
+package builder
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// SetAcceptEncoding 方法用于设置请求的 Accept-Encoding 头部。它接收任意个 string 类型的参数，例如 gzip、deflate 或 br,
+// 请求中显式设置的 Accept-Encoding 头部优先。gzip 和 deflate 编码的响应体会被自动解压, 其他编码保持原样;
+// 需要保留压缩后的原始内容 (例如直接保存 .gz 文件) 时可以配合 DisableAutoDecompress 使用。
+func (client *Client) SetAcceptEncoding(encodings ...string) *Client {
+	client.acceptEncoding = strings.Join(encodings, ", ")
+	return client
+}
+
+// DisableAutoDecompress 方法用于关闭响应体的自动解压, 关闭后响应体为服务器返回的原始字节, Content-Encoding 头部保持不变。
+// 没有通过 SetAcceptEncoding 设置编码时, 请求不再自动携带 Accept-Encoding: gzip。
+func (client *Client) DisableAutoDecompress() *Client {
+	client.disableDecompress = true
+	if transport := client.transport("DisableAutoDecompress"); transport != nil {
+		transport.DisableCompression = true
+	}
+	return client
+}
+
+// setAcceptEncoding 方法用于在请求没有设置 Accept-Encoding 头部时使用 SetAcceptEncoding 的设置。
+// 没有设置时按照 net/http 的规则显式添加 Accept-Encoding: gzip, 由 decompressResponse 解压, 以便统计压缩前的字节数。
+func (request *Request) setAcceptEncoding(req *http.Request) {
+	if req.Header.Get("Accept-Encoding") != "" {
+		return
+	}
+	if request.client.acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", request.client.acceptEncoding)
+		return
+	}
+	transport := request.client.GetTransport()
+	if transport != nil && !transport.DisableCompression && !request.client.disableDecompress &&
+		req.Method != MethodHead && req.Header.Get("Range") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+}
+
+// decompressResponse 方法用于解压 gzip 和 deflate 编码的响应体。net/http 只在自己添加 Accept-Encoding 时才会自动解压,
+// 因此通过 SetAcceptEncoding 或请求头设置的编码需要在这里解压。
+func (request *Request) decompressResponse(raw *http.Response) {
+	if request.client.disableDecompress || raw == nil || raw.Body == nil || raw.Body == http.NoBody || raw.Uncompressed {
+		return
+	}
+	encoding := strings.ToLower(strings.TrimSpace(raw.Header.Get("Content-Encoding")))
+	if encoding != "gzip" && encoding != "x-gzip" && encoding != "deflate" {
+		return
+	}
+	raw.Body = &decompressBody{body: raw.Body, wire: &countingReader{reader: raw.Body}, encoding: encoding}
+	raw.Header.Del("Content-Encoding")
+	raw.Header.Del("Content-Length")
+	raw.ContentLength = -1
+	raw.Uncompressed = true
+}
+
+// decompressBody 类型用于在第一次读取时创建解压 reader。
+type decompressBody struct {
+	body     io.ReadCloser
+	wire     *countingReader // wire 用于统计读取的压缩数据的字节数
+	encoding string
+	reader   io.Reader
+	err      error
+}
+
+// Read 方法用于读取解压后的响应体。
+func (d *decompressBody) Read(p []byte) (int, error) {
+	if d.reader == nil && d.err == nil {
+		if d.encoding == "deflate" {
+			d.reader, d.err = newDeflateReader(d.wire)
+		} else if d.reader, d.err = gzip.NewReader(d.wire); d.err != nil {
+			d.err = fmt.Errorf("decompress Error: %w", d.err)
+		}
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.reader.Read(p)
+}
+
+// newDeflateReader 方法用于创建 deflate 解压 reader。HTTP 的 deflate 编码应为 zlib 格式,
+// 但部分服务器返回不带 zlib 头部的原始 deflate 数据, 因此根据前两个字节判断。
+func newDeflateReader(body io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(body)
+	header, err := buffered.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+// Close 方法用于关闭原始响应体。
+func (d *decompressBody) Close() error {
+	if closer, ok := d.reader.(io.Closer); ok {
+		_ = closer.Close()
+	}
+	return d.body.Close()
+}
+
+// countingReader 类型用于统计从 reader 读取的字节数。
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+// Read 方法用于读取数据并累加读取的字节数。
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ContentEncoding 方法用于获取服务器返回响应体时使用的压缩编码, 例如 gzip, 未压缩时返回空字符串。
+func (response *Response) ContentEncoding() string {
+	if d, ok := response.ResponseRaw.Body.(*decompressBody); ok {
+		return d.encoding
+	}
+	return response.ResponseRaw.Header.Get("Content-Encoding")
+}
+
+// DecodedBytes 方法用于获取解压后的响应体的字节数。
+func (response *Response) DecodedBytes() int64 {
+	body, _ := response.readBody()
+	return int64(len(body))
+}
+
+// WireBytes 方法用于获取响应体在网络上传输的字节数, 即解压前的字节数, 未压缩时与 DecodedBytes 相同。
+func (response *Response) WireBytes() int64 {
+	body, _ := response.readBody()
+	if d, ok := response.ResponseRaw.Body.(*decompressBody); ok {
+		return d.wire.n
+	}
+	return int64(len(body))
+}
+
+// CompressionRatio 方法用于获取响应体的压缩比, 即解压后的字节数与传输的字节数之比, 例如 5 表示压缩后只有原来的五分之一。
+// 未压缩时返回 1, 响应体为空时返回 0。
+func (response *Response) CompressionRatio() float64 {
+	wire := response.WireBytes()
+	if wire == 0 {
+		return 0
+	}
+	return float64(response.DecodedBytes()) / float64(wire)
+}
@@ -0,0 +1,84 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tidwall/gjson"
+)
+
+// EnvelopeCheck 类型用于检查 JSON 响应外层的状态信息, 例如要求 code 为 0, 返回的错误会使请求失败。
+type EnvelopeCheck func(result gjson.Result) error
+
+// APIError 类型用于存储接口在响应外层返回的业务错误, 可以通过 errors.As 从请求返回的错误中获取。
+type APIError struct {
+	StatusCode int          // HTTP 响应的状态码
+	Code       string       // 接口返回的业务状态码
+	Message    string       // 接口返回的错误信息
+	Result     gjson.Result // 完整的 JSON 响应
+}
+
+// Error 方法用于获取业务错误的描述。
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("api error: code %s", e.Code)
+	}
+	return fmt.Sprintf("api error: code %s: %s", e.Code, e.Message)
+}
+
+// SetEnvelopeCheck 方法用于设置检查 JSON 响应外层状态的函数。它接收一个 EnvelopeCheck 类型的参数，
+// 该函数对 Client 收到的所有 JSON 响应 (经过解码管道之后) 生效, 返回错误时请求失败, 非 JSON 的响应不会被检查。
+// 常见的 code/message 格式可以使用 CodeEnvelope 生成检查函数, 个别接口可以通过 Request.SkipEnvelopeCheck 跳过检查。
+func (client *Client) SetEnvelopeCheck(check EnvelopeCheck) *Client {
+	client.envelopeCheck = check
+	return client
+}
+
+// SkipEnvelopeCheck 方法用于跳过当前请求的响应外层检查, 适用于没有统一外层格式的接口。
+func (request *Request) SkipEnvelopeCheck() *Request {
+	request.skipEnvelope = true
+	return request
+}
+
+// CodeEnvelope 方法用于生成按业务状态码检查响应外层的 EnvelopeCheck。它接收两个 string 类型的参数，分别表示
+// 状态码和错误信息的 gjson 路径, 以及可选的表示成功的状态码 (默认为 "0")。状态码不存在或不是成功的状态码时返回 *APIError,
+// 例如 CodeEnvelope("code", "message") 或 CodeEnvelope("status", "msg", "100000")。
+func CodeEnvelope(codePath, messagePath string, okCodes ...string) EnvelopeCheck {
+	if len(okCodes) == 0 {
+		okCodes = []string{"0"}
+	}
+	return func(result gjson.Result) error {
+		code := result.Get(codePath)
+		for _, ok := range okCodes {
+			if code.Exists() && code.String() == ok {
+				return nil
+			}
+		}
+		return &APIError{Code: code.String(), Message: result.Get(messagePath).String(), Result: result}
+	}
+}
+
+// checkEnvelope 方法用于使用 SetEnvelopeCheck 设置的函数检查 JSON 响应, 返回的 *APIError 会补充 HTTP 状态码。
+func (response *Response) checkEnvelope() error {
+	request := response.RequestSource
+	check := request.client.envelopeCheck
+	if check == nil || request.skipEnvelope {
+		return nil
+	}
+	if response.Result != "" {
+		if !gjson.Valid(response.Result) {
+			return nil
+		}
+	} else if !gjson.ValidBytes(response.GetByte()) {
+		return nil
+	}
+	result := response.Gjson()
+	err := safeCall("EnvelopeCheck", func() error {
+		return check(result)
+	})
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == 0 {
+		apiErr.StatusCode = response.GetStatusCode()
+	}
+	return err
+}
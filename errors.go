@@ -0,0 +1,292 @@
+package builder
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"golang.org/x/net/context"
+	"io"
+	"net"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"syscall"
+)
+
+// ErrorKind 类型用于表示请求错误的类别。
+type ErrorKind int
+
+const (
+	// KindUnknown 表示无法归类的错误
+	KindUnknown ErrorKind = iota
+
+	// KindInvalidRequest 表示请求本身无效, 例如 URL 无法解析或请求体无法序列化
+	KindInvalidRequest
+
+	// KindDNS 表示域名解析失败
+	KindDNS
+
+	// KindConnection 表示建立或维持连接失败, 例如连接被拒绝或被重置
+	KindConnection
+
+	// KindTLS 表示 TLS 握手或证书校验失败
+	KindTLS
+
+	// KindTimeout 表示请求超时
+	KindTimeout
+
+	// KindCanceled 表示请求被调用方取消
+	KindCanceled
+
+	// KindHTTP 表示 HTTP 协议层面的错误
+	KindHTTP
+
+	// KindDecode 表示响应结果解码或校验失败
+	KindDecode
+)
+
+// String 方法用于获取错误类别的名称。
+func (kind ErrorKind) String() string {
+	switch kind {
+	case KindInvalidRequest:
+		return "invalid request"
+	case KindDNS:
+		return "dns"
+	case KindConnection:
+		return "connection"
+	case KindTLS:
+		return "tls"
+	case KindTimeout:
+		return "timeout"
+	case KindCanceled:
+		return "canceled"
+	case KindHTTP:
+		return "http"
+	case KindDecode:
+		return "decode"
+	default:
+		return "unknown"
+	}
+}
+
+// 以下哨兵错误可以配合 errors.Is 判断 *Error 的类别, 例如 errors.Is(err, builder.ErrTimeout)。
+var (
+	ErrInvalidRequest = errors.New("invalid request")
+	ErrDNS            = errors.New("dns error")
+	ErrConnection     = errors.New("connection error")
+	ErrTLS            = errors.New("tls error")
+	ErrTimeout        = errors.New("timeout")
+	ErrCanceled       = errors.New("request canceled")
+	ErrHTTP           = errors.New("http error")
+	ErrDecode         = errors.New("decode error")
+)
+
+// kindSentinels 用于把错误类别映射到对应的哨兵错误。
+var kindSentinels = map[ErrorKind]error{
+	KindInvalidRequest: ErrInvalidRequest,
+	KindDNS:            ErrDNS,
+	KindConnection:     ErrConnection,
+	KindTLS:            ErrTLS,
+	KindTimeout:        ErrTimeout,
+	KindCanceled:       ErrCanceled,
+	KindHTTP:           ErrHTTP,
+	KindDecode:         ErrDecode,
+}
+
+// Error 类型用于存储一次请求失败的详细信息, 可以通过 errors.As 获取, 通过 errors.Is 判断类别。
+type Error struct {
+	Kind       ErrorKind // 错误类别
+	Method     string    // HTTP 请求的 Method
+	URL        string    // HTTP 请求的 URL
+	Attempts   int       // 已尝试的次数, 请求未发送时为 0
+	StatusCode int       // HTTP 响应的状态码, 没有收到响应时为 0
+	Err        error     // 导致失败的原始错误
+}
+
+// Error 方法用于获取错误的描述。
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString("request Error: ")
+	if e.Method != "" {
+		b.WriteString(e.Method + " ")
+	}
+	b.WriteString(e.URL)
+	if e.Attempts > 1 {
+		fmt.Fprintf(&b, " (after %d attempts)", e.Attempts)
+	}
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, " status %d", e.StatusCode)
+	}
+	if cause := e.Err; cause != nil {
+		// *url.Error 的描述中已经包含了 Method 和 URL, 这里只输出其中的原因
+		var urlErr *url.Error
+		if errors.As(cause, &urlErr) && urlErr.Err != nil {
+			cause = urlErr.Err
+		}
+		b.WriteString(": " + cause.Error())
+	}
+	return b.String()
+}
+
+// Unwrap 方法用于获取导致失败的原始错误。
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is 方法用于支持 errors.Is 按错误类别匹配哨兵错误。
+func (e *Error) Is(target error) bool {
+	sentinel, ok := kindSentinels[e.Kind]
+	return ok && sentinel == target
+}
+
+// newError 方法用于创建一个 *Error, 未指定类别时根据原始错误自动归类。
+func (request *Request) newError(kind ErrorKind, attempts int, err error) *Error {
+	if kind == KindUnknown {
+		kind = classifyError(err)
+	}
+	e := &Error{Kind: kind, Method: request.Method, Attempts: attempts, Err: err}
+	if request.URL != nil {
+		e.URL = request.URL.String()
+	}
+	return e
+}
+
+// classifyError 方法用于根据原始错误判断错误类别。
+func classifyError(err error) ErrorKind {
+	if err == nil {
+		return KindUnknown
+	}
+	var builderErr *Error
+	if errors.As(err, &builderErr) {
+		return builderErr.Kind
+	}
+	if errors.Is(err, context.Canceled) {
+		return KindCanceled
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsTimeout {
+			return KindTimeout
+		}
+		return KindDNS
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return KindTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return KindTimeout
+	}
+	if isTLSError(err) {
+		return KindTLS
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.EHOSTUNREACH) || errors.Is(err, syscall.ENETUNREACH) {
+		return KindConnection
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return KindConnection
+	}
+	return KindUnknown
+}
+
+// isTLSError 方法用于判断错误是否发生在 TLS 握手或证书校验阶段。
+func isTLSError(err error) bool {
+	var recordErr tls.RecordHeaderError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	return errors.As(err, &recordErr) || errors.As(err, &certErr) ||
+		errors.As(err, &unknownAuthority) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) ||
+		strings.Contains(err.Error(), "tls: ")
+}
+
+// newDecodeError 方法用于创建一个响应结果解码或校验失败的 *Error。
+func (response *Response) newDecodeError(err error) *Error {
+	e := response.RequestSource.newError(KindDecode, 0, err)
+	e.StatusCode = response.GetStatusCode()
+	return e
+}
+
+// IsTimeout 方法用于判断错误是否由请求超时引起。
+func IsTimeout(err error) bool {
+	return err != nil && classifyError(err) == KindTimeout
+}
+
+// IsDNSError 方法用于判断错误是否由域名解析失败引起。
+func IsDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) || errors.Is(err, ErrDNS)
+}
+
+// IsConnectionReset 方法用于判断错误是否由连接被对端重置或意外关闭引起, 这类错误通常可以安全地重试。
+func IsConnectionReset(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	// 服务端在返回响应前关闭连接时, net/http 返回包装后的 io.EOF
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && errors.Is(urlErr.Err, io.EOF) {
+		return true
+	}
+	return strings.Contains(err.Error(), "connection reset by peer")
+}
+
+// IsUnexpectedEOF 方法用于判断错误是否由响应在读取完成之前意外结束引起。
+func IsUnexpectedEOF(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && errors.Is(urlErr.Err, io.EOF)
+}
+
+// IsTLSHandshakeTimeout 方法用于判断错误是否由 TLS 握手超时引起。
+func IsTLSHandshakeTimeout(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "TLS handshake timeout")
+}
+
+// IsProxyError 方法用于判断错误是否发生在连接代理或代理建立隧道的阶段。
+func IsProxyError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "proxyconnect" {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "proxyconnect") || strings.Contains(msg, "socks connect")
+}
+
+// PanicError 类型用于存储用户提供的函数 (解码器、钩子、序列化函数等) 发生 panic 时的信息。
+type PanicError struct {
+	Func  string // 发生 panic 的函数名称
+	Value any    // recover 得到的值
+	Stack []byte // 发生 panic 时的调用栈
+}
+
+// Error 方法用于获取错误的描述, 包含 panic 的值和调用栈。
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in %s: %v\n%s", e.Func, e.Value, e.Stack)
+}
+
+// safeCall 方法用于调用用户提供的函数, 把其中发生的 panic 转换为 *PanicError, 避免单个函数的错误导致整个程序崩溃。
+func safeCall(name string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Func: name, Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,128 @@
+package builder
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// EventType 类型用于表示请求生命周期中的事件类型。
+type EventType string
+
+const (
+	// EventRequestQueued 表示请求已经准备好, 正在等待调度发送
+	EventRequestQueued EventType = "RequestQueued"
+	// EventDNSStart 表示开始解析主机名
+	EventDNSStart EventType = "DNSStart"
+	// EventRetry 表示请求失败后即将重试
+	EventRetry EventType = "Retry"
+	// EventResponseReceived 表示收到了服务器的响应头
+	EventResponseReceived EventType = "ResponseReceived"
+	// EventDecodeFinished 表示响应体已经读取并完成解码
+	EventDecodeFinished EventType = "DecodeFinished"
+)
+
+// Event 类型用于存储一个请求生命周期事件。
+type Event struct {
+	Type       EventType
+	Time       time.Time
+	RequestID  string // RequestID 为请求 ID, 未开启 EnableRequestID 时为空
+	Method     string
+	URL        string
+	Attempt    int   // Attempt 为事件发生时的尝试次数, 从 1 开始
+	StatusCode int   // StatusCode 为 ResponseReceived 事件的状态码
+	Err        error // Err 为 Retry 和 DecodeFinished 事件的错误
+}
+
+// eventBufferSize 为每个订阅者的事件缓冲区大小, 缓冲区已满时新的事件会被丢弃, 以免阻塞请求。
+const eventBufferSize = 256
+
+// eventBus 类型用于把事件分发给所有订阅者。
+type eventBus struct {
+	mu          sync.RWMutex
+	subscribers []chan Event
+	closed      bool
+}
+
+// subscribe 方法用于添加一个订阅者。
+func (bus *eventBus) subscribe() <-chan Event {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	ch := make(chan Event, eventBufferSize)
+	if bus.closed {
+		close(ch)
+		return ch
+	}
+	bus.subscribers = append(bus.subscribers, ch)
+	return ch
+}
+
+// hasSubscribers 方法用于判断是否存在订阅者, 没有订阅者时无需构造事件。
+func (bus *eventBus) hasSubscribers() bool {
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+	return len(bus.subscribers) > 0
+}
+
+// publish 方法用于把事件发送给所有订阅者, 订阅者的缓冲区已满时丢弃该事件。
+func (bus *eventBus) publish(event Event) {
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+	for _, ch := range bus.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// close 方法用于关闭所有订阅者的 channel。
+func (bus *eventBus) close() {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if bus.closed {
+		return
+	}
+	bus.closed = true
+	for _, ch := range bus.subscribers {
+		close(ch)
+	}
+	bus.subscribers = nil
+}
+
+// Events 方法用于订阅请求生命周期事件, 每次调用都返回一个新的 channel, 可用于长时间爬取时的监控面板或终端界面。
+// 事件不会阻塞请求, 订阅者处理不及时导致缓冲区已满时新的事件会被丢弃。Client.Close 会关闭所有返回的 channel。
+func (client *Client) Events() <-chan Event {
+	return client.events.subscribe()
+}
+
+// emit 方法用于发布一个与当前请求相关的事件。
+func (request *Request) emit(eventType EventType, attempt int, statusCode int, err error) {
+	if !request.client.events.hasSubscribers() {
+		return
+	}
+	request.client.events.publish(Event{
+		Type:       eventType,
+		Time:       time.Now(),
+		RequestID:  request.requestID,
+		Method:     request.Method,
+		URL:        request.URL.String(),
+		Attempt:    attempt,
+		StatusCode: statusCode,
+		Err:        err,
+	})
+}
+
+// traceEvents 方法用于在存在订阅者时为 http.Request 添加发布 DNSStart 事件的 httptrace.ClientTrace。
+func (request *Request) traceEvents(req *http.Request, attempt int) *http.Request {
+	if !request.client.events.hasSubscribers() {
+		return req
+	}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			request.emit(EventDNSStart, attempt, 0, nil)
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
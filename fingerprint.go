@@ -0,0 +1,149 @@
+package builder
+
+import (
+	"crypto/tls"
+	"math/rand"
+	"net/http"
+	"sync"
+)
+
+// Fingerprint 类型用于描述一组客户端特征, 包括 User-Agent、附加的请求头和 TLS ClientHello 的参数。
+// 同一个 Fingerprint 中的各项应当来自同一种浏览器, 避免出现 Chrome 的 User-Agent 搭配 Firefox 的请求头这种容易被识别的组合。
+// net/http 按固定的顺序写入请求头, 因此请求头的顺序无法通过 Fingerprint 控制。
+type Fingerprint struct {
+	Name             string            // 指纹的名称, 用于日志和调试
+	UserAgent        string            // User-Agent 请求头
+	Headers          map[string]string // 附加的请求头, 例如 Accept、Accept-Language 和 Sec-CH-UA
+	CipherSuites     []uint16          // TLS 1.2 及以下版本可用的加密套件, 为空时使用默认值
+	CurvePreferences []tls.CurveID     // 密钥交换使用的椭圆曲线, 按优先顺序排列, 为空时使用默认值
+	MinVersion       uint16            // 最低 TLS 版本, 为 0 时使用默认值
+	MaxVersion       uint16            // 最高 TLS 版本, 为 0 时使用默认值
+}
+
+// fingerprintPool 类型用于存储 SetFingerprintPool 设置的指纹池和每个指纹对应的 Transport。
+type fingerprintPool struct {
+	fingerprints []Fingerprint
+	sticky       bool
+	hosts        sync.Map // hosts 用于存储固定分配时每个主机使用的指纹序号
+
+	mu         sync.Mutex
+	base       *http.Transport   // base 用于存储生成 transports 时使用的 Transport
+	transports []*http.Transport // transports 用于存储每个指纹对应的 Transport, 按需创建
+}
+
+// SetFingerprintPool 方法用于设置请求轮换使用的客户端指纹池。它接收一个 bool 类型的参数和若干个 Fingerprint 类型的参数,
+// sticky 为 true 时同一个主机固定使用第一次随机分配到的指纹, 为 false 时每个请求随机选择一个指纹。
+// 每个指纹使用从客户端 Transport 复制得到的独立 Transport 发送请求, 因此需要在修改 Transport 的设置之后再调用该方法。
+// 指纹的 User-Agent 和请求头会覆盖客户端的设置, 但不会覆盖通过 Request.SetHeader 单独设置的值。不传入指纹时关闭轮换。
+// 请求头的顺序不在指纹的控制范围之内: HTTP/1.1 的请求头由 net/http 按固定的规则写入, HTTP/2 的请求头由 HPACK 编码,
+// 都无法按浏览器的顺序发送; ClientHello 中扩展的顺序同样由 crypto/tls 决定。需要模拟这些特征时请使用 SetTransport 设置专门的 Transport。
+func (client *Client) SetFingerprintPool(sticky bool, fingerprints ...Fingerprint) *Client {
+	var pool *fingerprintPool
+	if len(fingerprints) > 0 {
+		pool = &fingerprintPool{fingerprints: fingerprints, sticky: sticky}
+	}
+	client.Lock()
+	old := client.fingerprints
+	client.fingerprints = pool
+	client.Unlock()
+	if old != nil {
+		old.closeIdleConnections()
+	}
+	return client
+}
+
+// pick 方法用于为 host 选择一个指纹, 返回指纹的序号。
+func (pool *fingerprintPool) pick(host string) int {
+	if !pool.sticky {
+		return rand.Intn(len(pool.fingerprints))
+	}
+	index, _ := pool.hosts.LoadOrStore(host, rand.Intn(len(pool.fingerprints)))
+	return index.(int)
+}
+
+// transport 方法用于获取第 index 个指纹对应的 Transport, 客户端的 Transport 不是 *http.Transport 时返回 nil。
+func (pool *fingerprintPool) transport(base *http.Transport, index int) *http.Transport {
+	if base == nil {
+		return nil
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if pool.base != base {
+		// 客户端的 Transport 被替换后重新生成
+		for _, transport := range pool.transports {
+			if transport != nil {
+				transport.CloseIdleConnections()
+			}
+		}
+		pool.base, pool.transports = base, make([]*http.Transport, len(pool.fingerprints))
+	}
+	if pool.transports[index] == nil {
+		pool.transports[index] = pool.fingerprints[index].newTransport(base)
+	}
+	return pool.transports[index]
+}
+
+// closeIdleConnections 方法用于关闭所有指纹对应的 Transport 中的空闲连接。
+func (pool *fingerprintPool) closeIdleConnections() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	for _, transport := range pool.transports {
+		if transport != nil {
+			transport.CloseIdleConnections()
+		}
+	}
+}
+
+// newTransport 方法用于复制 base 并按照指纹设置 TLS 参数。
+func (fingerprint Fingerprint) newTransport(base *http.Transport) *http.Transport {
+	transport := base.Clone()
+	config := &tls.Config{}
+	if transport.TLSClientConfig != nil {
+		config = transport.TLSClientConfig.Clone()
+	}
+	if len(fingerprint.CipherSuites) > 0 {
+		config.CipherSuites = fingerprint.CipherSuites
+	}
+	if len(fingerprint.CurvePreferences) > 0 {
+		config.CurvePreferences = fingerprint.CurvePreferences
+	}
+	if fingerprint.MinVersion != 0 {
+		config.MinVersion = fingerprint.MinVersion
+	}
+	if fingerprint.MaxVersion != 0 {
+		config.MaxVersion = fingerprint.MaxVersion
+	}
+	transport.TLSClientConfig = config
+	return transport
+}
+
+// applyFingerprint 方法用于为请求选择指纹并设置对应的请求头, 选择的指纹在重试时保持不变。
+func (request *Request) applyFingerprint(req *http.Request) {
+	client := request.client
+	client.RLock()
+	pool := client.fingerprints
+	inherited := make(http.Header, len(client.Header))
+	for key, value := range client.Header {
+		inherited.Set(key, value)
+	}
+	client.RUnlock()
+	request.fingerprint = nil
+	if pool == nil {
+		return
+	}
+	index := pool.pick(req.URL.Hostname())
+	fingerprint := pool.fingerprints[index]
+	set := func(key, value string) {
+		// 只覆盖从客户端继承的请求头
+		if current := req.Header.Get(key); current == "" || current == inherited.Get(key) {
+			req.Header.Set(key, value)
+		}
+	}
+	if fingerprint.UserAgent != "" {
+		set("User-Agent", fingerprint.UserAgent)
+	}
+	for key, value := range fingerprint.Headers {
+		set(key, value)
+	}
+	request.fingerprint = pool.transport(client.GetTransport(), index)
+}
@@ -0,0 +1,67 @@
+package builder
+
+import "golang.org/x/net/context"
+
+// Future 类型用于表示一个异步执行中的 HTTP 请求。
+type Future struct {
+	done     chan struct{}
+	cancel   context.CancelFunc
+	response *Response
+	err      error
+}
+
+// Done 方法用于获取一个在请求完成后被关闭的 channel。
+func (future *Future) Done() <-chan struct{} {
+	return future.done
+}
+
+// Result 方法用于等待请求完成并返回请求的响应和错误。
+func (future *Future) Result() (*Response, error) {
+	<-future.done
+	return future.response, future.err
+}
+
+// Cancel 方法用于取消尚未完成的请求, 被取消的请求会以 context.Canceled 错误结束。
+func (future *Future) Cancel() {
+	future.cancel()
+}
+
+// Async 方法用于异步发送 HTTP 请求。它接收两个 string 类型的参数，分别表示 HTTP 请求的 Method 和路径。
+func (request *Request) Async(method, path string) *Future {
+	ctx, cancel := context.WithCancel(request.ctx)
+	request.ctx = ctx
+	future := &Future{done: make(chan struct{}), cancel: cancel}
+	go func() {
+		defer close(future.done)
+		future.err = safeCall("Async", func() (err error) {
+			future.response, err = request.newResponse(method, path)
+			return err
+		})
+	}()
+	return future
+}
+
+// GetAsync 方法用于异步创建一个 GET 请求。它接收一个 string 类型的参数，表示 HTTP 请求的路径。
+func (request *Request) GetAsync(path string) *Future {
+	return request.Async(MethodGet, path)
+}
+
+// PostAsync 方法用于异步创建一个 POST 请求。它接收一个 string 类型的参数，表示 HTTP 请求的路径。
+func (request *Request) PostAsync(path string) *Future {
+	return request.Async(MethodPost, path)
+}
+
+// PutAsync 方法用于异步创建一个 PUT 请求。它接收一个 string 类型的参数，表示 HTTP 请求的路径。
+func (request *Request) PutAsync(path string) *Future {
+	return request.Async(MethodPut, path)
+}
+
+// DeleteAsync 方法用于异步创建一个 DELETE 请求。它接收一个 string 类型的参数，表示 HTTP 请求的路径。
+func (request *Request) DeleteAsync(path string) *Future {
+	return request.Async(MethodDelete, path)
+}
+
+// PatchAsync 方法用于异步创建一个 PATCH 请求。它接收一个 string 类型的参数，表示 HTTP 请求的路径。
+func (request *Request) PatchAsync(path string) *Future {
+	return request.Async(MethodPatch, path)
+}
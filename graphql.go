@@ -0,0 +1,130 @@
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// GraphQLClient 类型用于向 GraphQL 端点发送查询。
+type GraphQLClient struct {
+	client          *Client
+	endpoint        string
+	persistedQuery  bool
+	persistedHashes sync.Map // persistedHashes 用于缓存查询语句的 SHA-256 哈希
+}
+
+// GraphQLError 类型用于表示 GraphQL 响应中 errors 字段的一项。
+type GraphQLError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// GraphQLErrors 类型用于表示 GraphQL 响应返回的错误列表。
+type GraphQLErrors []GraphQLError
+
+// Error 方法用于获取错误的描述。
+func (errs GraphQLErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Message)
+	}
+	return "graphql Error: " + strings.Join(messages, "; ")
+}
+
+// graphQLResponse 类型用于解析 GraphQL 响应的外层结构。
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors GraphQLErrors   `json:"errors"`
+}
+
+const persistedQueryNotFound = "PersistedQueryNotFound"
+
+// GraphQL 方法用于创建一个向 endpoint 发送查询的 GraphQLClient。它接收一个 string 类型的参数，该参数表示 GraphQL 端点的路径或 URL。
+func (client *Client) GraphQL(endpoint string) *GraphQLClient {
+	return &GraphQLClient{client: client, endpoint: endpoint}
+}
+
+// EnablePersistedQueries 方法用于开启自动持久化查询 (Automatic Persisted Queries)。开启后查询首先只发送其 SHA-256 哈希,
+// 服务器返回 PersistedQueryNotFound 时再携带完整的查询重新发送, 以减少重复查询的请求体大小。
+func (gql *GraphQLClient) EnablePersistedQueries() *GraphQLClient {
+	gql.persistedQuery = true
+	return gql
+}
+
+// Query 方法用于发送 GraphQL 查询或变更。它接收一个 string 类型的参数表示查询语句, 一个 map[string]any 类型的参数表示变量,
+// 以及一个 any 类型的参数，该参数必须是指针类型, 用于接收响应中的 data 字段。
+// 响应中包含 errors 字段时, 仍会解析 data 字段, 并返回 GraphQLErrors。
+func (gql *GraphQLClient) Query(query string, variables map[string]any, result any) error {
+	payload := map[string]any{"query": query}
+	if len(variables) > 0 {
+		payload["variables"] = variables
+	}
+	if gql.persistedQuery {
+		delete(payload, "query")
+		payload["extensions"] = map[string]any{
+			"persistedQuery": map[string]any{"version": 1, "sha256Hash": gql.queryHash(query)},
+		}
+	}
+	body, err := gql.send(payload)
+	if err == nil && gql.persistedQuery && body.Errors.hasPersistedQueryNotFound() {
+		payload["query"] = query
+		body, err = gql.send(payload)
+	}
+	if err != nil {
+		return err
+	}
+	if result != nil && len(body.Data) > 0 && string(body.Data) != "null" {
+		err = safeCall("JSONUnmarshal", func() error {
+			return gql.client.JSONUnmarshal(body.Data, result)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(body.Errors) > 0 {
+		return body.Errors
+	}
+	return nil
+}
+
+// send 方法用于发送 GraphQL 请求并解析响应的外层结构。
+func (gql *GraphQLClient) send(payload map[string]any) (*graphQLResponse, error) {
+	response, err := gql.client.R().SetBodyJson(payload).Post(gql.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	var body graphQLResponse
+	err = safeCall("JSONUnmarshal", func() error {
+		return gql.client.JSONUnmarshal(response.GetByte(), &body)
+	})
+	if err != nil {
+		return nil, response.newDecodeError(fmt.Errorf("invalid graphql response (%s): %w", response.GetStatus(), err))
+	}
+	return &body, nil
+}
+
+// queryHash 方法用于计算查询语句的 SHA-256 哈希, 结果会被缓存。
+func (gql *GraphQLClient) queryHash(query string) string {
+	if hash, ok := gql.persistedHashes.Load(query); ok {
+		return hash.(string)
+	}
+	sum := sha256.Sum256([]byte(query))
+	hash := hex.EncodeToString(sum[:])
+	gql.persistedHashes.Store(query, hash)
+	return hash
+}
+
+// hasPersistedQueryNotFound 方法用于判断服务器是否因为没有找到持久化查询而拒绝请求。
+func (errs GraphQLErrors) hasPersistedQueryNotFound() bool {
+	for _, err := range errs {
+		if err.Message == persistedQueryNotFound || err.Extensions["code"] == "PERSISTED_QUERY_NOT_FOUND" {
+			return true
+		}
+	}
+	return false
+}
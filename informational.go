@@ -0,0 +1,48 @@
+package builder
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+)
+
+// InformationalFunc 类型用于处理服务器在最终响应之前返回的 1xx 信息响应, 例如 103 Early Hints。
+// code 为状态码, header 为信息响应携带的头部, 103 响应中的预加载提示位于 Link 头部。
+type InformationalFunc func(code int, header http.Header)
+
+// OnInformational 方法用于添加处理 1xx 信息响应的回调函数。它接收一个 InformationalFunc 类型的参数，
+// 该回调对 Client 发送的所有请求生效, 可用于根据 CDN 返回的 103 Early Hints 提前加载资源。
+func (client *Client) OnInformational(fn InformationalFunc) *Client {
+	client.informationalFuncs = append(client.informationalFuncs, fn)
+	return client
+}
+
+// OnInformational 方法用于为当前请求添加处理 1xx 信息响应的回调函数, 在 Client 的回调函数之后调用。
+func (request *Request) OnInformational(fn InformationalFunc) *Request {
+	request.informationalFuncs = append(request.informationalFuncs, fn)
+	return request
+}
+
+// traceInformational 方法用于在设置了回调函数时, 为 http.Request 添加接收 1xx 信息响应的 httptrace.ClientTrace。
+func (request *Request) traceInformational(req *http.Request) *http.Request {
+	funcs := append(append([]InformationalFunc{}, request.client.informationalFuncs...), request.informationalFuncs...)
+	if len(funcs) == 0 {
+		return req
+	}
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			for _, fn := range funcs {
+				fn := fn
+				err := safeCall("InformationalFunc", func() error {
+					fn(code, http.Header(header))
+					return nil
+				})
+				if err != nil {
+					request.LogError(err, code, "informational.go", "Got1xxResponse")
+				}
+			}
+			return nil
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
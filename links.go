@@ -0,0 +1,76 @@
+package builder
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Links 方法用于从 HTML 响应中提取链接并转换为绝对地址。它接收一个 string 类型的参数，该参数表示 CSS 选择器,
+// 为空时使用 "a[href]"。每个匹配的元素优先读取 href 属性, 其次是 src 属性, 相对地址按照最终请求的 URL
+// (跟随重定向之后) 和文档中的 <base href> 解析。javascript:、mailto: 等非 HTTP 链接会被忽略, 结果按出现顺序去重并去除片段部分。
+func (response *Response) Links(selector string) []*url.URL {
+	if selector == "" {
+		selector = "a[href]"
+	}
+	return response.resolveLinks(selector, "href", "src")
+}
+
+// resolveLinks 方法用于提取匹配 selector 的元素中第一个存在的属性, 并按照 Links 的规则转换为绝对地址。
+func (response *Response) resolveLinks(selector string, attrs ...string) []*url.URL {
+	doc := response.Html()
+	if doc == nil {
+		return nil
+	}
+	base := response.finalURL()
+	if href, ok := doc.Find("base[href]").First().Attr("href"); ok {
+		if ref, err := url.Parse(strings.TrimSpace(href)); err == nil {
+			base = resolveURL(base, ref)
+		}
+	}
+	var links []*url.URL
+	seen := make(map[string]bool)
+	doc.Find(selector).Each(func(_ int, s *goquery.Selection) {
+		var link string
+		for _, attr := range attrs {
+			if value, ok := s.Attr(attr); ok {
+				link = strings.TrimSpace(value)
+				break
+			}
+		}
+		ref, err := url.Parse(link)
+		if err != nil || link == "" {
+			return
+		}
+		u := resolveURL(base, ref)
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return
+		}
+		u.Fragment, u.RawFragment = "", ""
+		if key := u.String(); !seen[key] {
+			seen[key] = true
+			links = append(links, u)
+		}
+	})
+	return links
+}
+
+// finalURL 方法用于获取响应对应的最终请求 URL, 跟随重定向之后的地址优先。
+func (response *Response) finalURL() *url.URL {
+	if response.ResponseRaw != nil && response.ResponseRaw.Request != nil && response.ResponseRaw.Request.URL != nil {
+		return response.ResponseRaw.Request.URL
+	}
+	if response.Request != nil {
+		return response.Request.URL
+	}
+	return nil
+}
+
+// resolveURL 方法用于按照 base 解析 ref, base 为空时直接返回 ref。
+func resolveURL(base, ref *url.URL) *url.URL {
+	if base == nil {
+		return ref
+	}
+	return base.ResolveReference(ref)
+}
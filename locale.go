@@ -0,0 +1,65 @@
+package builder
+
+import (
+	"fmt"
+	"golang.org/x/text/language"
+	"strings"
+)
+
+// acceptLanguage 方法用于根据按优先级排列的语言标签生成 Accept-Language 头部, 例如 zh-CN 生成 zh-CN,zh;q=0.9。
+// 带有地区的标签会在其后补充对应的语言, q 值按顺序从 1 递减 0.1, 最低为 0.1。
+func acceptLanguage(tags ...string) (string, error) {
+	var expanded []string
+	seen := map[string]bool{}
+	add := func(tag string) {
+		if !seen[tag] {
+			seen[tag] = true
+			expanded = append(expanded, tag)
+		}
+	}
+	for _, raw := range tags {
+		tag, err := language.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return "", err
+		}
+		add(tag.String())
+		if base, confidence := tag.Base(); confidence != language.No && base.String() != tag.String() {
+			add(base.String())
+		}
+	}
+	parts := make([]string, 0, len(expanded))
+	for i, tag := range expanded {
+		if i == 0 {
+			parts = append(parts, tag)
+			continue
+		}
+		q := 10 - i
+		if q < 1 {
+			q = 1
+		}
+		parts = append(parts, fmt.Sprintf("%s;q=0.%d", tag, q))
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// SetLocale 方法用于设置客户端所有请求的 Accept-Language 头部。它接收任意个 string 类型的参数，
+// 表示按优先级排列的语言标签, 例如 SetLocale("zh-CN", "en") 生成 zh-CN,zh;q=0.9,en;q=0.8。
+func (client *Client) SetLocale(tags ...string) *Client {
+	value, err := acceptLanguage(tags...)
+	if err != nil {
+		client.LogError(err, tags, "locale.go", "SetLocale")
+		return client
+	}
+	return client.SetHeader("Accept-Language", value)
+}
+
+// SetLanguage 方法用于设置当前请求的 Accept-Language 头部, 覆盖客户端的 SetLocale 设置。它接收任意个 string 类型的参数，
+// 表示按优先级排列的语言标签。
+func (request *Request) SetLanguage(tags ...string) *Request {
+	value, err := acceptLanguage(tags...)
+	if err != nil {
+		request.LogError(err, tags, "locale.go", "SetLanguage")
+		return request
+	}
+	return request.SetHeader("Accept-Language", value)
+}
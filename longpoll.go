@@ -0,0 +1,101 @@
+package builder
+
+import (
+	"errors"
+	"golang.org/x/net/context"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// LongPollOptions 类型用于配置长轮询。
+type LongPollOptions struct {
+	Context      context.Context        // Context 用于停止长轮询, 为空时使用 context.Background()
+	Timeout      time.Duration          // Timeout 为每次轮询请求的超时时间, 默认为 90 秒
+	TokenParam   string                 // TokenParam 为携带最后一次收到的 token 的 Query 参数名, 为空时不携带
+	InitialToken string                 // InitialToken 为第一次轮询携带的 token
+	Token        func(*Response) string // Token 用于从响应中提取新的 token, 返回空字符串时沿用之前的 token
+	MinBackoff   time.Duration          // MinBackoff 为请求失败后第一次等待的时间, 默认为 1 秒
+	MaxBackoff   time.Duration          // MaxBackoff 为请求失败后等待的最长时间, 默认为 60 秒
+}
+
+const (
+	defaultLongPollTimeout    = 90 * time.Second
+	defaultLongPollMinBackoff = time.Second
+	defaultLongPollMaxBackoff = time.Minute
+)
+
+// LongPoll 方法用于对 path 进行长轮询。它接收一个 string 类型的参数表示请求路径, 一个 LongPollOptions 类型的参数表示轮询配置,
+// 以及处理每次响应的 handler。每次轮询都是一个使用 opts.Timeout 作为超时时间的 GET 请求, 轮询超时后立即重新发起;
+// 请求失败、服务器返回 429 或 5xx 时按指数退避等待后重连, 成功后退避时间重置。
+// handler 返回 false 或者 opts.Context 结束时停止轮询。
+func (client *Client) LongPoll(path string, opts LongPollOptions, handler func(*Response) bool) error {
+	if opts.Context == nil {
+		opts.Context = context.Background()
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultLongPollTimeout
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = defaultLongPollMinBackoff
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = defaultLongPollMaxBackoff
+	}
+	token, backoff := opts.InitialToken, time.Duration(0)
+	for {
+		if err := opts.Context.Err(); err != nil {
+			return err
+		}
+		// 由 LongPoll 负责退避和重连, 单次轮询不再重试
+		request := client.R().SetContext(opts.Context).SetTimeout(opts.Timeout).SetRetryCount(1)
+		if opts.TokenParam != "" && token != "" {
+			request.SetQueryParam(opts.TokenParam, token)
+		}
+		response, err := request.Get(path)
+		if err == nil && response.GetStatusCode() != http.StatusTooManyRequests && response.GetStatusCode() < http.StatusInternalServerError {
+			backoff = 0
+			if opts.Token != nil {
+				if err = safeCall("LongPollOptions.Token", func() error {
+					if next := opts.Token(response); next != "" {
+						token = next
+					}
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+			if !callPageFunc(handler, response, &err) {
+				return err
+			}
+			continue
+		}
+		if err != nil && opts.Context.Err() != nil {
+			return opts.Context.Err()
+		}
+		if err != nil && IsTimeout(err) {
+			// 在超时时间内没有新的数据, 立即重新轮询
+			continue
+		}
+		backoff = nextBackoff(backoff, opts.MinBackoff, opts.MaxBackoff)
+		if err == nil {
+			err = errors.New(response.GetStatus())
+		}
+		client.LogInfo(err, backoff.String(), "LongPoll")
+		if err = waitInterval(opts.Context, time.Now(), backoff); err != nil {
+			return err
+		}
+	}
+}
+
+// nextBackoff 方法用于计算下一次退避的时间, 在上一次的基础上翻倍并加入最多 20% 的随机抖动。
+func nextBackoff(previous, min, max time.Duration) time.Duration {
+	next := previous * 2
+	if next < min {
+		next = min
+	}
+	if next > max {
+		next = max
+	}
+	return next + time.Duration(rand.Int63n(int64(next)/5+1))
+}
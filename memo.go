@@ -0,0 +1,105 @@
+package builder
+
+import (
+	"net/http"
+	"time"
+)
+
+// maxMemoEntries 为 SetCacheTTL 使用的内存缓存的最大条目数, 达到上限时先清除过期的条目, 仍然超出时清除最早过期的条目。
+const maxMemoEntries = 4096
+
+// SetCacheTTL 方法用于在内存中缓存 GET 请求的响应。它接收一个 time.Duration 类型的参数，该参数表示缓存的有效期。
+// 有效期内对同一 URL (包括 Query 参数) 并且携带相同 Authorization 和 Cookie 的 GET 请求直接返回缓存的响应, 不发送网络请求, 也不考虑服务器返回的缓存头部。
+// 只有状态码为 200 的响应会被缓存。同一 URL 的请求同时未命中缓存时只有一个请求会被发送, 其他请求等待并使用它的结果。
+// 缓存最多保存 4096 个条目。
+func (request *Request) SetCacheTTL(ttl time.Duration) *Request {
+	request.cacheTTL = ttl
+	return request
+}
+
+// doWithMemo 方法用于在 SetCacheTTL 设置的内存缓存的参与下执行 HTTP 请求。
+func (request *Request) doWithMemo() (*Response, error) {
+	client := request.client
+	client.Lock()
+	if client.memo == nil {
+		client.memo = newCache()
+	}
+	memo := client.memo
+	client.Unlock()
+
+	key := cacheKey(request.NewRequest)
+	entry, done, leader := memo.lookupMemo(key)
+	if !leader && entry == nil {
+		// 等待同一缓存键正在进行的请求, 它的响应被缓存后直接使用, 否则自行发送请求
+		select {
+		case <-done:
+		case <-request.ctx.Done():
+			return nil, request.newError(KindUnknown, 0, request.ctx.Err())
+		}
+		if cached, ok := memo.get(key); ok && cached.isFresh() {
+			entry = cached
+		}
+	}
+	memo.record(entry != nil)
+	if entry != nil {
+		return request.newCacheResponse(entry), nil
+	}
+	if leader {
+		defer memo.finishMemo(key, done)
+	}
+	response, err := request.newDoRequest()
+	if err != nil {
+		return nil, err
+	}
+	if response.GetStatusCode() != http.StatusOK {
+		return response, nil
+	}
+	body, err := response.readBody()
+	if err != nil {
+		return nil, err
+	}
+	entry = newCacheEntry(request.NewRequest, response.ResponseRaw, body)
+	entry.expiresAt = entry.storedAt.Add(request.cacheTTL)
+	memo.setMemo(key, entry)
+	return response, nil
+}
+
+// lookupMemo 方法用于查找未过期的缓存条目。未命中且没有正在进行的请求时把当前请求登记为 leader,
+// 返回的 channel 在 leader 完成时关闭; 已有正在进行的请求时返回该请求的 channel。
+func (cache *Cache) lookupMemo(key string) (entry *cacheEntry, done chan struct{}, leader bool) {
+	cache.Lock()
+	defer cache.Unlock()
+	if entry, ok := cache.entries[key]; ok && entry.isFresh() {
+		return entry, nil, false
+	}
+	if done, ok := cache.inflight[key]; ok {
+		return nil, done, false
+	}
+	done = make(chan struct{})
+	cache.inflight[key] = done
+	return nil, done, true
+}
+
+// finishMemo 方法用于在 leader 请求完成后取消登记并唤醒等待的请求。
+func (cache *Cache) finishMemo(key string, done chan struct{}) {
+	cache.Lock()
+	delete(cache.inflight, key)
+	cache.Unlock()
+	close(done)
+}
+
+// setMemo 方法用于保存缓存条目, 条目数达到 maxMemoEntries 时先清除过期的条目, 仍然超出时清除最早过期的条目, 直到低于上限的 90%。
+func (cache *Cache) setMemo(key string, entry *cacheEntry) {
+	cache.Lock()
+	defer cache.Unlock()
+	if _, ok := cache.entries[key]; !ok && len(cache.entries) >= maxMemoEntries {
+		now := time.Now()
+		for k, e := range cache.entries {
+			if !now.Before(e.expiresAt) {
+				delete(cache.entries, k)
+			}
+		}
+		cache.evictLocked(maxMemoEntries*9/10, func(a, b *cacheEntry) bool { return a.expiresAt.Before(b.expiresAt) })
+	}
+	cache.entries[key] = entry
+}
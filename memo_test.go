@@ -0,0 +1,95 @@
+package builder
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheTTLCoalescesMisses(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte("catalog"))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := client.R().SetCacheTTL(time.Minute).Get(server.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if response.String() != "catalog" {
+				t.Errorf("response = %q", response.String())
+			}
+		}()
+	}
+	wg.Wait()
+	if n := hits.Load(); n != 1 {
+		t.Errorf("server received %d requests, want 1", n)
+	}
+	if memo := client.MemoCache(); memo.Hits() != 7 || memo.Misses() != 1 {
+		t.Errorf("hits/misses = %d/%d, want 7/1", memo.Hits(), memo.Misses())
+	}
+}
+
+func TestMemoCacheIsBounded(t *testing.T) {
+	memo := newCache()
+	now := time.Now()
+	for i := 0; i < maxMemoEntries+100; i++ {
+		entry := &cacheEntry{storedAt: now, expiresAt: now.Add(time.Duration(i+1) * time.Second)}
+		if i < 100 {
+			entry.expiresAt = now.Add(-time.Second)
+		}
+		memo.setMemo(fmt.Sprintf("GET /%d", i), entry)
+	}
+	if n := memo.Len(); n > maxMemoEntries {
+		t.Fatalf("memo cache has %d entries, limit %d", n, maxMemoEntries)
+	}
+	if _, ok := memo.get(fmt.Sprintf("GET /%d", maxMemoEntries+99)); !ok {
+		t.Error("latest entry was evicted")
+	}
+}
+
+// TestCacheTTLSeparatesAccounts 确认 SetCacheTTL 不会把一个账号的响应返回给携带其他 Authorization 或 Cookie 的请求。
+func TestCacheTTLSeparatesAccounts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("Cookie")))
+	}))
+	defer server.Close()
+	client := NewClient().SetAuthorizationKey("alice")
+
+	tests := []struct {
+		request *Request
+		want    string
+	}{
+		{client.R(), "alice|"},
+		{client.R().SetAuthToken("bob"), "bob|"},
+		{client.R().NoAuth(), "|"},
+		{client.R().SetCookie(&http.Cookie{Name: "uid", Value: "2"}), "alice|uid=2"},
+		{client.R(), "alice|"},
+	}
+	for i, tt := range tests {
+		response, err := tt.request.SetCacheTTL(time.Minute).Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := response.String(); got != tt.want {
+			t.Errorf("request %d: body = %q, want %q", i, got, tt.want)
+		}
+	}
+	if hits := client.MemoCache().Hits(); hits != 1 {
+		t.Errorf("memo hits = %d, want 1", hits)
+	}
+}
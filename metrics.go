@@ -0,0 +1,114 @@
+package builder
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RequestMetrics 类型用于存储一次发送到网络的请求尝试的指标。
+type RequestMetrics struct {
+	Method        string
+	Host          string
+	StatusCode    int           // StatusCode 为响应的状态码, 请求失败时为 0
+	Duration      time.Duration // Duration 为从发送请求到读取完响应体的耗时
+	BytesSent     int64         // BytesSent 为请求体的字节数
+	BytesReceived int64         // BytesReceived 为读取的响应体字节数
+	Attempt       int           // Attempt 为当前是第几次尝试, 从 1 开始
+	Retry         bool          // Retry 表示当前尝试是否为重试
+	Err           error         // Err 为请求失败或者读取响应体失败时的错误
+}
+
+// MetricsHook 接口用于接收请求指标, 可以对接 statsd、OpenMetrics 或者自定义的监控系统。
+type MetricsHook interface {
+	ObserveRequest(RequestMetrics)
+}
+
+// SetMetricsHook 方法用于设置接收请求指标的 MetricsHook。它接收一个 MetricsHook 类型的参数，
+// 每次发送到网络的请求尝试 (包括重试) 在读取完响应体或者请求失败后都会调用一次 ObserveRequest。
+func (client *Client) SetMetricsHook(hook MetricsHook) *Client {
+	client.metricsHook = hook
+	return client
+}
+
+// SetMetricsHook 方法用于设置只接收当前请求指标的 MetricsHook。它接收一个 MetricsHook 类型的参数，
+// 当前请求的每次尝试在调用客户端的 MetricsHook 之后都会调用一次 ObserveRequest, 适合单独统计一部分请求而不影响客户端的设置。
+func (request *Request) SetMetricsHook(hook MetricsHook) *Request {
+	request.metricsHook = hook
+	return request
+}
+
+// observeAttempt 方法用于记录一次请求尝试的指标, 收到响应时在响应体读取完毕或关闭后才调用 MetricsHook。
+func (request *Request) observeAttempt(req *http.Request, raw *http.Response, err error, attempt int, start time.Time) {
+	hooks := make([]MetricsHook, 0, 2)
+	for _, hook := range []MetricsHook{request.client.metricsHook, request.metricsHook} {
+		if hook != nil {
+			hooks = append(hooks, hook)
+		}
+	}
+	if len(hooks) == 0 {
+		return
+	}
+	metrics := RequestMetrics{
+		Method:  req.Method,
+		Host:    req.URL.Host,
+		Attempt: attempt + 1,
+		Retry:   attempt > 0,
+	}
+	if req.ContentLength > 0 {
+		metrics.BytesSent = req.ContentLength
+	}
+	observe := func(metrics RequestMetrics) {
+		metrics.Duration = time.Since(start)
+		for _, hook := range hooks {
+			if err := safeCall("MetricsHook", func() error {
+				hook.ObserveRequest(metrics)
+				return nil
+			}); err != nil {
+				request.LogError(err, req.URL.String(), "metrics.go", "ObserveRequest")
+			}
+		}
+	}
+	if err != nil || raw == nil || raw.Body == nil {
+		metrics.Err = err
+		observe(metrics)
+		return
+	}
+	metrics.StatusCode = raw.StatusCode
+	raw.Body = &metricsBody{ReadCloser: raw.Body, metrics: metrics, observe: observe}
+}
+
+// metricsBody 类型用于统计读取的响应体字节数, 并在读取完毕或者关闭时调用一次 MetricsHook。
+type metricsBody struct {
+	io.ReadCloser
+	once    sync.Once
+	metrics RequestMetrics
+	observe func(RequestMetrics)
+}
+
+// Read 方法用于读取响应体并累计字节数。
+func (body *metricsBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	body.metrics.BytesReceived += int64(n)
+	if err == io.EOF {
+		body.done(nil)
+	} else if err != nil {
+		body.done(err)
+	}
+	return n, err
+}
+
+// Close 方法用于关闭响应体, 未读取完毕就关闭时同样会记录指标。
+func (body *metricsBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.done(nil)
+	return err
+}
+
+func (body *metricsBody) done(err error) {
+	body.once.Do(func() {
+		body.metrics.Err = err
+		body.observe(body.metrics)
+	})
+}
@@ -0,0 +1,31 @@
+package builder
+
+// ErrorFunc 类型用于处理请求最终失败 (包括所有重试都失败) 时的错误, request 为失败的请求。
+type ErrorFunc func(request *Request, err error)
+
+// OnError 方法用于添加请求最终失败时调用的回调函数。它接收一个 ErrorFunc 类型的参数，该回调对 Client 发送的所有请求生效,
+// 在重试全部失败、解码或校验失败等情况下只调用一次, 可用于集中处理告警、代理黑名单或者令牌失效。
+// 重试次数用完时最后一次响应仍然满足重试条件 (例如 503 或 429) 也视为失败: 请求照常返回该响应,
+// 回调函数收到 Kind 为 KindHTTP、StatusCode 为该状态码的 *Error。
+func (client *Client) OnError(fn ErrorFunc) *Client {
+	client.Lock()
+	defer client.Unlock()
+	client.errorFuncs = append(append([]ErrorFunc{}, client.errorFuncs...), fn)
+	return client
+}
+
+// callErrorFuncs 方法用于依次调用 OnError 添加的回调函数, 回调函数发生 panic 时只记录日志。
+func (request *Request) callErrorFuncs(err error) {
+	request.client.RLock()
+	funcs := request.client.errorFuncs
+	request.client.RUnlock()
+	for _, fn := range funcs {
+		fn := fn
+		if e := safeCall("ErrorFunc", func() error {
+			fn(request, err)
+			return nil
+		}); e != nil {
+			request.LogError(e, request.path, "onerror.go", "callErrorFuncs")
+		}
+	}
+}
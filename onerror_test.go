@@ -0,0 +1,60 @@
+package builder
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOnErrorAfterRetryableStatus(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var got []error
+	client := NewClient().SetRetryCount(2).SetRetryPolicy(func(raw *http.Response, err error) bool {
+		return err != nil || raw.StatusCode == http.StatusServiceUnavailable
+	}).OnError(func(_ *Request, err error) {
+		got = append(got, err)
+	})
+	response, err := client.R().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.GetStatusCode() != http.StatusServiceUnavailable || attempts != 2 {
+		t.Fatalf("status %d after %d attempts", response.GetStatusCode(), attempts)
+	}
+	if len(got) != 1 {
+		t.Fatalf("OnError called %d times, want 1", len(got))
+	}
+	var e *Error
+	if !errors.As(got[0], &e) || e.Kind != KindHTTP || e.StatusCode != http.StatusServiceUnavailable || e.Attempts != 2 {
+		t.Errorf("OnError got %v", got[0])
+	}
+	if !errors.Is(got[0], ErrHTTP) {
+		t.Error("errors.Is(err, ErrHTTP) = false")
+	}
+}
+
+func TestOnErrorNotCalledOnSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	called := false
+	client := NewClient().OnError(func(*Request, error) { called = true })
+	if _, err := client.R().Get(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if called {
+		t.Error("OnError called for a non-retryable status")
+	}
+	if _, err := client.R().Get("http://127.0.0.1:1/"); err == nil || !called {
+		t.Errorf("OnError not called for a connection error (err %v)", err)
+	}
+}
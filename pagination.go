@@ -0,0 +1,277 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/context"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseLinkHeader 方法用于解析 RFC 5988 格式的 Link 头部, 返回 rel 到 URL 的映射。
+func parseLinkHeader(values []string) map[string]string {
+	links := map[string]string{}
+	for _, value := range values {
+		for _, link := range splitLinks(value) {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			target = target[1 : len(target)-1]
+			for _, param := range parts[1:] {
+				key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				// rel 可以包含多个以空格分隔的关系类型
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)) {
+					rel = strings.ToLower(rel)
+					if _, ok = links[rel]; !ok {
+						links[rel] = target
+					}
+				}
+			}
+		}
+	}
+	return links
+}
+
+// splitLinks 方法用于按逗号拆分 Link 头部, 忽略尖括号和引号内的逗号。
+func splitLinks(value string) []string {
+	var links []string
+	var inURL, inQuote bool
+	start := 0
+	for i, c := range value {
+		switch {
+		case c == '<' && !inQuote:
+			inURL = true
+		case c == '>' && !inQuote:
+			inURL = false
+		case c == '"' && !inURL:
+			inQuote = !inQuote
+		case c == ',' && !inURL && !inQuote:
+			links = append(links, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(links, value[start:])
+}
+
+// NextURL 方法用于获取 Link 头部中 rel="next" 指向的下一页 URL, 相对 URL 会基于本次请求的 URL 解析。
+func (response *Response) NextURL() (*url.URL, bool) {
+	next, ok := parseLinkHeader(response.GetHeader().Values("Link"))["next"]
+	if !ok {
+		return nil, false
+	}
+	u, err := url.Parse(next)
+	if err != nil {
+		response.RequestSource.LogError(err, next, "pagination.go", "NextURL")
+		return nil, false
+	}
+	if response.Request != nil {
+		u = response.Request.URL.ResolveReference(u)
+	}
+	return u, true
+}
+
+// Next 方法用于请求 Link 头部中 rel="next" 指向的下一页。
+// 下一页请求沿用原请求的 Header 和 Cookies, Query 参数由链接本身提供; 没有下一页时返回 nil, nil。
+func (response *Response) Next() (*Response, error) {
+	next, ok := response.NextURL()
+	if !ok {
+		return nil, nil
+	}
+	request := response.RequestSource.Clone()
+	request.QueryParam.Range(func(key, _ any) bool {
+		request.QueryParam.Delete(key)
+		return true
+	})
+	request.Body = nil
+	return request.Get(next.String())
+}
+
+// Paginate 方法用于沿着 Link 头部的 rel="next" 依次请求所有分页。它接收一个通过 Prepare 准备好的请求,
+// 每获取到一页响应都会调用 fn, fn 返回 false 或者没有下一页时停止。
+func (client *Client) Paginate(request *Request, fn func(*Response) bool) error {
+	response, err := request.Send()
+	for err == nil && response != nil {
+		if !callPageFunc(fn, response, &err) {
+			return err
+		}
+		response, err = response.Next()
+	}
+	return err
+}
+
+// PageOptions 类型用于配置基于游标或页码的分页。
+type PageOptions struct {
+	Param    string                 // Param 为携带游标或页码的 Query 参数名
+	Start    string                 // Start 为第一页的游标或页码, 为空时第一页沿用请求中已设置的参数
+	Next     func(*Response) string // Next 用于从响应中提取下一页的游标或页码, 返回空字符串表示没有下一页
+	Interval time.Duration          // Interval 为相邻两页请求之间的最小间隔, 用于限制请求速率
+	MaxPages int                    // MaxPages 为最多请求的页数, 0 表示不限制
+}
+
+// PaginateBy 方法用于按游标或页码依次请求所有分页。它接收一个通过 Prepare 准备好的请求和分页配置,
+// 每一页都基于该请求复制出新的请求发送, 因此客户端的重试配置对每一页单独生效;
+// 每获取到一页响应都会调用 fn, fn 返回 false、Next 返回空字符串或达到 MaxPages 时停止。
+func (client *Client) PaginateBy(request *Request, opts PageOptions, fn func(*Response) bool) error {
+	if opts.Param == "" || opts.Next == nil {
+		return fmt.Errorf("pagination Error: Param and Next are required")
+	}
+	cursor := opts.Start
+	var last time.Time
+	for pages := 0; opts.MaxPages <= 0 || pages < opts.MaxPages; pages++ {
+		if err := waitInterval(request.ctx, last, opts.Interval); err != nil {
+			return err
+		}
+		last = time.Now()
+		page := request.Clone()
+		if cursor != "" {
+			page.SetQueryParam(opts.Param, cursor)
+		}
+		response, err := page.Send()
+		if err != nil {
+			return err
+		}
+		if !callPageFunc(fn, response, &err) {
+			return err
+		}
+		if err = safeCall("PageOptions.Next", func() error {
+			cursor = opts.Next(response)
+			return nil
+		}); err != nil || cursor == "" {
+			return err
+		}
+	}
+	return nil
+}
+
+// callPageFunc 方法用于调用分页回调函数, 回调发生 panic 时把错误写入 err 并停止分页。
+func callPageFunc(fn func(*Response) bool, response *Response, err *error) bool {
+	next := false
+	*err = safeCall("Paginate", func() error {
+		next = fn(response)
+		return nil
+	})
+	return next && *err == nil
+}
+
+// waitInterval 方法用于等待到距离 last 至少 interval 的时间, ctx 结束时提前返回 ctx 的错误。
+func waitInterval(ctx context.Context, last time.Time, interval time.Duration) error {
+	if last.IsZero() || interval <= 0 {
+		return nil
+	}
+	wait := time.Until(last.Add(interval))
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DefaultFetchAllMaxPages 为 FetchAll 在 PageOptions.MaxPages 为 0 时最多请求的页数, 防止分页接口异常时无限请求。
+const DefaultFetchAllMaxPages = 1000
+
+// ErrPageLimit 表示 FetchAll 达到了 DefaultFetchAllMaxPages 但仍有下一页。
+var ErrPageLimit = errors.New("pagination Error: page limit reached")
+
+// FetchAll 方法用于请求所有分页, 并把每一页 JSON 中 gjson 路径 itemsPath 选中的数组追加到 out 中。
+// 它接收一个通过 Prepare 准备好的请求、列表数据的 gjson 路径、分页配置和一个指向切片的指针, 列表数据使用请求的 JSON 解码函数解码。
+// opts.Param 和 opts.Next 为空时沿着 Link 头部的 rel="next" 翻页, 否则与 PaginateBy 相同。opts.MaxPages 为 0 时
+// 最多请求 DefaultFetchAllMaxPages 页, 达到上限且仍有下一页时返回 ErrPageLimit, 已获取的数据保留在 out 中。
+func (client *Client) FetchAll(request *Request, itemsPath string, opts PageOptions, out any) error {
+	slice := reflect.ValueOf(out)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("pagination Error: out must be a pointer to a slice, got %T", out)
+	}
+	limit := opts.MaxPages
+	if limit <= 0 {
+		limit = DefaultFetchAllMaxPages
+	}
+	pages, more := 0, false
+	var decodeErr error
+	collect := func(response *Response) bool {
+		pages++
+		if items := response.GjsonGet(itemsPath); items.Exists() {
+			batch := reflect.New(slice.Elem().Type())
+			decodeErr = safeCall("JSONUnmarshal", func() error {
+				return request.jsonUnmarshaler()([]byte(items.Raw), batch.Interface())
+			})
+			if decodeErr != nil {
+				decodeErr = fmt.Errorf("pagination Error: page %d: %w", pages, decodeErr)
+				return false
+			}
+			slice.Elem().Set(reflect.AppendSlice(slice.Elem(), batch.Elem()))
+		}
+		return true
+	}
+	var err error
+	if opts.Param == "" && opts.Next == nil {
+		err = client.Paginate(request, func(response *Response) bool {
+			if !collect(response) {
+				return false
+			}
+			if pages >= limit {
+				_, more = response.NextURL()
+				return false
+			}
+			return true
+		})
+	} else {
+		pageOpts := opts
+		pageOpts.MaxPages = limit
+		if next := opts.Next; next != nil {
+			pageOpts.Next = func(response *Response) string {
+				cursor := next(response)
+				more = cursor != ""
+				return cursor
+			}
+		}
+		err = client.PaginateBy(request, pageOpts, collect)
+	}
+	if err != nil {
+		return err
+	}
+	if decodeErr != nil {
+		return decodeErr
+	}
+	if opts.MaxPages <= 0 && pages >= limit && more {
+		return ErrPageLimit
+	}
+	return nil
+}
+
+// CursorFromPath 方法用于创建一个从响应 JSON 的 gjson 路径中提取下一页游标的 PageOptions.Next 函数。
+func CursorFromPath(path string) func(*Response) string {
+	return func(response *Response) string {
+		return response.GjsonGet(path).String()
+	}
+}
+
+// NextPageNumber 方法用于创建一个递增页码的 PageOptions.Next 函数。它接收两个 string 类型的参数，
+// 分别表示页码参数名和响应 JSON 中列表数据的 gjson 路径, 当列表为空时表示没有下一页。
+func NextPageNumber(param, itemsPath string) func(*Response) string {
+	return func(response *Response) string {
+		if len(response.GjsonGet(itemsPath).Array()) == 0 {
+			return ""
+		}
+		current := 1
+		if value, ok := response.RequestSource.QueryParam.Load(param); ok {
+			if n, err := strconv.Atoi(fmt.Sprintf("%v", value)); err == nil {
+				current = n
+			}
+		}
+		return strconv.Itoa(current + 1)
+	}
+}
@@ -0,0 +1,159 @@
+package assert
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// TestingT is the subset of *testing.T used by the assertions.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Subject is the HTTP response under test, satisfied by *builder.Response.
+type Subject interface {
+	GetStatusCode() int
+	GetHeader() http.Header
+	String() string
+}
+
+// Expectation chains assertions on a single response. Failed assertions are
+// reported through TestingT.Errorf, so every check in a chain is evaluated.
+type Expectation struct {
+	t       TestingT
+	subject Subject
+}
+
+// That starts an assertion chain on subject.
+func That(t TestingT, subject Subject) *Expectation {
+	return &Expectation{t: t, subject: subject}
+}
+
+// Status asserts the response status code.
+func (e *Expectation) Status(code int) *Expectation {
+	e.t.Helper()
+	if actual := e.subject.GetStatusCode(); actual != code {
+		e.t.Errorf("expected status %d, got %d", code, actual)
+	}
+	return e
+}
+
+// StatusIn asserts the response status code is one of codes.
+func (e *Expectation) StatusIn(codes ...int) *Expectation {
+	e.t.Helper()
+	actual := e.subject.GetStatusCode()
+	for _, code := range codes {
+		if actual == code {
+			return e
+		}
+	}
+	e.t.Errorf("expected status in %v, got %d", codes, actual)
+	return e
+}
+
+// Header asserts the first value of the response header key equals value.
+func (e *Expectation) Header(key, value string) *Expectation {
+	e.t.Helper()
+	if actual := e.subject.GetHeader().Get(key); actual != value {
+		e.t.Errorf("expected header %s to be %q, got %q", key, value, actual)
+	}
+	return e
+}
+
+// HeaderContains asserts some value of the response header key contains substr.
+func (e *Expectation) HeaderContains(key, substr string) *Expectation {
+	e.t.Helper()
+	values := e.subject.GetHeader().Values(key)
+	for _, value := range values {
+		if strings.Contains(value, substr) {
+			return e
+		}
+	}
+	e.t.Errorf("expected header %s to contain %q, got %q", key, substr, values)
+	return e
+}
+
+// HeaderExists asserts the response carries the header key.
+func (e *Expectation) HeaderExists(key string) *Expectation {
+	e.t.Helper()
+	if len(e.subject.GetHeader().Values(key)) == 0 {
+		e.t.Errorf("expected header %s to be present", key)
+	}
+	return e
+}
+
+// Body asserts the response body equals expected.
+func (e *Expectation) Body(expected string) *Expectation {
+	e.t.Helper()
+	if actual := e.subject.String(); actual != expected {
+		e.t.Errorf("expected body %q, got %q", expected, actual)
+	}
+	return e
+}
+
+// BodyContains asserts the response body contains substr.
+func (e *Expectation) BodyContains(substr string) *Expectation {
+	e.t.Helper()
+	if !strings.Contains(e.subject.String(), substr) {
+		e.t.Errorf("expected body to contain %q", substr)
+	}
+	return e
+}
+
+// JsonPathExists asserts the gjson path exists in the response body.
+func (e *Expectation) JsonPathExists(path string) *Expectation {
+	e.t.Helper()
+	if !gjson.Get(e.subject.String(), path).Exists() {
+		e.t.Errorf("expected JSON path %s to exist", path)
+	}
+	return e
+}
+
+// JsonPath asserts the value at the gjson path equals expected. expected is
+// compared after a JSON round trip, so 42 matches both 42 and 42.0 and
+// structs match their JSON representation.
+func (e *Expectation) JsonPath(path string, expected any) *Expectation {
+	e.t.Helper()
+	result := gjson.Get(e.subject.String(), path)
+	if !result.Exists() {
+		e.t.Errorf("expected JSON path %s to be %v, but it does not exist", path, expected)
+		return e
+	}
+	want, err := roundTrip(expected)
+	if err != nil {
+		e.t.Errorf("can not compare JSON path %s: %v", path, err)
+		return e
+	}
+	var got any
+	if err = json.Unmarshal([]byte(result.Raw), &got); err != nil {
+		e.t.Errorf("can not compare JSON path %s: %v", path, err)
+		return e
+	}
+	if !reflect.DeepEqual(want, got) {
+		e.t.Errorf("expected JSON path %s to be %s, got %s", path, mustMarshal(expected), result.Raw)
+	}
+	return e
+}
+
+func roundTrip(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
+
+func mustMarshal(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "<unmarshalable>"
+	}
+	return string(b)
+}
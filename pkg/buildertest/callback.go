@@ -0,0 +1,244 @@
+// Package buildertest provides helpers for end-to-end tests of code built on
+// builder. It lives outside the builder package so that programs using
+// builder do not link net/http/httptest or the assertion helpers.
+package buildertest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/catnovelapi/builder"
+	"github.com/catnovelapi/builder/pkg/assert"
+	"github.com/tidwall/gjson"
+)
+
+const formContentType = "application/x-www-form-urlencoded"
+
+// TestingT is the subset of *testing.T used by NewCallbackServer.
+type TestingT interface {
+	assert.TestingT
+	Fatalf(format string, args ...any)
+	Cleanup(func())
+}
+
+// CapturedRequest is one request received by a CallbackServer.
+type CapturedRequest struct {
+	Method     string
+	URL        *url.URL
+	Header     http.Header
+	Body       []byte
+	Form       url.Values // query parameters merged with a form-encoded body
+	ReceivedAt time.Time
+
+	t assert.TestingT
+}
+
+// CallbackServer is a server for end-to-end tests that records every request
+// it receives, e.g. to serve as an OAuth redirect_uri or a webhook target.
+type CallbackServer struct {
+	server *httptest.Server
+	t      TestingT
+
+	mu       sync.Mutex
+	requests []*CapturedRequest
+	waited   int
+	arrived  chan struct{}
+	status   int
+	header   http.Header
+	body     string
+	handler  http.HandlerFunc
+}
+
+// NewCallbackServer starts an httptest server that records requests. It is
+// closed through t.Cleanup when the test ends. By default every request gets
+// a 200 with an empty body; use Respond or Handle to change that.
+func NewCallbackServer(t TestingT) *CallbackServer {
+	t.Helper()
+	server := &CallbackServer{t: t, status: http.StatusOK, header: http.Header{}, arrived: make(chan struct{}, 1)}
+	server.server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	t.Cleanup(server.server.Close)
+	return server
+}
+
+// serveHTTP records the request and writes the configured response.
+func (server *CallbackServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	captured := &CapturedRequest{
+		Method:     r.Method,
+		URL:        r.URL,
+		Header:     r.Header.Clone(),
+		Body:       body,
+		Form:       r.URL.Query(),
+		ReceivedAt: time.Now(),
+		t:          server.t,
+	}
+	if mediaType := strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0]); mediaType == formContentType {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			for key, values := range form {
+				captured.Form[key] = append(captured.Form[key], values...)
+			}
+		}
+	}
+	server.mu.Lock()
+	server.requests = append(server.requests, captured)
+	status, header, responseBody, handler := server.status, server.header, server.body, server.handler
+	server.mu.Unlock()
+	select {
+	case server.arrived <- struct{}{}:
+	default:
+	}
+	if handler != nil {
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+		handler(w, r)
+		return
+	}
+	for key, values := range header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, responseBody)
+}
+
+// URL returns the full address of path on the server, e.g. for
+// "/oauth/callback".
+func (server *CallbackServer) URL(path string) string {
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return server.server.URL + path
+}
+
+// Client returns a builder.Client whose BaseURL points at the server.
+func (server *CallbackServer) Client() *builder.Client {
+	return builder.NewClient().SetBaseURL(server.server.URL)
+}
+
+// Respond sets the status code and body returned for every request.
+func (server *CallbackServer) Respond(status int, body string) *CallbackServer {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.status, server.body = status, body
+	return server
+}
+
+// RespondHeader sets a header on the responses.
+func (server *CallbackServer) RespondHeader(key, value string) *CallbackServer {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	header := server.header.Clone()
+	header.Set(key, value)
+	server.header = header
+	return server
+}
+
+// Handle generates responses with handler instead. Requests are still
+// recorded.
+func (server *CallbackServer) Handle(handler http.HandlerFunc) *CallbackServer {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.handler = handler
+	return server
+}
+
+// Requests returns every request received so far.
+func (server *CallbackServer) Requests() []*CapturedRequest {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]*CapturedRequest(nil), server.requests...)
+}
+
+// Wait returns the next request not yet returned by Wait, waiting at most
+// timeout for it to arrive and failing the test through t.Fatalf otherwise.
+// It suits webhooks that arrive asynchronously or OAuth redirects completed
+// by a browser.
+func (server *CallbackServer) Wait(timeout time.Duration) *CapturedRequest {
+	server.t.Helper()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		server.mu.Lock()
+		if server.waited < len(server.requests) {
+			captured := server.requests[server.waited]
+			server.waited++
+			server.mu.Unlock()
+			return captured
+		}
+		server.mu.Unlock()
+		select {
+		case <-server.arrived:
+		case <-timer.C:
+			server.t.Fatalf("callback server: no request received within %v", timeout)
+			return nil
+		}
+	}
+}
+
+// AssertCount checks the number of requests received.
+func (server *CallbackServer) AssertCount(n int) *CallbackServer {
+	server.t.Helper()
+	if count := len(server.Requests()); count != n {
+		server.t.Errorf("callback server: expected %d requests, got %d", n, count)
+	}
+	return server
+}
+
+// AssertReceived checks that a request with method and path was received and
+// returns the first match, or reports an error and returns nil.
+func (server *CallbackServer) AssertReceived(method, path string) *CapturedRequest {
+	server.t.Helper()
+	for _, captured := range server.Requests() {
+		if captured.Method == method && captured.URL.Path == path {
+			return captured
+		}
+	}
+	server.t.Errorf("callback server: no %s %s request received", method, path)
+	return nil
+}
+
+// AssertQuery checks the first value of a query or form parameter.
+func (captured *CapturedRequest) AssertQuery(key, value string) *CapturedRequest {
+	captured.t.Helper()
+	if actual, ok := captured.Form[key]; !ok {
+		captured.t.Errorf("%s %s: parameter %q is missing", captured.Method, captured.URL.Path, key)
+	} else if actual[0] != value {
+		captured.t.Errorf("%s %s: expected parameter %q to be %q, got %q", captured.Method, captured.URL.Path, key, value, actual[0])
+	}
+	return captured
+}
+
+// AssertHeader checks the first value of a request header.
+func (captured *CapturedRequest) AssertHeader(key, value string) *CapturedRequest {
+	captured.t.Helper()
+	if actual := captured.Header.Get(key); actual != value {
+		captured.t.Errorf("%s %s: expected header %s to be %q, got %q", captured.Method, captured.URL.Path, key, value, actual)
+	}
+	return captured
+}
+
+// AssertBodyContains checks that the request body contains substr.
+func (captured *CapturedRequest) AssertBodyContains(substr string) *CapturedRequest {
+	captured.t.Helper()
+	if !strings.Contains(string(captured.Body), substr) {
+		captured.t.Errorf("%s %s: expected body to contain %q, got %q", captured.Method, captured.URL.Path, substr, captured.Body)
+	}
+	return captured
+}
+
+// AssertJsonPath checks the value at a gjson path of a JSON request body.
+// Both values are formatted with fmt.Sprint before comparing.
+func (captured *CapturedRequest) AssertJsonPath(path string, expected any) *CapturedRequest {
+	captured.t.Helper()
+	result := gjson.GetBytes(captured.Body, path)
+	if !result.Exists() {
+		captured.t.Errorf("%s %s: JSON path %q is missing", captured.Method, captured.URL.Path, path)
+	} else if actual, want := result.String(), fmt.Sprint(expected); actual != want {
+		captured.t.Errorf("%s %s: expected JSON path %q to be %s, got %s", captured.Method, captured.URL.Path, path, want, actual)
+	}
+	return captured
+}
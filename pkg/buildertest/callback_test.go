@@ -0,0 +1,29 @@
+package buildertest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCallbackServerRecordsRequests(t *testing.T) {
+	server := NewCallbackServer(t).Respond(http.StatusCreated, `{"ok":true}`).RespondHeader("Content-Type", "application/json")
+
+	response, err := server.Client().R().
+		SetHeader("X-Signature", "abc").
+		SetBodyJson(map[string]any{"event": "paid", "amount": 42}).
+		Post("/webhook?state=xyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.GetStatusCode() != http.StatusCreated || !response.GjsonGet("ok").Bool() {
+		t.Errorf("response = %d %s, want 201 {\"ok\":true}", response.GetStatusCode(), response.String())
+	}
+
+	server.Wait(time.Second).
+		AssertQuery("state", "xyz").
+		AssertHeader("X-Signature", "abc").
+		AssertJsonPath("event", "paid").
+		AssertJsonPath("amount", 42)
+	server.AssertCount(1).AssertReceived(http.MethodPost, "/webhook")
+}
@@ -0,0 +1,268 @@
+// Package crawler is a small crawling framework on top of builder.Client:
+// a deduplicating URL frontier, depth and page limits, per-host politeness
+// and pluggable handlers that receive each fetched *builder.Response.
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/catnovelapi/builder"
+)
+
+// Page is a fetched page passed to handlers.
+type Page struct {
+	URL      *url.URL
+	Depth    int // 0 for seeds
+	Response *builder.Response
+
+	crawler *Crawler
+}
+
+// Enqueue adds links to the frontier one level deeper than the page.
+// Links that were already seen or are rejected by the options are ignored.
+func (page *Page) Enqueue(links ...*url.URL) {
+	for _, link := range links {
+		page.crawler.enqueue(link, page.Depth+1)
+	}
+}
+
+// Handler processes a fetched page. An error is reported through
+// Options.OnError and does not stop the crawl.
+type Handler func(page *Page) error
+
+// Options configures a Crawler.
+type Options struct {
+	// MaxDepth limits how many links away from a seed a page may be, 0 for no limit.
+	MaxDepth int
+	// MaxPages stops the crawl after this many pages were fetched, 0 for no limit.
+	MaxPages int
+	// Concurrency is the number of pages fetched at the same time, 4 when zero.
+	Concurrency int
+	// MaxPerHost is the number of concurrent requests to one host, 1 when zero.
+	MaxPerHost int
+	// HostDelay is the minimum delay between two requests to the same host.
+	// It is applied through Client.SetCrawlDelay for every host the crawler visits.
+	HostDelay time.Duration
+	// SameHost restricts the crawl to the hosts of the seeds.
+	SameHost bool
+	// Filter, when set, decides whether a discovered URL is crawled.
+	Filter func(u *url.URL) bool
+	// FollowSelector is the CSS selector of the links followed on HTML
+	// pages, "a[href]" when empty. NoFollow disables following, leaving
+	// discovery to handlers calling Page.Enqueue.
+	FollowSelector string
+	NoFollow       bool
+	// OnError receives fetch and handler errors. When nil, Run returns them
+	// joined after the crawl.
+	OnError func(u *url.URL, err error)
+}
+
+// Crawler crawls pages with a builder.Client.
+type Crawler struct {
+	client   *builder.Client
+	opts     Options
+	handlers []Handler
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    []item
+	seen     map[uint64]struct{}
+	hosts    map[string]bool // seed hosts, for SameHost
+	delayed  map[string]bool // hosts with a crawl delay installed
+	active   map[string]int  // in-flight requests per host
+	inFlight int
+	fetched  int
+	errs     []error
+	canceled bool
+}
+
+// New returns a Crawler that fetches pages with client. Configure robots.txt
+// handling, user agent and retries on the client itself.
+func New(client *builder.Client, opts Options) *Crawler {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.MaxPerHost <= 0 {
+		opts.MaxPerHost = 1
+	}
+	if opts.FollowSelector == "" {
+		opts.FollowSelector = "a[href]"
+	}
+	crawler := &Crawler{
+		client:  client,
+		opts:    opts,
+		seen:    map[uint64]struct{}{},
+		hosts:   map[string]bool{},
+		delayed: map[string]bool{},
+		active:  map[string]int{},
+	}
+	crawler.cond = sync.NewCond(&crawler.mu)
+	return crawler
+}
+
+// Handle registers handlers that are called, in order, for every fetched page.
+func (crawler *Crawler) Handle(handlers ...Handler) *Crawler {
+	crawler.handlers = append(crawler.handlers, handlers...)
+	return crawler
+}
+
+// Run crawls from the seed URLs until the frontier is exhausted, MaxPages
+// is reached or ctx is canceled. A Crawler can only be run once.
+func (crawler *Crawler) Run(ctx context.Context, seeds ...string) error {
+	for _, seed := range seeds {
+		u, err := url.Parse(seed)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("crawler: invalid seed %q", seed)
+		}
+		crawler.hosts[strings.ToLower(u.Host)] = true
+	}
+	for _, seed := range seeds {
+		u, _ := url.Parse(seed)
+		crawler.enqueue(u, 0)
+	}
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			crawler.mu.Lock()
+			crawler.canceled = true
+			crawler.mu.Unlock()
+			crawler.cond.Broadcast()
+		case <-finished:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < crawler.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				next, ok := crawler.next()
+				if !ok {
+					return
+				}
+				crawler.visit(ctx, next)
+				crawler.done(next)
+			}
+		}()
+	}
+	wg.Wait()
+	if crawler.opts.OnError == nil {
+		return errors.Join(crawler.errs...)
+	}
+	return nil
+}
+
+// enqueue adds u to the frontier unless it was seen before or is rejected.
+func (crawler *Crawler) enqueue(u *url.URL, depth int) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+	if crawler.opts.MaxDepth > 0 && depth > crawler.opts.MaxDepth {
+		return
+	}
+	crawler.mu.Lock()
+	defer crawler.mu.Unlock()
+	if crawler.opts.SameHost && depth > 0 && !crawler.hosts[strings.ToLower(u.Host)] {
+		return
+	}
+	if crawler.opts.Filter != nil && depth > 0 && !crawler.opts.Filter(u) {
+		return
+	}
+	key := hashURL(u)
+	if _, ok := crawler.seen[key]; ok {
+		return
+	}
+	crawler.seen[key] = struct{}{}
+	crawler.queue = append(crawler.queue, item{url: u, depth: depth})
+	crawler.cond.Signal()
+}
+
+// next blocks until a URL whose host has a free slot is available. It
+// returns false when the crawl is over.
+func (crawler *Crawler) next() (item, bool) {
+	crawler.mu.Lock()
+	defer crawler.mu.Unlock()
+	for {
+		if crawler.canceled || (crawler.opts.MaxPages > 0 && crawler.fetched >= crawler.opts.MaxPages) {
+			return item{}, false
+		}
+		for i, candidate := range crawler.queue {
+			host := strings.ToLower(candidate.url.Host)
+			if crawler.active[host] >= crawler.opts.MaxPerHost {
+				continue
+			}
+			crawler.queue = append(crawler.queue[:i], crawler.queue[i+1:]...)
+			crawler.active[host]++
+			crawler.inFlight++
+			crawler.fetched++
+			if crawler.opts.HostDelay > 0 && !crawler.delayed[host] {
+				crawler.delayed[host] = true
+				crawler.client.SetCrawlDelay(host, crawler.opts.HostDelay)
+			}
+			return candidate, true
+		}
+		if len(crawler.queue) == 0 && crawler.inFlight == 0 {
+			// nothing left to fetch and nothing in flight that could add more
+			crawler.cond.Broadcast()
+			return item{}, false
+		}
+		crawler.cond.Wait()
+	}
+}
+
+// done releases the host slot taken by next.
+func (crawler *Crawler) done(finished item) {
+	crawler.mu.Lock()
+	crawler.active[strings.ToLower(finished.url.Host)]--
+	crawler.inFlight--
+	crawler.mu.Unlock()
+	crawler.cond.Broadcast()
+}
+
+// visit fetches a page, runs the handlers and follows its links.
+func (crawler *Crawler) visit(ctx context.Context, target item) {
+	response, err := crawler.client.R().SetContext(ctx).Get(target.url.String())
+	if err != nil {
+		crawler.report(target.url, err)
+		return
+	}
+	// pages that are neither read by a handler nor followed only need the status and headers
+	defer response.Close()
+	page := &Page{URL: target.url, Depth: target.depth, Response: response, crawler: crawler}
+	for _, handler := range crawler.handlers {
+		if err := handler(page); err != nil {
+			crawler.report(target.url, err)
+		}
+	}
+	if crawler.opts.NoFollow || !isHTML(response) {
+		return
+	}
+	for _, link := range response.Links(crawler.opts.FollowSelector) {
+		crawler.enqueue(link, target.depth+1)
+	}
+}
+
+func (crawler *Crawler) report(u *url.URL, err error) {
+	if crawler.opts.OnError != nil {
+		crawler.opts.OnError(u, err)
+		return
+	}
+	crawler.mu.Lock()
+	crawler.errs = append(crawler.errs, fmt.Errorf("crawler: %s: %w", u, err))
+	crawler.mu.Unlock()
+}
+
+func isHTML(response *builder.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(response.GetHeader().Get("Content-Type"))
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
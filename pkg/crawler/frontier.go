@@ -0,0 +1,46 @@
+package crawler
+
+import (
+	"hash/fnv"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Normalize returns the canonical form of u used to detect duplicates: the
+// scheme and host are lower-cased, default ports and the fragment are
+// removed, an empty path becomes "/", dot segments are resolved and query
+// parameters are sorted.
+func Normalize(u *url.URL) string {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+	if port := n.Port(); (n.Scheme == "http" && port == "80") || (n.Scheme == "https" && port == "443") {
+		n.Host = n.Hostname()
+	}
+	n.Fragment, n.RawFragment = "", ""
+	n.User = nil
+	if n.Path == "" {
+		n.Path, n.RawPath = "/", ""
+	}
+	n = *n.ResolveReference(&url.URL{Path: n.Path, RawPath: n.RawPath, RawQuery: n.RawQuery})
+	if n.RawQuery != "" {
+		pairs := strings.Split(n.RawQuery, "&")
+		sort.Strings(pairs)
+		n.RawQuery = strings.Join(pairs, "&")
+	}
+	return n.String()
+}
+
+// hashURL returns the frontier key of u.
+func hashURL(u *url.URL) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(Normalize(u)))
+	return h.Sum64()
+}
+
+// item is a URL waiting in the frontier.
+type item struct {
+	url   *url.URL
+	depth int
+}
@@ -0,0 +1,204 @@
+package files
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// DefaultMaxSize is the rotation size used when RotateOptions.MaxSize is not set
+const DefaultMaxSize = 100 * 1024 * 1024
+
+// RotateOptions configures a RotateWriter
+type RotateOptions struct {
+	MaxSize    int64 // MaxSize is the size in bytes at which the file is rotated, DefaultMaxSize if <= 0
+	MaxBackups int   // MaxBackups is the number of rotated generations to keep, all are kept if <= 0
+	Compress   bool  // Compress gzips rotated generations
+}
+
+// RotateWriter is an io.WriteCloser appending to a file that is rotated once it
+// reaches MaxSize. Rotated generations are named name.1, name.2, ... (with a
+// .gz suffix when compressed), name.1 being the most recent.
+type RotateWriter struct {
+	mu   sync.Mutex
+	name string
+	opts RotateOptions
+	file *os.File
+	size int64
+}
+
+// NewRotateWriter opens (or creates) the file name for appending
+func NewRotateWriter(name string, opts RotateOptions) (*RotateWriter, error) {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMaxSize
+	}
+	w := &RotateWriter{name: name, opts: opts}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotateWriter) open() error {
+	file, err := os.OpenFile(w.name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	w.file, w.size = file, info.Size()
+	return nil
+}
+
+// Write appends p to the file, rotating first if p would push it past MaxSize.
+// A failed rotation does not stop logging: p is still written to the current
+// file and the rotation error is returned, rotation is retried on the next Write.
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	var rotateErr error
+	if w.size > 0 && w.size+int64(len(p)) > w.opts.MaxSize {
+		rotateErr = w.rotate()
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Rotate rotates the file immediately
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rotate()
+}
+
+// Close closes the current file
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// rotate moves the current file to generation 1 and opens a new file. The
+// current file stays open until the new one is open, so on any failure w.file
+// is still usable (possibly writing into the rotated generation).
+func (w *RotateWriter) rotate() error {
+	if w.opts.MaxBackups > 0 {
+		if err := removeGeneration(w.name, w.opts.MaxBackups); err != nil {
+			return err
+		}
+	}
+	// shift name.N to name.N+1, starting from the oldest generation
+	for i := w.lastGeneration(); i >= 1; i-- {
+		for _, suffix := range []string{"", ".gz"} {
+			old := generationName(w.name, i) + suffix
+			if _, err := os.Stat(old); err == nil {
+				if err = RenameFile(old, generationName(w.name, i+1)+suffix); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	rotated := generationName(w.name, 1)
+	current := w.file
+	if err := RenameFile(w.name, rotated); err != nil {
+		// some platforms (Windows) cannot rename an open file, retry once it is closed
+		_ = current.Close()
+		current = nil
+		if err = RenameFile(w.name, rotated); err != nil {
+			// the file was not moved, reopen it and keep appending
+			if openErr := w.open(); openErr != nil {
+				return fmt.Errorf("%w (reopen: %v)", err, openErr)
+			}
+			return err
+		}
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	if current != nil {
+		_ = current.Close()
+	}
+	if w.opts.Compress {
+		return compressFile(rotated)
+	}
+	return nil
+}
+
+// lastGeneration returns the highest existing generation number
+func (w *RotateWriter) lastGeneration() int {
+	n := 0
+	for {
+		next := generationName(w.name, n+1)
+		if _, err := os.Stat(next); err != nil {
+			if _, err = os.Stat(next + ".gz"); err != nil {
+				return n
+			}
+		}
+		n++
+	}
+}
+
+func generationName(name string, n int) string {
+	return fmt.Sprintf("%s.%d", name, n)
+}
+
+// removeGeneration deletes generation n and every older generation
+func removeGeneration(name string, n int) error {
+	for ; ; n++ {
+		removed := false
+		for _, suffix := range []string{"", ".gz"} {
+			err := os.Remove(generationName(name, n) + suffix)
+			if err == nil {
+				removed = true
+			} else if !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if !removed {
+			return nil
+		}
+	}
+}
+
+// compressFile gzips name into name.gz and removes name
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(name + ".gz")
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,64 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotateWriterRotates(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "debug.log")
+	w, err := NewRotateWriter(name, RotateOptions{MaxSize: 10, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if _, err = w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for file, want := range map[string]string{name: "third\n", name + ".1": "second\n", name + ".2": "first\n"} {
+		if data, _ := os.ReadFile(file); string(data) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), data, want)
+		}
+	}
+}
+
+func TestRotateWriterKeepsWritingAfterFailedRotation(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "debug.log")
+	w, err := NewRotateWriter(name, RotateOptions{MaxSize: 10, MaxBackups: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	// name.1 is a non-empty directory, so removing the old generation fails
+	if err = os.MkdirAll(filepath.Join(name+".1", "x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("first line\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("second\n")); err == nil {
+		t.Fatal("expected rotation error")
+	}
+	if _, err = w.Write([]byte("third\n")); err == nil {
+		t.Fatal("expected rotation error on retry")
+	}
+	// once the obstacle is gone rotation succeeds and writing continues
+	if err = os.RemoveAll(name + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("fourth\n")); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(name + ".1")
+	if got := string(data); !strings.HasPrefix(got, "first line\nsecond\nthird\n") {
+		t.Errorf("rotated file = %q", got)
+	}
+	if data, _ = os.ReadFile(name); string(data) != "fourth\n" {
+		t.Errorf("current file = %q, want %q", data, "fourth\n")
+	}
+}
@@ -0,0 +1,439 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Schema is a compiled JSON Schema. It supports the commonly used subset of
+// draft-07 and OpenAPI 3 schema objects: type (including OpenAPI "nullable"),
+// enum, const, properties, required, additionalProperties, items, minItems,
+// maxItems, uniqueItems, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// multipleOf, minLength, maxLength, pattern, minProperties, maxProperties,
+// allOf, anyOf, oneOf, not and local "#/..." $ref pointers.
+type Schema struct {
+	node any
+	root any
+
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+// FieldError describes a single validation failure.
+type FieldError struct {
+	Path    string // JSON pointer of the offending value, "" for the document root
+	Message string
+}
+
+func (e FieldError) String() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// ValidationError is returned when a document does not match a schema.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, fieldError := range e.Errors {
+		parts[i] = fieldError.String()
+	}
+	return "schema validation failed: " + strings.Join(parts, "; ")
+}
+
+// Compile parses a JSON Schema document.
+func Compile(data []byte) (*Schema, error) {
+	var node any
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid schema: %w", err)
+	}
+	return New(node, node), nil
+}
+
+// New wraps an already decoded schema node. root is the document that local
+// $ref pointers are resolved against, e.g. an OpenAPI document for a schema
+// taken from its components.
+func New(node, root any) *Schema {
+	return &Schema{node: node, root: root, patterns: map[string]*regexp.Regexp{}}
+}
+
+// Validate checks a JSON document against the schema.
+func (s *Schema) Validate(data []byte) error {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return &ValidationError{Errors: []FieldError{{Message: "invalid JSON: " + err.Error()}}}
+	}
+	return s.ValidateValue(value)
+}
+
+// ValidateValue checks a decoded JSON value (as produced by encoding/json)
+// against the schema.
+func (s *Schema) ValidateValue(value any) error {
+	var errs []FieldError
+	s.validate(s.node, normalize(value), "", &errs, 0)
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: errs}
+}
+
+// maxDepth guards against cyclic $ref chains.
+const maxDepth = 64
+
+func (s *Schema) validate(node any, value any, path string, errs *[]FieldError, depth int) {
+	if depth > maxDepth {
+		*errs = append(*errs, FieldError{Path: path, Message: "schema nesting too deep"})
+		return
+	}
+	switch n := node.(type) {
+	case bool:
+		if !n {
+			*errs = append(*errs, FieldError{Path: path, Message: "value is not allowed"})
+		}
+		return
+	case map[string]any:
+		s.validateObjectSchema(n, value, path, errs, depth)
+	}
+}
+
+func (s *Schema) validateObjectSchema(n map[string]any, value any, path string, errs *[]FieldError, depth int) {
+	add := func(format string, args ...any) {
+		*errs = append(*errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if ref, ok := n["$ref"].(string); ok {
+		target, err := s.resolve(ref)
+		if err != nil {
+			add("%v", err)
+			return
+		}
+		s.validate(target, value, path, errs, depth+1)
+		return
+	}
+	if value == nil {
+		if nullable, _ := n["nullable"].(bool); nullable {
+			return
+		}
+	}
+	if t, ok := n["type"]; ok && !matchesType(t, value) {
+		add("expected %s, got %s", typeString(t), typeOf(value))
+		return
+	}
+	if enum, ok := n["enum"].([]any); ok {
+		found := false
+		for _, candidate := range enum {
+			if equal(normalize(candidate), value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("value is not one of the allowed values")
+		}
+	}
+	if constant, ok := n["const"]; ok && !equal(normalize(constant), value) {
+		add("value does not match const")
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if min, ok := number(n["minLength"]); ok && float64(length) < min {
+			add("length %d is less than %v", length, min)
+		}
+		if max, ok := number(n["maxLength"]); ok && float64(length) > max {
+			add("length %d is greater than %v", length, max)
+		}
+		if pattern, ok := n["pattern"].(string); ok {
+			re, err := s.compilePattern(pattern)
+			if err != nil {
+				add("invalid pattern %q: %v", pattern, err)
+			} else if !re.MatchString(v) {
+				add("value does not match pattern %q", pattern)
+			}
+		}
+	case float64:
+		s.validateNumber(n, v, add)
+	case []any:
+		if min, ok := number(n["minItems"]); ok && float64(len(v)) < min {
+			add("array has %d items, less than %v", len(v), min)
+		}
+		if max, ok := number(n["maxItems"]); ok && float64(len(v)) > max {
+			add("array has %d items, more than %v", len(v), max)
+		}
+		if unique, _ := n["uniqueItems"].(bool); unique {
+			for i := range v {
+				for j := i + 1; j < len(v); j++ {
+					if equal(v[i], v[j]) {
+						add("items %d and %d are equal", i, j)
+					}
+				}
+			}
+		}
+		switch items := n["items"].(type) {
+		case map[string]any, bool:
+			for i, item := range v {
+				s.validate(items, item, fmt.Sprintf("%s/%d", path, i), errs, depth+1)
+			}
+		case []any:
+			for i, item := range v {
+				if i < len(items) {
+					s.validate(items[i], item, fmt.Sprintf("%s/%d", path, i), errs, depth+1)
+				}
+			}
+		}
+	case map[string]any:
+		s.validateProperties(n, v, path, errs, depth, add)
+	}
+
+	if all, ok := n["allOf"].([]any); ok {
+		for _, sub := range all {
+			s.validate(sub, value, path, errs, depth+1)
+		}
+	}
+	if anyOf, ok := n["anyOf"].([]any); ok && s.countMatches(anyOf, value, path, depth) == 0 {
+		add("value does not match any schema in anyOf")
+	}
+	if oneOf, ok := n["oneOf"].([]any); ok {
+		if matches := s.countMatches(oneOf, value, path, depth); matches != 1 {
+			add("value matches %d schemas in oneOf, expected exactly 1", matches)
+		}
+	}
+	if not, ok := n["not"]; ok && s.countMatches([]any{not}, value, path, depth) == 1 {
+		add("value must not match the schema in not")
+	}
+}
+
+func (s *Schema) validateNumber(n map[string]any, v float64, add func(string, ...any)) {
+	if min, ok := number(n["minimum"]); ok {
+		// OpenAPI 3.0 expresses exclusivity as a boolean next to minimum
+		if exclusive, _ := n["exclusiveMinimum"].(bool); exclusive && v <= min {
+			add("value %v must be greater than %v", v, min)
+		} else if v < min {
+			add("value %v is less than minimum %v", v, min)
+		}
+	}
+	if max, ok := number(n["maximum"]); ok {
+		if exclusive, _ := n["exclusiveMaximum"].(bool); exclusive && v >= max {
+			add("value %v must be less than %v", v, max)
+		} else if v > max {
+			add("value %v is greater than maximum %v", v, max)
+		}
+	}
+	if min, ok := number(n["exclusiveMinimum"]); ok && v <= min {
+		add("value %v must be greater than %v", v, min)
+	}
+	if max, ok := number(n["exclusiveMaximum"]); ok && v >= max {
+		add("value %v must be less than %v", v, max)
+	}
+	if multiple, ok := number(n["multipleOf"]); ok && multiple > 0 {
+		if q := v / multiple; math.Abs(q-math.Round(q)) > 1e-9 {
+			add("value %v is not a multiple of %v", v, multiple)
+		}
+	}
+}
+
+func (s *Schema) validateProperties(n map[string]any, v map[string]any, path string, errs *[]FieldError, depth int, add func(string, ...any)) {
+	if min, ok := number(n["minProperties"]); ok && float64(len(v)) < min {
+		add("object has %d properties, less than %v", len(v), min)
+	}
+	if max, ok := number(n["maxProperties"]); ok && float64(len(v)) > max {
+		add("object has %d properties, more than %v", len(v), max)
+	}
+	if required, ok := n["required"].([]any); ok {
+		for _, name := range required {
+			if key, ok := name.(string); ok {
+				if _, present := v[key]; !present {
+					*errs = append(*errs, FieldError{Path: path + "/" + escape(key), Message: "required property is missing"})
+				}
+			}
+		}
+	}
+	properties, _ := n["properties"].(map[string]any)
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		childPath := path + "/" + escape(key)
+		if sub, ok := properties[key]; ok {
+			s.validate(sub, v[key], childPath, errs, depth+1)
+			continue
+		}
+		switch additional := n["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*errs = append(*errs, FieldError{Path: childPath, Message: "additional property is not allowed"})
+			}
+		case map[string]any:
+			s.validate(additional, v[key], childPath, errs, depth+1)
+		}
+	}
+}
+
+func (s *Schema) countMatches(schemas []any, value any, path string, depth int) int {
+	matches := 0
+	for _, sub := range schemas {
+		var subErrs []FieldError
+		s.validate(sub, value, path, &subErrs, depth+1)
+		if len(subErrs) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+// resolve follows a local JSON pointer such as "#/components/schemas/Book".
+func (s *Schema) resolve(ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported $ref %q: only local references are supported", ref)
+	}
+	pointer, err := url.PathUnescape(strings.TrimPrefix(ref, "#"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid $ref %q", ref)
+	}
+	node := s.root
+	if pointer == "" {
+		return node, nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if node, ok = object[token]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return node, nil
+}
+
+func (s *Schema) compilePattern(pattern string) (*regexp.Regexp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if re, ok := s.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	s.patterns[pattern] = re
+	return re, nil
+}
+
+// normalize converts json.Number and other numeric types to float64 so that
+// values decoded in different ways compare equal.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = normalize(item)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = normalize(item)
+		}
+		return out
+	}
+	if f, ok := number(value); ok {
+		return f
+	}
+	return value
+}
+
+func number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func typeOf(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func matchesType(t any, value any) bool {
+	switch t := t.(type) {
+	case string:
+		actual := typeOf(value)
+		return t == actual || (t == "number" && actual == "integer")
+	case []any:
+		for _, candidate := range t {
+			if matchesType(candidate, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func typeString(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, len(list))
+		for i, name := range list {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func equal(a, b any) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func escape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,79 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/catnovelapi/builder"
+)
+
+// har mirrors the parts of the HAR 1.2 format needed to replay requests.
+type har struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				PostData *struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// skippedHARHeaders are managed by the client or the transport and are not
+// copied from recorded requests.
+var skippedHARHeaders = map[string]bool{
+	"accept-encoding":   true,
+	"connection":        true,
+	"content-length":    true,
+	"host":              true,
+	"transfer-encoding": true,
+}
+
+// LoadHAR reads a HAR file, e.g. exported from browser developer tools,
+// and returns one prepared request per entry, created with client.R().
+// Use Request.WithHost to point the requests at a mirror.
+func LoadHAR(client *builder.Client, path string) ([]*builder.Request, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseHAR(client, data)
+}
+
+// ParseHAR is like LoadHAR but parses HAR data from memory.
+func ParseHAR(client *builder.Client, data []byte) ([]*builder.Request, error) {
+	var archive har
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, fmt.Errorf("loadtest: invalid HAR: %w", err)
+	}
+	requests := make([]*builder.Request, 0, len(archive.Log.Entries))
+	for i, entry := range archive.Log.Entries {
+		recorded := entry.Request
+		if recorded.Method == "" || recorded.URL == "" {
+			return nil, fmt.Errorf("loadtest: HAR entry %d has no method or URL", i)
+		}
+		request := client.R()
+		for _, header := range recorded.Headers {
+			// HTTP/2 pseudo headers such as :authority are not real headers
+			if strings.HasPrefix(header.Name, ":") || skippedHARHeaders[strings.ToLower(header.Name)] {
+				continue
+			}
+			request.SetHeader(header.Name, header.Value)
+		}
+		if recorded.PostData != nil && recorded.PostData.Text != "" {
+			request.SetBodyRaw([]byte(recorded.PostData.Text), recorded.PostData.MimeType)
+		}
+		requests = append(requests, request.Prepare(strings.ToUpper(recorded.Method), recorded.URL))
+	}
+	return requests, nil
+}
@@ -0,0 +1,223 @@
+// Package loadtest replays prepared builder requests at a target rate and
+// reports latency percentiles and error rates, e.g. to capacity-test a
+// self-hosted mirror of an API.
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/catnovelapi/builder"
+)
+
+// Options configures Run.
+type Options struct {
+	// RPS is the target number of requests per second once ramp-up is over.
+	RPS float64
+	// Duration is the total length of the run, including ramp-up.
+	Duration time.Duration
+	// RampUp linearly increases the rate from zero to RPS over this period.
+	RampUp time.Duration
+	// MaxInFlight caps the number of outstanding requests, 512 when zero.
+	// Requests that would exceed it are not sent and are counted as dropped,
+	// so that a slow server does not silently lower the offered load.
+	MaxInFlight int
+}
+
+// Latencies summarizes the latency distribution of network attempts.
+type Latencies struct {
+	Min, Mean, P50, P90, P95, P99, Max time.Duration
+}
+
+// Report is the result of a run.
+type Report struct {
+	Requests      int         // requests sent
+	Dropped       int         // requests skipped because MaxInFlight was reached
+	Errors        int         // requests that returned an error or a 5xx status
+	Attempts      int         // network attempts reported by the metrics hook, including retries
+	StatusCodes   map[int]int // final status code of each request, 0 for errors without a response
+	BytesReceived int64
+	Elapsed       time.Duration
+	Latency       Latencies
+}
+
+// ErrorRate returns the fraction of requests that failed.
+func (r *Report) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Requests)
+}
+
+// Throughput returns the achieved requests per second.
+func (r *Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Elapsed.Seconds()
+}
+
+// String formats the report for humans.
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "requests: %d (%.1f/s), dropped: %d, errors: %d (%.2f%%), attempts: %d\n",
+		r.Requests, r.Throughput(), r.Dropped, r.Errors, r.ErrorRate()*100, r.Attempts)
+	l := r.Latency
+	fmt.Fprintf(&b, "latency: min %v, mean %v, p50 %v, p90 %v, p95 %v, p99 %v, max %v\n",
+		l.Min, l.Mean, l.P50, l.P90, l.P95, l.P99, l.Max)
+	codes := make([]int, 0, len(r.StatusCodes))
+	for code := range r.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	b.WriteString("status:")
+	for _, code := range codes {
+		fmt.Fprintf(&b, " %d=%d", code, r.StatusCodes[code])
+	}
+	return b.String()
+}
+
+// recorder collects the attempt metrics of the requests sent by Run.
+type recorder struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	bytes     int64
+}
+
+func (r *recorder) ObserveRequest(metrics builder.RequestMetrics) {
+	r.mu.Lock()
+	r.latencies = append(r.latencies, metrics.Duration)
+	r.bytes += metrics.BytesReceived
+	r.mu.Unlock()
+}
+
+// Run sends the requests round-robin at the rate given by opts until
+// opts.Duration has elapsed or ctx is canceled, then waits for outstanding
+// requests (which are canceled only with ctx) and returns the report. Each
+// send uses a clone of the prepared request, so requests can be reused.
+// Attempts are recorded with a per-request metrics hook on the clones, so
+// the client's own MetricsHook keeps receiving them and other traffic on
+// the client is not counted. Run does not modify client, which is the
+// client the requests were created with.
+func Run(ctx context.Context, client *builder.Client, requests []*builder.Request, opts Options) (*Report, error) {
+	if len(requests) == 0 {
+		return nil, errors.New("loadtest: no requests")
+	}
+	if opts.RPS <= 0 || opts.Duration <= 0 {
+		return nil, errors.New("loadtest: RPS and Duration must be positive")
+	}
+	if opts.MaxInFlight <= 0 {
+		opts.MaxInFlight = 512
+	}
+	rec := &recorder{}
+
+	// stop issuing requests after Duration, but let outstanding ones finish
+	dispatch, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+	report := &Report{StatusCodes: map[int]int{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, opts.MaxInFlight)
+	start := time.Now()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for i := 0; ; i++ {
+		timer.Reset(time.Until(start.Add(schedule(opts, i))))
+		select {
+		case <-dispatch.Done():
+		case <-timer.C:
+		}
+		if dispatch.Err() != nil {
+			break
+		}
+		select {
+		case slots <- struct{}{}:
+			wg.Add(1)
+			go func(request *builder.Request) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				response, err := request.Clone().SetContext(ctx).SetMetricsHook(rec).Send()
+				mu.Lock()
+				defer mu.Unlock()
+				report.Requests++
+				code := 0
+				if response != nil {
+					code = response.GetStatusCode()
+					// read the whole body so that latency and bytes cover the full response
+					_ = response.GetByte()
+				}
+				report.StatusCodes[code]++
+				if err != nil || code >= 500 {
+					report.Errors++
+				}
+			}(requests[i%len(requests)])
+		default:
+			mu.Lock()
+			report.Dropped++
+			mu.Unlock()
+		}
+	}
+	wg.Wait()
+	report.Elapsed = time.Since(start)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	report.Attempts = len(rec.latencies)
+	report.BytesReceived = rec.bytes
+	report.Latency = summarize(rec.latencies)
+	return report, nil
+}
+
+// schedule returns when the i-th request (counting from zero) is due. The
+// number of requests due by time t is the integral of the rate, which grows
+// linearly during ramp-up and stays at RPS afterwards.
+func schedule(opts Options, i int) time.Duration {
+	k := float64(i)
+	ramp := opts.RampUp.Seconds()
+	var seconds float64
+	if rampRequests := opts.RPS * ramp / 2; k < rampRequests {
+		seconds = math.Sqrt(2 * ramp * k / opts.RPS)
+	} else {
+		seconds = ramp + (k-rampRequests)/opts.RPS
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func summarize(latencies []time.Duration) Latencies {
+	if len(latencies) == 0 {
+		return Latencies{}
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, latency := range sorted {
+		total += latency
+	}
+	percentile := func(p float64) time.Duration {
+		index := int(p*float64(len(sorted))+0.5) - 1
+		if index < 0 {
+			index = 0
+		}
+		if index >= len(sorted) {
+			index = len(sorted) - 1
+		}
+		return sorted[index]
+	}
+	return Latencies{
+		Min:  sorted[0],
+		Mean: total / time.Duration(len(sorted)),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P95:  percentile(0.95),
+		P99:  percentile(0.99),
+		Max:  sorted[len(sorted)-1],
+	}
+}
@@ -0,0 +1,66 @@
+package loadtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/catnovelapi/builder"
+)
+
+type countingHook struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (h *countingHook) ObserveRequest(builder.RequestMetrics) {
+	h.mu.Lock()
+	h.count++
+	h.mu.Unlock()
+}
+
+func TestRunKeepsClientMetricsHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	hook := &countingHook{}
+	client := builder.NewClient().SetMetricsHook(hook)
+
+	// traffic sent on the client during the run is not part of the report
+	stop := make(chan struct{})
+	var other sync.WaitGroup
+	other.Add(1)
+	go func() {
+		defer other.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_, _ = client.R().Get(server.URL + "/other")
+			}
+		}
+	}()
+	report, err := Run(context.Background(), client, []*builder.Request{client.R().Prepare("GET", server.URL)},
+		Options{RPS: 50, Duration: 200 * time.Millisecond})
+	close(stop)
+	other.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Requests == 0 || report.Attempts != report.Requests {
+		t.Errorf("attempts = %d, requests = %d, want equal and non-zero", report.Attempts, report.Requests)
+	}
+	if client.GetClientMetricsHook() != hook {
+		t.Error("Run replaced the client's MetricsHook")
+	}
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	if hook.count < report.Attempts {
+		t.Errorf("client hook saw %d attempts, want at least %d", hook.count, report.Attempts)
+	}
+}
@@ -0,0 +1,594 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Options configures Generate.
+type Options struct {
+	// Package is the name of the generated package, "api" when empty.
+	Package string
+}
+
+// Generate emits Go source with one method per operation of doc on a
+// Client type wrapping *builder.Client. Component schemas become named
+// types, path parameters become method arguments, query and header
+// parameters are grouped into a <Operation>Params struct, and the JSON
+// schema of the first 2xx response becomes the result type. Cookie
+// parameters are not supported and are skipped.
+func Generate(doc *Document, opts Options) ([]byte, error) {
+	if opts.Package == "" {
+		opts.Package = "api"
+	}
+	g := &generator{doc: doc, names: map[string]bool{"Client": true, "New": true, "DefaultServerURL": true}, imports: map[string]bool{}}
+	schemas := doc.Schemas()
+	componentNames := map[string]string{}
+	for _, name := range sortedKeys(schemas) {
+		componentNames[name] = g.uniqueName(goName(name))
+	}
+	g.components = componentNames
+	for _, name := range sortedKeys(schemas) {
+		g.namedType(componentNames[name], schemas[name])
+	}
+	methodNames := map[string]bool{"Builder": true}
+	var methods bytes.Buffer
+	for _, operation := range doc.Operations {
+		name := operation.ID
+		if name == "" {
+			name = strings.ToLower(operation.Method) + " " + strings.NewReplacer("{", "by ", "}", "").Replace(operation.Path)
+		}
+		name = uniqueIn(methodNames, goName(name))
+		if err := g.method(&methods, name, operation); err != nil {
+			return nil, fmt.Errorf("openapi: %s %s: %w", operation.Method, operation.Path, err)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by builder gen openapi. DO NOT EDIT.\n\n")
+	if doc.Title != "" {
+		fmt.Fprintf(&out, "// Package %s is a client for %s", opts.Package, doc.Title)
+		if doc.Version != "" {
+			fmt.Fprintf(&out, " %s", doc.Version)
+		}
+		fmt.Fprintf(&out, ".\n")
+	}
+	fmt.Fprintf(&out, "package %s\n\nimport (\n", opts.Package)
+	for _, path := range sortedKeys(g.imports) {
+		if !strings.Contains(path, ".") {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+	}
+	fmt.Fprintf(&out, "\n\t%q\n", "github.com/catnovelapi/builder")
+	for _, path := range sortedKeys(g.imports) {
+		if strings.Contains(path, ".") {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+	}
+	fmt.Fprintf(&out, ")\n\n")
+	if len(doc.Servers) > 0 {
+		fmt.Fprintf(&out, "// DefaultServerURL is the first server listed in the spec.\nconst DefaultServerURL = %q\n\n", doc.Servers[0])
+	}
+	fmt.Fprintf(&out, `// Client wraps a builder.Client with one method per operation in the spec.
+type Client struct {
+	client *builder.Client
+}
+
+// New returns a Client that sends its requests through client.
+func New(client *builder.Client) *Client {
+	return &Client{client: client}
+}
+
+// Builder returns the underlying builder.Client.
+func (c *Client) Builder() *builder.Client {
+	return c.client
+}
+
+`)
+	for _, name := range g.order {
+		out.WriteString(g.types[name])
+		out.WriteString("\n")
+	}
+	out.Write(methods.Bytes())
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("openapi: format generated code: %w", err)
+	}
+	return source, nil
+}
+
+type generator struct {
+	doc        *Document
+	components map[string]string // component schema name -> Go type name
+	names      map[string]bool   // Go names already taken at package level
+	types      map[string]string // Go type name -> declaration
+	order      []string
+	imports    map[string]bool
+}
+
+func (g *generator) uniqueName(name string) string {
+	return uniqueIn(g.names, name)
+}
+
+// uniqueIn returns name, or name with the smallest numeric suffix not yet
+// in taken, and marks the result as taken.
+func uniqueIn(taken map[string]bool, name string) string {
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	taken[unique] = true
+	return unique
+}
+
+func (g *generator) declare(name, declaration string) {
+	if g.types == nil {
+		g.types = map[string]string{}
+	}
+	g.types[name] = declaration
+	g.order = append(g.order, name)
+}
+
+// namedType declares a component schema as a Go type.
+func (g *generator) namedType(name string, schema any) {
+	object, _ := schema.(map[string]any)
+	var decl strings.Builder
+	fmt.Fprintf(&decl, "// %s is generated from the %s schema.\n", name, name)
+	if text := description(object); text != "" {
+		decl.WriteString("//\n")
+		writeComment(&decl, "", text)
+	}
+	if enum, ok := object["enum"].([]any); ok && schemaType(object) == "string" {
+		fmt.Fprintf(&decl, "type %s string\n\n", name)
+		var constants strings.Builder
+		for _, value := range enum {
+			if value, ok := value.(string); ok {
+				fmt.Fprintf(&constants, "\t%s %s = %q\n", g.uniqueName(name+goName(value)), name, value)
+			}
+		}
+		if constants.Len() > 0 {
+			fmt.Fprintf(&decl, "const (\n%s)\n", constants.String())
+		}
+		g.declare(name, decl.String())
+		return
+	}
+	// reserve the slot first so that inline types follow the type using them
+	g.declare(name, "")
+	if isStruct(object) {
+		g.structType(&decl, name, object)
+		g.types[name] = decl.String()
+		return
+	}
+	fmt.Fprintf(&decl, "type %s %s\n", name, g.goType(schema, name))
+	g.types[name] = decl.String()
+}
+
+// structType writes a struct declaration for an object schema.
+func (g *generator) structType(decl *strings.Builder, name string, object map[string]any) {
+	var fields strings.Builder
+	// field names share one namespace across allOf parts, embedded types included
+	taken := map[string]bool{}
+	for _, part := range list(object["allOf"]) {
+		part, _ := part.(map[string]any)
+		if ref, ok := part["$ref"].(string); ok {
+			embedded := g.refType(ref)
+			if !taken[embedded] {
+				taken[embedded] = true
+				fmt.Fprintf(&fields, "\t%s\n", embedded)
+			}
+		} else {
+			g.structFields(&fields, name, part, taken)
+		}
+	}
+	g.structFields(&fields, name, object, taken)
+	fmt.Fprintf(decl, "type %s struct {\n%s}\n", name, fields.String())
+}
+
+func (g *generator) structFields(fields *strings.Builder, name string, object map[string]any, taken map[string]bool) {
+	properties, _ := object["properties"].(map[string]any)
+	required := map[string]bool{}
+	for _, key := range list(object["required"]) {
+		if key, ok := key.(string); ok {
+			required[key] = true
+		}
+	}
+	for _, property := range sortedKeys(properties) {
+		field := uniqueIn(taken, goName(property))
+		fieldType := g.goType(properties[property], name+field)
+		tag := property
+		if !required[property] {
+			fieldType = optional(fieldType)
+			tag += ",omitempty"
+		}
+		if text := description(properties[property]); text != "" {
+			writeComment(fields, "\t", text)
+		}
+		fmt.Fprintf(fields, "\t%s %s `json:%q`\n", field, fieldType, tag)
+	}
+}
+
+// goType returns the Go type for schema, declaring a struct named hint
+// for inline object schemas.
+func (g *generator) goType(schema any, hint string) string {
+	object, ok := schema.(map[string]any)
+	if !ok {
+		return "any"
+	}
+	if ref, ok := object["$ref"].(string); ok {
+		return g.refType(ref)
+	}
+	if object["oneOf"] != nil || object["anyOf"] != nil {
+		g.imports["encoding/json"] = true
+		return "json.RawMessage"
+	}
+	if isStruct(object) {
+		name := g.uniqueName(hint)
+		var decl strings.Builder
+		g.declare(name, "")
+		g.structType(&decl, name, object)
+		g.types[name] = decl.String()
+		return name
+	}
+	format, _ := object["format"].(string)
+	switch schemaType(object) {
+	case "string":
+		if format == "date-time" {
+			g.imports["time"] = true
+			return "time.Time"
+		}
+		return "string"
+	case "integer":
+		if format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		if format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.goType(object["items"], hint+"Item")
+	case "object":
+		if additional, ok := object["additionalProperties"].(map[string]any); ok {
+			return "map[string]" + g.goType(additional, hint+"Value")
+		}
+		return "map[string]any"
+	}
+	return "any"
+}
+
+// refType returns the Go type for a $ref.
+func (g *generator) refType(ref string) string {
+	const prefix = "#/components/schemas/"
+	if name, ok := g.components[strings.TrimPrefix(ref, prefix)]; ok && strings.HasPrefix(ref, prefix) {
+		return name
+	}
+	target, err := g.doc.Resolve(map[string]any{"$ref": ref})
+	if err != nil {
+		return "any"
+	}
+	return g.goType(target, goName(ref[strings.LastIndex(ref, "/")+1:]))
+}
+
+// method writes the wrapper method for one operation.
+func (g *generator) method(out *bytes.Buffer, name string, operation *Operation) error {
+	var args []string
+	var pathParams, otherParams []*Parameter
+	locals := map[string]bool{"ctx": true, "params": true, "body": true, "request": true, "response": true, "result": true, "err": true, "c": true}
+	argNames := map[*Parameter]string{}
+	argTypes := map[*Parameter]string{}
+	for _, parameter := range operation.Parameters {
+		switch parameter.In {
+		case "path":
+			pathParams = append(pathParams, parameter)
+		case "query", "header":
+			otherParams = append(otherParams, parameter)
+		}
+	}
+	args = append(args, "ctx context.Context")
+	g.imports["context"] = true
+	for _, parameter := range pathParams {
+		arg := lowerName(goName(parameter.Name))
+		if locals[arg] || isKeyword(arg) {
+			arg += "Param"
+		}
+		arg = uniqueIn(locals, arg)
+		argNames[parameter] = arg
+		argTypes[parameter] = g.goType(parameter.Schema, name+goName(parameter.Name))
+		args = append(args, arg+" "+argTypes[parameter])
+	}
+
+	// path expression
+	var pathExpr []string
+	rest := operation.Path
+	for rest != "" {
+		open := strings.Index(rest, "{")
+		if open < 0 {
+			pathExpr = append(pathExpr, strconv.Quote(rest))
+			break
+		}
+		closing := strings.Index(rest[open:], "}")
+		if closing < 0 {
+			return fmt.Errorf("unterminated path parameter")
+		}
+		if open > 0 {
+			pathExpr = append(pathExpr, strconv.Quote(rest[:open]))
+		}
+		paramName := rest[open+1 : open+closing]
+		var parameter *Parameter
+		for _, p := range pathParams {
+			if p.Name == paramName {
+				parameter = p
+			}
+		}
+		if parameter == nil {
+			return fmt.Errorf("path parameter %q is not declared", paramName)
+		}
+		g.imports["net/url"] = true
+		switch {
+		case argTypes[parameter] == "string":
+			pathExpr = append(pathExpr, "url.PathEscape("+argNames[parameter]+")")
+		case schemaType(parameter.Schema) == "string":
+			pathExpr = append(pathExpr, "url.PathEscape(string("+argNames[parameter]+"))")
+		default:
+			g.imports["fmt"] = true
+			pathExpr = append(pathExpr, "url.PathEscape(fmt.Sprint("+argNames[parameter]+"))")
+		}
+		rest = rest[open+closing+1:]
+	}
+	if len(pathExpr) == 0 {
+		pathExpr = []string{`"/"`}
+	}
+
+	// query and header parameters
+	var paramsType string
+	fieldNames := map[*Parameter]string{}
+	if len(otherParams) > 0 {
+		paramsType = g.uniqueName(name + "Params")
+		var fields strings.Builder
+		taken := map[string]bool{}
+		for _, parameter := range otherParams {
+			fieldNames[parameter] = uniqueIn(taken, goName(parameter.Name))
+			fieldType := "string"
+			if parameter.In == "query" {
+				fieldType = g.goType(parameter.Schema, paramsType+fieldNames[parameter])
+				if !parameter.Required {
+					fieldType = optional(fieldType)
+				}
+			}
+			if parameter.Description != "" {
+				writeComment(&fields, "\t", parameter.Description)
+			}
+			fmt.Fprintf(&fields, "\t%s %s // %s %q\n", fieldNames[parameter], fieldType, parameter.In, parameter.Name)
+		}
+		var decl strings.Builder
+		fmt.Fprintf(&decl, "// %s holds the query and header parameters of %s.\ntype %s struct {\n%s}\n", paramsType, name, paramsType, fields.String())
+		g.declare(paramsType, decl.String())
+		args = append(args, "params *"+paramsType)
+	}
+
+	// request body
+	var bodyStmt string
+	if operation.RequestBody != nil {
+		schema, mediaType := JSONSchema(operation.RequestBody.Content)
+		bodyType := "[]byte"
+		switch {
+		case mediaType != "":
+			bodyType = g.goType(schema, name+"Body")
+			bodyStmt = "request.SetBodyJson(body)"
+		case operation.RequestBody.Content["application/x-www-form-urlencoded"] != nil:
+			mediaType = "application/x-www-form-urlencoded"
+			bodyType = g.goType(operation.RequestBody.Content[mediaType], name+"Body")
+			bodyStmt = fmt.Sprintf("request.SetHeaderContentType(%q).SetBody(body)", mediaType)
+		default:
+			mediaTypes := sortedKeys(operation.RequestBody.Content)
+			if len(mediaTypes) > 0 {
+				mediaType = mediaTypes[0]
+			}
+			bodyStmt = fmt.Sprintf("request.SetBodyRaw(body, %q)", mediaType)
+		}
+		if bodyType != "[]byte" {
+			bodyType = optional(bodyType)
+		}
+		args = append(args, "body "+bodyType)
+		if bodyType[0] == '*' || bodyType[0] == '[' || strings.HasPrefix(bodyType, "map[") || bodyType == "any" {
+			bodyStmt = "if body != nil {\n" + bodyStmt + "\n}"
+		}
+	}
+
+	// result
+	var resultType string
+	if response := operation.SuccessResponse(); response != nil {
+		if schema, _ := JSONSchema(response.Content); schema != nil {
+			resultType = g.goType(schema, name+"Result")
+		}
+	}
+	returns := "(*builder.Response, error)"
+	zero := ""
+	if resultType != "" {
+		returns = "(" + optional(resultType) + ", *builder.Response, error)"
+		zero = "nil, "
+	}
+
+	fmt.Fprintf(out, "// %s calls %s %s.\n", name, operation.Method, operation.Path)
+	if operation.Summary != "" {
+		out.WriteString("//\n")
+		writeComment(out, "", operation.Summary)
+	}
+	fmt.Fprintf(out, "func (c *Client) %s(%s) %s {\n", name, strings.Join(args, ", "), returns)
+	fmt.Fprintf(out, "request := c.client.R().SetContext(ctx)\n")
+	if paramsType != "" {
+		fmt.Fprintf(out, "if params != nil {\n")
+		for _, parameter := range otherParams {
+			field := "params." + fieldNames[parameter]
+			if parameter.In == "query" {
+				fmt.Fprintf(out, "request.SetQueryParam(%q, %s)\n", parameter.Name, field)
+			} else {
+				fmt.Fprintf(out, "if %s != \"\" {\nrequest.SetHeader(%q, %s)\n}\n", field, parameter.Name, field)
+			}
+		}
+		fmt.Fprintf(out, "}\n")
+	}
+	if bodyStmt != "" {
+		fmt.Fprintf(out, "%s\n", bodyStmt)
+	}
+	fmt.Fprintf(out, "response, err := request.Prepare(%q, %s).Send()\n", operation.Method, strings.Join(pathExpr, " + "))
+	fmt.Fprintf(out, "if err != nil {\nreturn %sresponse, err\n}\n", zero)
+	g.imports["github.com/catnovelapi/builder/pkg/openapi"] = true
+	fmt.Fprintf(out, "if code := response.GetStatusCode(); code < 200 || code > 299 {\n")
+	fmt.Fprintf(out, "return %sresponse, &openapi.StatusError{Method: %q, Path: %q, StatusCode: code, Body: response.String()}\n}\n", zero, operation.Method, operation.Path)
+	if resultType == "" {
+		fmt.Fprintf(out, "return response, nil\n}\n\n")
+		return nil
+	}
+	fmt.Fprintf(out, "var result %s\n", resultType)
+	fmt.Fprintf(out, "if err := response.Json(&result); err != nil {\nreturn nil, response, err\n}\n")
+	if optional(resultType) != resultType {
+		fmt.Fprintf(out, "return &result, response, nil\n}\n\n")
+	} else {
+		fmt.Fprintf(out, "return result, response, nil\n}\n\n")
+	}
+	return nil
+}
+
+// optional returns the type used for values that may be absent: pointers
+// for scalars and structs, the type itself for slices, maps and interfaces.
+func optional(goType string) string {
+	if strings.HasPrefix(goType, "[]") || strings.HasPrefix(goType, "map[") || strings.HasPrefix(goType, "*") ||
+		goType == "any" || goType == "json.RawMessage" {
+		return goType
+	}
+	return "*" + goType
+}
+
+func isStruct(object map[string]any) bool {
+	if object == nil {
+		return false
+	}
+	if _, ok := object["$ref"]; ok {
+		return false
+	}
+	if object["allOf"] != nil {
+		return true
+	}
+	properties, _ := object["properties"].(map[string]any)
+	return len(properties) > 0
+}
+
+// schemaType returns the schema's type, ignoring "null" in OpenAPI 3.1 type lists.
+func schemaType(schema any) string {
+	object, _ := schema.(map[string]any)
+	switch t := object["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, candidate := range t {
+			if candidate, ok := candidate.(string); ok && candidate != "null" {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+func description(schema any) string {
+	object, _ := schema.(map[string]any)
+	text, _ := object["description"].(string)
+	return strings.TrimSpace(text)
+}
+
+// writeComment writes text as a comment, one line per line of text.
+func writeComment(w interface{ WriteString(string) (int, error) }, indent, text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		w.WriteString(strings.TrimRight(indent+"// "+strings.TrimSpace(line), " ") + "\n")
+	}
+}
+
+// initialisms are upper-cased as a whole when they form a word.
+var initialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "https": true, "id": true, "ip": true, "json": true,
+	"sql": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// goName converts an identifier from the spec to an exported Go name.
+func goName(s string) string {
+	var words []string
+	var word []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			words = append(words, string(word))
+			word = []rune{r}
+		default:
+			word = append(word, r)
+		}
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	var name strings.Builder
+	for _, word := range words {
+		if initialisms[strings.ToLower(word)] {
+			name.WriteString(strings.ToUpper(word))
+			continue
+		}
+		r := []rune(word)
+		name.WriteRune(unicode.ToUpper(r[0]))
+		name.WriteString(string(r[1:]))
+	}
+	result := name.String()
+	if result == "" {
+		return "X"
+	}
+	if unicode.IsDigit([]rune(result)[0]) {
+		return "N" + result
+	}
+	return result
+}
+
+// lowerName converts an exported Go name to an unexported one, lowering a
+// leading initialism as a whole ("URLPath" becomes "urlPath").
+func lowerName(name string) string {
+	r := []rune(name)
+	upper := 0
+	for upper < len(r) && unicode.IsUpper(r[upper]) {
+		upper++
+	}
+	switch {
+	case upper == len(r):
+		return strings.ToLower(name)
+	case upper > 1:
+		upper--
+	}
+	for i := 0; i < upper; i++ {
+		r[i] = unicode.ToLower(r[i])
+	}
+	return string(r)
+}
+
+var keywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true, "default": true,
+	"defer": true, "else": true, "fallthrough": true, "for": true, "func": true, "go": true, "goto": true,
+	"if": true, "import": true, "interface": true, "map": true, "package": true, "range": true,
+	"return": true, "select": true, "struct": true, "switch": true, "type": true, "var": true,
+	"url": true, "fmt": true, "builder": true, "openapi": true, "context": true, "string": true,
+}
+
+// isKeyword reports whether name is a Go keyword or would shadow an
+// identifier the generated methods use.
+func isKeyword(name string) bool {
+	return keywords[name]
+}
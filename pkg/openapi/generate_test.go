@@ -0,0 +1,65 @@
+package openapi
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestGenerateGolden generates a client for every testdata/<name>.yaml,
+// compares it with testdata/<name>/api.go and checks that it compiles.
+func TestGenerateGolden(t *testing.T) {
+	specs, err := filepath.Glob(filepath.Join("testdata", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) == 0 {
+		t.Fatal("no specs in testdata")
+	}
+	for _, spec := range specs {
+		name := strings.TrimSuffix(filepath.Base(spec), ".yaml")
+		t.Run(name, func(t *testing.T) {
+			doc, err := Load(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			source, err := Generate(doc, Options{Package: name})
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", name, "api.go")
+			if *update {
+				if err = os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err = os.WriteFile(golden, source, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if !bytes.Equal(source, want) {
+				t.Errorf("generated code differs from %s (run go test -update to accept it):\n%s", golden, source)
+			}
+			if testing.Short() {
+				return
+			}
+			goTool, err := exec.LookPath("go")
+			if err != nil {
+				t.Skip("go tool not found")
+			}
+			// testdata is skipped by ./... patterns but builds when named explicitly
+			if out, err := exec.Command(goTool, "vet", "./"+filepath.ToSlash(filepath.Dir(golden))).CombinedOutput(); err != nil {
+				t.Errorf("generated code does not compile: %v\n%s", err, out)
+			}
+		})
+	}
+}
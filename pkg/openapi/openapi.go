@@ -0,0 +1,334 @@
+// Package openapi loads OpenAPI 3 documents and generates typed wrappers
+// over builder.Client for the operations they describe.
+package openapi
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a parsed OpenAPI 3 document.
+type Document struct {
+	Title      string
+	Version    string
+	Servers    []string
+	Operations []*Operation
+
+	root map[string]any
+}
+
+// Operation is a single method on a path.
+type Operation struct {
+	ID          string // operationId, may be empty
+	Method      string // upper case, e.g. "GET"
+	Path        string // path template, e.g. "/pets/{petId}"
+	Summary     string
+	Parameters  []*Parameter
+	RequestBody *RequestBody
+	Responses   map[string]*Response // keyed by status code, "2XX" or "default"
+}
+
+// Parameter is a path, query, header or cookie parameter. Parameters
+// declared on the path item are merged into each of its operations.
+type Parameter struct {
+	Name        string
+	In          string // "path", "query", "header" or "cookie"
+	Required    bool
+	Description string
+	Schema      any
+}
+
+// RequestBody describes the accepted request bodies.
+type RequestBody struct {
+	Required bool
+	Content  map[string]any // media type -> schema (nil when none is given)
+}
+
+// Response describes a response for one status code.
+type Response struct {
+	Description string
+	Content     map[string]any // media type -> schema (nil when none is given)
+}
+
+// methods lists the operations of a path item in a stable order.
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Load reads and parses an OpenAPI document in YAML or JSON format.
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses an OpenAPI document in YAML or JSON format.
+func Parse(data []byte) (*Document, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("openapi: invalid document: %w", err)
+	}
+	root, ok := normalize(raw).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("openapi: document is not an object")
+	}
+	if version, _ := root["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("openapi: unsupported document version %q, only OpenAPI 3 is supported", version)
+	}
+	doc := &Document{root: root}
+	if info, ok := root["info"].(map[string]any); ok {
+		doc.Title, _ = info["title"].(string)
+		doc.Version, _ = info["version"].(string)
+	}
+	for _, server := range list(root["servers"]) {
+		if server, ok := server.(map[string]any); ok {
+			if u, ok := server["url"].(string); ok {
+				doc.Servers = append(doc.Servers, u)
+			}
+		}
+	}
+	paths, _ := root["paths"].(map[string]any)
+	for _, path := range sortedKeys(paths) {
+		item, err := doc.object(paths[path])
+		if err != nil {
+			return nil, fmt.Errorf("openapi: path %s: %w", path, err)
+		}
+		shared, err := doc.parameters(item["parameters"])
+		if err != nil {
+			return nil, fmt.Errorf("openapi: path %s: %w", path, err)
+		}
+		for _, method := range methods {
+			node, ok := item[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			operation, err := doc.operation(strings.ToUpper(method), path, node, shared)
+			if err != nil {
+				return nil, fmt.Errorf("openapi: %s %s: %w", strings.ToUpper(method), path, err)
+			}
+			doc.Operations = append(doc.Operations, operation)
+		}
+	}
+	return doc, nil
+}
+
+func (d *Document) operation(method, path string, node map[string]any, shared []*Parameter) (*Operation, error) {
+	operation := &Operation{Method: method, Path: path, Responses: map[string]*Response{}}
+	operation.ID, _ = node["operationId"].(string)
+	operation.Summary, _ = node["summary"].(string)
+	own, err := d.parameters(node["parameters"])
+	if err != nil {
+		return nil, err
+	}
+	// parameters declared on the operation override those of the path item
+	for _, parameter := range shared {
+		overridden := false
+		for _, p := range own {
+			if p.Name == parameter.Name && p.In == parameter.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			operation.Parameters = append(operation.Parameters, parameter)
+		}
+	}
+	operation.Parameters = append(operation.Parameters, own...)
+	if node["requestBody"] != nil {
+		body, err := d.object(node["requestBody"])
+		if err != nil {
+			return nil, fmt.Errorf("requestBody: %w", err)
+		}
+		operation.RequestBody = &RequestBody{Content: content(body["content"])}
+		operation.RequestBody.Required, _ = body["required"].(bool)
+	}
+	responses, _ := node["responses"].(map[string]any)
+	for status, value := range responses {
+		response, err := d.object(value)
+		if err != nil {
+			return nil, fmt.Errorf("response %s: %w", status, err)
+		}
+		description, _ := response["description"].(string)
+		operation.Responses[status] = &Response{Description: description, Content: content(response["content"])}
+	}
+	return operation, nil
+}
+
+func (d *Document) parameters(node any) ([]*Parameter, error) {
+	var parameters []*Parameter
+	for _, value := range list(node) {
+		object, err := d.object(value)
+		if err != nil {
+			return nil, fmt.Errorf("parameter: %w", err)
+		}
+		parameter := &Parameter{Schema: object["schema"]}
+		parameter.Name, _ = object["name"].(string)
+		parameter.In, _ = object["in"].(string)
+		parameter.Required, _ = object["required"].(bool)
+		parameter.Description, _ = object["description"].(string)
+		if parameter.Name == "" || parameter.In == "" {
+			return nil, fmt.Errorf("parameter is missing name or in")
+		}
+		parameters = append(parameters, parameter)
+	}
+	return parameters, nil
+}
+
+// object resolves node and requires it to be an object.
+func (d *Document) object(node any) (map[string]any, error) {
+	resolved, err := d.Resolve(node)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := resolved.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an object")
+	}
+	return object, nil
+}
+
+// Root returns the decoded document. Schemas taken from the document are
+// resolved against it, e.g. jsonschema.New(schema, doc.Root()).
+func (d *Document) Root() map[string]any {
+	return d.root
+}
+
+// Schemas returns the schemas declared under components/schemas.
+func (d *Document) Schemas() map[string]any {
+	components, _ := d.root["components"].(map[string]any)
+	schemas, _ := components["schemas"].(map[string]any)
+	return schemas
+}
+
+// maxRefDepth guards against cyclic $ref chains.
+const maxRefDepth = 32
+
+// Resolve follows local "#/..." $ref pointers until it reaches a node that
+// is not a reference. Other nodes are returned unchanged.
+func (d *Document) Resolve(node any) (any, error) {
+	for i := 0; i < maxRefDepth; i++ {
+		object, ok := node.(map[string]any)
+		if !ok {
+			return node, nil
+		}
+		ref, ok := object["$ref"].(string)
+		if !ok {
+			return node, nil
+		}
+		target, err := d.pointer(ref)
+		if err != nil {
+			return nil, err
+		}
+		node = target
+	}
+	return nil, fmt.Errorf("$ref chain too deep")
+}
+
+func (d *Document) pointer(ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q: only local references are supported", ref)
+	}
+	pointer, err := url.PathUnescape(ref[2:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid $ref %q", ref)
+	}
+	var node any = d.root
+	for _, token := range strings.Split(pointer, "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if node, ok = object[token]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return node, nil
+}
+
+// SuccessResponse returns the response the operation documents for
+// success: the lowest 2xx status, then "2XX", then "default".
+func (o *Operation) SuccessResponse() *Response {
+	for _, status := range sortedKeys(o.Responses) {
+		if len(status) == 3 && status[0] == '2' && status != "2XX" {
+			return o.Responses[status]
+		}
+	}
+	if response, ok := o.Responses["2XX"]; ok {
+		return response
+	}
+	return o.Responses["default"]
+}
+
+// JSONSchema returns the schema of the first JSON media type in content,
+// or nil when there is none.
+func JSONSchema(content map[string]any) (schema any, mediaType string) {
+	for _, mediaType := range sortedKeys(content) {
+		if IsJSON(mediaType) {
+			return content[mediaType], mediaType
+		}
+	}
+	return nil, ""
+}
+
+// IsJSON reports whether mediaType is application/json or a +json type.
+func IsJSON(mediaType string) bool {
+	mediaType = strings.ToLower(strings.TrimSpace(strings.Split(mediaType, ";")[0]))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func content(node any) map[string]any {
+	object, _ := node.(map[string]any)
+	if len(object) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(object))
+	for mediaType, value := range object {
+		media, _ := value.(map[string]any)
+		out[mediaType] = media["schema"]
+	}
+	return out
+}
+
+// normalize converts the map[any]any values produced by YAML for mappings
+// with non-string keys (such as response status codes) to map[string]any.
+func normalize(node any) any {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, value := range v {
+			v[key] = normalize(value)
+		}
+		return v
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for key, value := range v {
+			out[fmt.Sprint(key)] = normalize(value)
+		}
+		return out
+	case []any:
+		for i, value := range v {
+			v[i] = normalize(value)
+		}
+		return v
+	}
+	return node
+}
+
+func list(node any) []any {
+	items, _ := node.([]any)
+	return items
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,16 @@
+package openapi
+
+import "fmt"
+
+// StatusError is returned by generated methods when the server answers
+// with a status code outside the 2xx range.
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: unexpected status %d", e.Method, e.Path, e.StatusCode)
+}
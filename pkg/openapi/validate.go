@@ -0,0 +1,289 @@
+package openapi
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/catnovelapi/builder/pkg/jsonschema"
+)
+
+// ValidationError reports how a request or response differs from the spec.
+type ValidationError struct {
+	Method   string // method of the request
+	Path     string // path template of the matched operation, or the request path when none matched
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("openapi: %s %s: %s", e.Method, e.Path, strings.Join(e.Problems, "; "))
+}
+
+// Validator checks outgoing requests and incoming responses against a
+// Document. It implements builder.SpecValidator, so it can be installed
+// with Client.SetSpecValidator.
+type Validator struct {
+	doc       *Document
+	basePaths []string
+	routes    []*route
+}
+
+type route struct {
+	operation *Operation
+	pattern   *regexp.Regexp
+	names     []string // path parameter names in the order of the pattern groups
+	literal   int      // number of literal characters, used to prefer specific paths
+}
+
+// NewValidator returns a Validator for doc. Request paths are matched
+// against the operations' path templates, optionally prefixed with the
+// path of one of the document's servers.
+func NewValidator(doc *Document) *Validator {
+	v := &Validator{doc: doc}
+	for _, server := range doc.Servers {
+		if u, err := url.Parse(server); err == nil {
+			if base := strings.TrimSuffix(u.Path, "/"); base != "" {
+				v.basePaths = append(v.basePaths, base)
+			}
+		}
+	}
+	v.basePaths = append(v.basePaths, "")
+	for _, operation := range doc.Operations {
+		r := &route{operation: operation}
+		var pattern strings.Builder
+		pattern.WriteString("^")
+		rest := operation.Path
+		for {
+			open := strings.Index(rest, "{")
+			closing := strings.Index(rest, "}")
+			if open < 0 || closing < open {
+				pattern.WriteString(regexp.QuoteMeta(rest))
+				r.literal += len(rest)
+				break
+			}
+			pattern.WriteString(regexp.QuoteMeta(rest[:open]))
+			pattern.WriteString("([^/]+)")
+			r.literal += open
+			r.names = append(r.names, rest[open+1:closing])
+			rest = rest[closing+1:]
+		}
+		pattern.WriteString("/?$")
+		r.pattern = regexp.MustCompile(pattern.String())
+		v.routes = append(v.routes, r)
+	}
+	sort.SliceStable(v.routes, func(i, j int) bool { return v.routes[i].literal > v.routes[j].literal })
+	return v
+}
+
+// find returns the operation for req and its path parameter values.
+func (v *Validator) find(req *http.Request) (*Operation, map[string]string, error) {
+	path := req.URL.EscapedPath()
+	pathMatched := false
+	for _, base := range v.basePaths {
+		if !strings.HasPrefix(path, base) {
+			continue
+		}
+		rest := path[len(base):]
+		for _, r := range v.routes {
+			match := r.pattern.FindStringSubmatch(rest)
+			if match == nil {
+				continue
+			}
+			pathMatched = true
+			if r.operation.Method != req.Method {
+				continue
+			}
+			values := make(map[string]string, len(r.names))
+			for i, name := range r.names {
+				value, err := url.PathUnescape(match[i+1])
+				if err != nil {
+					value = match[i+1]
+				}
+				values[name] = value
+			}
+			return r.operation, values, nil
+		}
+	}
+	problem := "no operation matches the path"
+	if pathMatched {
+		problem = "method is not defined for the path"
+	}
+	return nil, nil, &ValidationError{Method: req.Method, Path: req.URL.Path, Problems: []string{problem}}
+}
+
+// ValidateRequest checks that req and its body match an operation of the
+// spec: path parameters, required and typed query and header parameters,
+// and the request body's media type and JSON schema. A nil body means the
+// body is not available (e.g. it is streamed from a file) and is not checked.
+func (v *Validator) ValidateRequest(req *http.Request, body []byte) error {
+	operation, pathValues, err := v.find(req)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	query := req.URL.Query()
+	for _, parameter := range operation.Parameters {
+		var values []string
+		switch parameter.In {
+		case "path":
+			values = []string{pathValues[parameter.Name]}
+		case "query":
+			values = query[parameter.Name]
+		case "header":
+			values = req.Header.Values(parameter.Name)
+		case "cookie":
+			if cookie, err := req.Cookie(parameter.Name); err == nil {
+				values = []string{cookie.Value}
+			}
+		}
+		if len(values) == 0 {
+			if parameter.Required {
+				problems = append(problems, fmt.Sprintf("%s parameter %q is required", parameter.In, parameter.Name))
+			}
+			continue
+		}
+		if err := v.validateParameter(parameter, values); err != nil {
+			problems = append(problems, fmt.Sprintf("%s parameter %q: %v", parameter.In, parameter.Name, err))
+		}
+	}
+	if operation.RequestBody != nil && body != nil {
+		switch {
+		case len(body) == 0 && operation.RequestBody.Required:
+			problems = append(problems, "request body is required")
+		case len(body) > 0:
+			if problem := v.validateContent(operation.RequestBody.Content, req.Header.Get("Content-Type"), body); problem != "" {
+				problems = append(problems, "request body: "+problem)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Method: req.Method, Path: operation.Path, Problems: problems}
+	}
+	return nil
+}
+
+// ValidateResponse checks that the status code of resp is documented for
+// the operation matching req, and that the body matches the documented
+// media type and JSON schema.
+func (v *Validator) ValidateResponse(req *http.Request, resp *http.Response, body []byte) error {
+	operation, _, err := v.find(req)
+	if err != nil {
+		return err
+	}
+	status := strconv.Itoa(resp.StatusCode)
+	response, ok := operation.Responses[status]
+	if !ok {
+		response, ok = operation.Responses[status[:1]+"XX"]
+	}
+	if !ok {
+		response, ok = operation.Responses["default"]
+	}
+	if !ok {
+		return &ValidationError{Method: req.Method, Path: operation.Path, Problems: []string{"status " + status + " is not documented"}}
+	}
+	if len(body) == 0 || len(response.Content) == 0 {
+		return nil
+	}
+	if problem := v.validateContent(response.Content, resp.Header.Get("Content-Type"), body); problem != "" {
+		return &ValidationError{Method: req.Method, Path: operation.Path, Problems: []string{"response " + status + " body: " + problem}}
+	}
+	return nil
+}
+
+// validateContent checks a body against the media types of a request body
+// or response. Only JSON bodies are checked against their schema.
+func (v *Validator) validateContent(content map[string]any, contentType string, body []byte) string {
+	if len(content) == 0 {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	schema, found := any(nil), false
+	for _, declared := range sortedKeys(content) {
+		if mediaTypeMatches(strings.ToLower(declared), mediaType) {
+			schema, found = content[declared], true
+			break
+		}
+	}
+	if !found {
+		return fmt.Sprintf("content type %q is not one of %s", contentType, strings.Join(sortedKeys(content), ", "))
+	}
+	if schema == nil || !IsJSON(mediaType) {
+		return ""
+	}
+	if err := jsonschema.New(schema, v.doc.Root()).Validate(body); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// mediaTypeMatches reports whether actual matches declared, which may use
+// wildcards such as "application/*" or "*/*".
+func mediaTypeMatches(declared, actual string) bool {
+	if declared == actual || declared == "*/*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(declared, "/*"); ok {
+		return strings.HasPrefix(actual, prefix+"/")
+	}
+	return false
+}
+
+// validateParameter converts the raw parameter values to the type given by
+// the parameter's schema and validates them.
+func (v *Validator) validateParameter(parameter *Parameter, values []string) error {
+	if parameter.Schema == nil {
+		return nil
+	}
+	schema, err := v.doc.Resolve(parameter.Schema)
+	if err != nil {
+		return err
+	}
+	var value any
+	if schemaType(schema) == "array" {
+		object, _ := schema.(map[string]any)
+		items, err := v.doc.Resolve(object["items"])
+		if err != nil {
+			return err
+		}
+		if len(values) == 1 && parameter.In != "query" {
+			values = strings.Split(values[0], ",")
+		}
+		list := make([]any, len(values))
+		for i, raw := range values {
+			if list[i], err = coerce(raw, schemaType(items)); err != nil {
+				return err
+			}
+		}
+		value = list
+	} else if value, err = coerce(values[0], schemaType(schema)); err != nil {
+		return err
+	}
+	return jsonschema.New(parameter.Schema, v.doc.Root()).ValidateValue(value)
+}
+
+// coerce converts a raw parameter value to the JSON type t.
+func coerce(raw, t string) (any, error) {
+	switch t {
+	case "integer", "number":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected %s, got %q", t, raw)
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("expected boolean, got %q", raw)
+		}
+		return b, nil
+	}
+	return raw, nil
+}
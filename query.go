@@ -0,0 +1,158 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// BoolFormat 类型用于表示 Query 参数中布尔值的格式。
+type BoolFormat int
+
+const (
+	// BoolTrueFalse 把布尔值格式化为 true 或 false
+	BoolTrueFalse BoolFormat = iota
+	// BoolOneZero 把布尔值格式化为 1 或 0
+	BoolOneZero
+)
+
+// SetTimeFormat 方法用于设置 Query 参数中 time.Time 类型的值的格式。它接收一个 string 类型的参数，
+// 该参数表示 time.Format 使用的布局, 例如 "2006-01-02", 默认为 time.RFC3339。
+func (client *Client) SetTimeFormat(layout string) *Client {
+	client.timeFormat = layout
+	return client
+}
+
+// SetBoolFormat 方法用于设置 Query 参数中布尔值的格式。它接收一个 BoolFormat 类型的参数，默认为 BoolTrueFalse。
+func (client *Client) SetBoolFormat(format BoolFormat) *Client {
+	client.boolFormat = format
+	return client
+}
+
+// OmitEmptyParams 方法用于开启忽略空参数的模式, 开启后值为零值 (空字符串、0、false、零时间、空切片等) 或者空指针的
+// Query 参数和表单参数不会被发送。指向零值的指针不会被忽略, 可以用来发送 0 或 false 这样的有效值。
+func (client *Client) OmitEmptyParams() *Client {
+	client.omitEmptyParams = true
+	return client
+}
+
+// SetQueryParamOmitEmpty 方法用于设置 HTTP 请求的 Query 部分, 值为零值或者空指针时忽略该参数。它接收一个 string 类型的参数名
+// 和一个 any 类型的参数值, 适合根据可选的筛选条件构造搜索请求。
+func (request *Request) SetQueryParamOmitEmpty(key string, value any) *Request {
+	if isEmptyParam(value) {
+		return request
+	}
+	return request.SetQueryParam(key, value)
+}
+
+// isEmptyParam 方法用于判断参数值是否为零值、空指针或者空的切片和 map。
+func isEmptyParam(value any) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return rv.IsZero()
+}
+
+// formatQueryValues 方法用于把 Query 参数的值格式化为字符串列表。支持字符串、布尔值、数字、time.Time、
+// fmt.Stringer、指针以及由这些类型组成的切片, 切片中的每个元素都会生成一个同名参数; nil 和空指针不生成参数。
+func (client *Client) formatQueryValues(value any) []string {
+	// 指针先解引用, 否则 *time.Time 等类型会匹配到 fmt.Stringer, 空指针调用 String 时会 panic, 也不会使用 SetTimeFormat
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		elem := rv.Elem().Interface()
+		if s, ok := value.(fmt.Stringer); ok {
+			if _, ok = elem.(fmt.Stringer); !ok {
+				// String 方法定义在指针类型上
+				return []string{s.String()}
+			}
+		}
+		return client.formatQueryValues(elem)
+	}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case time.Time:
+		layout := client.timeFormat
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return []string{v.Format(layout)}
+	case bool:
+		if client.boolFormat == BoolOneZero {
+			if v {
+				return []string{"1"}
+			}
+			return []string{"0"}
+		}
+		return []string{strconv.FormatBool(v)}
+	case fmt.Stringer:
+		return []string{v.String()}
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []string{strconv.FormatInt(rv.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return []string{strconv.FormatUint(rv.Uint(), 10)}
+	case reflect.Float32:
+		return []string{strconv.FormatFloat(rv.Float(), 'f', -1, 32)}
+	case reflect.Float64:
+		return []string{strconv.FormatFloat(rv.Float(), 'f', -1, 64)}
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return []string{string(rv.Bytes())}
+		}
+		var values []string
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, client.formatQueryValues(rv.Index(i).Interface())...)
+		}
+		return values
+	case reflect.String:
+		return []string{rv.String()}
+	case reflect.Bool:
+		return client.formatQueryValues(rv.Bool())
+	}
+	return []string{fmt.Sprintf("%v", value)}
+}
+
+// writeQueryParam 方法用于把一个 Query 参数以 key=value 形式写入 buf, 多个值使用 & 连接, 转义规则与 url.Values 相同。
+// 开启 OmitEmptyParams 时值为空的参数会被忽略。
+func (client *Client) writeQueryParam(buf *bytes.Buffer, key string, value any) {
+	if client.omitEmptyParams && isEmptyParam(value) {
+		return
+	}
+	if s, ok := value.(string); ok {
+		// 字符串是最常见的情况, 直接写入避免创建切片
+		writeQueryPair(buf, key, s)
+		return
+	}
+	for _, v := range client.formatQueryValues(value) {
+		writeQueryPair(buf, key, v)
+	}
+}
+
+// writeQueryPair 方法用于把转义后的 key=value 写入 buf, buf 不为空时先写入 &。
+func writeQueryPair(buf *bytes.Buffer, key, value string) {
+	if buf.Len() > 0 {
+		buf.WriteByte('&')
+	}
+	buf.WriteString(url.QueryEscape(key))
+	buf.WriteByte('=')
+	buf.WriteString(url.QueryEscape(value))
+}
@@ -0,0 +1,34 @@
+package builder
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestFormatQueryValuesPointers(t *testing.T) {
+	client := NewClient()
+	client.SetTimeFormat("2006-01-02")
+	day := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	n := 0
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"nil time pointer", (*time.Time)(nil), ""},
+		{"time pointer", &day, "since=2024-01-02"},
+		{"nil int pointer", (*int)(nil), ""},
+		{"pointer to zero", &n, "since=0"},
+		{"pointer receiver stringer", big.NewInt(42), "since=42"},
+		{"slice of time pointers", []*time.Time{&day, nil}, "since=2024-01-02"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := client.R().SetQueryParam("since", tt.value).GetQueryParamsEncode()
+			if got != tt.want {
+				t.Errorf("GetQueryParamsEncode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
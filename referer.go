@@ -0,0 +1,51 @@
+package builder
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// EnableAutoReferer 方法用于开启自动 Referer。开启后请求会自动把 Referer 设置为同一主机上一次请求的 URL (跟随重定向后的地址),
+// 模拟浏览器在站内逐页浏览的行为; 请求已经设置了 Referer 时不做修改, 不同主机之间不会传递 Referer,
+// 也不会把 https 页面的地址作为 Referer 发送给 http 页面。
+func (client *Client) EnableAutoReferer() *Client {
+	client.autoReferer = true
+	return client
+}
+
+// SetReferer 方法用于设置当前请求的 Referer 头部。它接收一个 string 类型的参数，该参数表示来源页面的 URL,
+// 设置后不会被 EnableAutoReferer 覆盖。
+func (request *Request) SetReferer(referer string) *Request {
+	return request.SetHeader("Referer", referer)
+}
+
+// applyAutoReferer 方法用于在开启自动 Referer 时为没有 Referer 的请求设置同一主机上一次请求的 URL。
+func (request *Request) applyAutoReferer(req *http.Request) {
+	if !request.client.autoReferer || req.Header.Get("Referer") != "" {
+		return
+	}
+	value, ok := request.client.referers.Load(req.URL.Host)
+	if !ok {
+		return
+	}
+	previous := value.(*url.URL)
+	if previous.Scheme == "https" && req.URL.Scheme != "https" {
+		return
+	}
+	req.Header.Set("Referer", previous.String())
+}
+
+// recordReferer 方法用于在开启自动 Referer 时记录本次请求最终的 URL, 作为同一主机下一次请求的 Referer。
+func (request *Request) recordReferer(response *Response) {
+	if !request.client.autoReferer {
+		return
+	}
+	u := request.NewRequest.URL
+	if response.ResponseRaw != nil && response.ResponseRaw.Request != nil {
+		u = response.ResponseRaw.Request.URL
+	}
+	// 与浏览器相同, Referer 不包含用户信息和片段
+	referer := *u
+	referer.User, referer.Fragment, referer.RawFragment = nil, "", ""
+	request.client.referers.Store(referer.Host, &referer)
+}
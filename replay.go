@@ -0,0 +1,23 @@
+package builder
+
+// Replay 方法用于重新发送产生该响应的请求。它接收任意个 func(*Request) 类型的参数，用于在发送前修改复制得到的请求,
+// 例如重新认证后更新 Authorization 请求头。原请求的 Method、路径、Header、Query、Cookies 和 Body 等配置都会被保留,
+// 因此失败或者过期的调用无需重新构造整个调用链即可再次执行。
+func (response *Response) Replay(mutators ...func(*Request)) (*Response, error) {
+	return response.RequestSource.Replay(mutators...)
+}
+
+// Replay 方法用于复制已发送的请求并再次发送, 复制得到的请求会依次经过 mutators 修改。
+func (request *Request) Replay(mutators ...func(*Request)) (*Response, error) {
+	replay := request.Clone()
+	for _, mutate := range mutators {
+		if err := safeCall("Replay", func() error {
+			mutate(replay)
+			return nil
+		}); err != nil {
+			request.LogError(err, request.path, "replay.go", "Replay")
+			return nil, err
+		}
+	}
+	return replay.Send()
+}
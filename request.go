@@ -1,14 +1,14 @@
 package builder
 
 import (
-	"bytes"
-	"fmt"
 	"golang.org/x/net/context"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 type Request struct {
@@ -16,20 +16,128 @@ type Request struct {
 	ctx        context.Context
 	Method     string // HTTP 请求的 Method 部分
 	Body       any
-	bodyBuf    *bytes.Buffer
-	bodyBytes  []byte
+	bodyBytes  []byte  // bodyBytes 用于存储生成的请求体, 由当前请求独占, 发送之后不会被修改或复用
 	client     *Client // 指向 Client 的指针
 	Header     sync.Map
 	QueryParam sync.Map
 	Cookies    []*http.Cookie
 	NewRequest *http.Request
+
+	path               string                         // path 用于存储通过 Prepare 准备或者最近一次发送的 HTTP 请求路径
+	priority           Priority                       // priority 用于存储请求的调度优先级
+	ignoreRobots       bool                           // ignoreRobots 用于标记当前请求是否跳过 robots.txt 检查
+	retryCount         int                            // retryCount 用于存储当前请求的重试次数, 0 表示使用客户端的设置
+	retryPolicy        RetryPolicy                    // retryPolicy 用于存储当前请求的重试策略, nil 表示使用客户端的设置
+	responseDecoders   []ResponseDecoder              // responseDecoders 用于存储当前请求的响应解码管道
+	overrideDecoders   bool                           // overrideDecoders 用于标记是否使用当前请求的解码管道
+	responseSchema     string                         // responseSchema 用于存储响应结果需要满足的 JSON Schema
+	requestID          string                         // requestID 用于存储请求 ID
+	checksum           *checksum                      // checksum 用于存储响应体期望的校验和
+	cacheTTL           time.Duration                  // cacheTTL 用于存储 SetCacheTTL 设置的内存缓存有效期
+	bodyFile           string                         // bodyFile 用于存储 SetBodyFile 设置的请求体文件路径
+	bodyKind           bodyKind                       // bodyKind 用于存储通过 SetBodyJson 等方法指定的请求体编码方式
+	contentLength      *int64                         // contentLength 用于存储 SetContentLength 设置的 Content-Length
+	forceChunked       bool                           // forceChunked 用于标记是否强制使用分块传输
+	informationalFuncs []InformationalFunc            // informationalFuncs 用于存储当前请求处理 1xx 信息响应的回调函数
+	timeout            time.Duration                  // timeout 用于存储当前请求的整体超时时间, 0 表示使用客户端的设置
+	bandwidthLimit     *int64                         // bandwidthLimit 用于存储当前请求的带宽限制, nil 表示使用客户端的设置
+	host               string                         // host 用于存储 WithHost 设置的目标主机
+	jsonMarshal        func(v any) ([]byte, error)    // jsonMarshal 用于存储 SetJSONMarshaler 设置的 JSON 编码函数
+	jsonUnmarshal      func(data []byte, v any) error // jsonUnmarshal 用于存储 SetJSONUnmarshaler 设置的 JSON 解码函数
+	fingerprint        *http.Transport                // fingerprint 用于存储当前请求选择的指纹对应的 Transport
+	trace              bool                           // trace 用于标记是否为当前请求开启耗时追踪
+	traceInfo          TraceInfo                      // traceInfo 用于存储最后一次尝试的耗时信息
+	skipEnvelope       bool                           // skipEnvelope 用于标记是否跳过响应外层检查
+	metricsHook        MetricsHook                    // metricsHook 用于接收当前请求的尝试指标, 在客户端的 MetricsHook 之后调用
+	doNotParse         bool                           // doNotParse 用于标记是否以流的方式读取响应体, 见 SetDoNotParseResponse
 }
 
+// SetBody 方法用于设置 HTTP 请求的 Body 部分, 编码方式根据 Body 的类型和 Content-Type 推断。
+// 需要明确编码方式时可以使用 SetBodyJson、SetBodyXml、SetBodyFormString 或 SetBodyRaw。
 func (request *Request) SetBody(v interface{}) *Request {
-	request.Body = v
+	request.Body, request.bodyKind = v, bodyAuto
+	return request
+}
+
+// Clone 方法用于复制请求的配置 (Method、路径、Body、Header、Query 和 Cookies 等),
+// 复制得到的请求与原请求互不影响, 且不包含原请求发送过程中产生的状态。
+// 配合 WithHost 可以把配置好的请求发送到另一个镜像进行对比或者故障切换。
+func (request *Request) Clone() *Request {
+	newRequest := &Request{
+		client:       request.client,
+		URL:          &url.URL{},
+		ctx:          request.ctx,
+		Method:       request.Method,
+		path:         request.path,
+		Body:         request.Body,
+		priority:     request.priority,
+		retryCount:   request.retryCount,
+		retryPolicy:  request.retryPolicy,
+		ignoreRobots: request.ignoreRobots,
+
+		responseDecoders: request.responseDecoders,
+		overrideDecoders: request.overrideDecoders,
+		responseSchema:   request.responseSchema,
+		checksum:         request.checksum,
+		cacheTTL:         request.cacheTTL,
+		bodyFile:         request.bodyFile,
+		bodyKind:         request.bodyKind,
+		contentLength:    request.contentLength,
+		forceChunked:     request.forceChunked,
+
+		informationalFuncs: request.informationalFuncs,
+		timeout:            request.timeout,
+		bandwidthLimit:     request.bandwidthLimit,
+		host:               request.host,
+		jsonMarshal:        request.jsonMarshal,
+		jsonUnmarshal:      request.jsonUnmarshal,
+		trace:              request.trace,
+		skipEnvelope:       request.skipEnvelope,
+		metricsHook:        request.metricsHook,
+		doNotParse:         request.doNotParse,
+	}
+	request.Header.Range(func(key, value any) bool {
+		// 自动生成的请求 ID 不会被复制, 复制得到的请求发送时会生成新的请求 ID
+		if request.requestID != "" && key == request.client.requestIDHeader && value == request.requestID {
+			return true
+		}
+		newRequest.Header.Store(key, value)
+		return true
+	})
+	request.QueryParam.Range(func(key, value any) bool {
+		newRequest.QueryParam.Store(key, value)
+		return true
+	})
+	newRequest.Cookies = append(newRequest.Cookies, request.Cookies...)
+	return newRequest
+}
+
+// SetContext 方法用于设置 HTTP 请求的 Context。它接收一个 context.Context 类型的参数，可用于取消请求或设置截止时间。
+func (request *Request) SetContext(ctx context.Context) *Request {
+	request.ctx = ctx
 	return request
 }
 
+// SetTimeout 方法用于设置当前请求的整体超时时间, 覆盖客户端的设置。它接收一个 time.Duration 类型的参数，
+// 该参数表示从建立连接到读取完响应体的最长时间。
+func (request *Request) SetTimeout(timeout time.Duration) *Request {
+	request.timeout = timeout
+	return request
+}
+
+// SetDoNotParseResponse 方法用于以流的方式读取当前请求的响应体, 适合非常大的响应。设置后响应不经过缓存,
+// 也不运行解码管道、Schema、接口规范和响应外层检查, 响应体留在网络连接上, 由 JsonStream、SaveFile 直接读取,
+// 或者通过 ResponseRaw.Body 自行读取; 只需要状态码或者响应头时调用 Response.Close 释放连接。
+func (request *Request) SetDoNotParseResponse() *Request {
+	request.doNotParse = true
+	return request
+}
+
+// GetContext 方法用于获取 HTTP 请求的 Context。
+func (request *Request) GetContext() context.Context {
+	return request.ctx
+}
+
 // SetHeader 方法用于设置 HTTP 请求的 Header 部分。它接收两个 string 类型的参数，
 func (request *Request) SetHeader(key, value string) *Request {
 	request.Header.Store(key, value)
@@ -57,6 +165,13 @@ func (request *Request) SetCookie(cookie *http.Cookie) *Request {
 	return request
 }
 
+// ClearCookies 方法用于清除当前请求的所有 Cookie, 包括从客户端继承的 Cookie, 不影响客户端的设置。
+// CookieJar 中保存的由服务器设置的 Cookie 仍会被发送。
+func (request *Request) ClearCookies() *Request {
+	request.Cookies = nil
+	return request
+}
+
 // SetQueryParams 方法用于设置 HTTP 请求的 Query 部分。它接收一个 map[string]interface{} 类型的参数，
 func (request *Request) SetQueryParams(query map[string]any) *Request {
 	for key, value := range query {
@@ -65,7 +180,8 @@ func (request *Request) SetQueryParams(query map[string]any) *Request {
 	return request
 }
 
-// SetQueryParam 方法用于设置 HTTP 请求的 Query 部分。它接收两个 string 类型的参数，
+// SetQueryParam 方法用于设置 HTTP 请求的 Query 部分。它接收一个 string 类型的参数名和一个 any 类型的参数值,
+// 参数值可以是字符串、布尔值、数字、time.Time 或者它们的切片, 格式由 Client.SetTimeFormat 和 SetBoolFormat 控制。
 func (request *Request) SetQueryParam(key string, value any) *Request {
 	request.QueryParam.Store(key, value)
 	return request
@@ -78,7 +194,17 @@ func (request *Request) SetQueryString(query string) *Request {
 			request.SetQueryParam(key, value[0])
 		}
 	} else {
-		request.client.LogError(err, query, "request.go", "SetQueryString")
+		request.LogError(err, query, "request.go", "SetQueryString")
+	}
+	return request
+}
+
+// EnableIdempotencyKey 方法用于为 HTTP 请求生成一个随机的 Idempotency-Key 请求头。
+// 同一个请求的所有重试都会携带相同的 Idempotency-Key, 避免重试的 POST 请求在服务端重复创建资源。
+// 如果已经设置了 Idempotency-Key 请求头, 则保留原有的值。
+func (request *Request) EnableIdempotencyKey() *Request {
+	if _, ok := request.Header.Load(idempotencyKeyHeader); !ok {
+		request.SetHeader(idempotencyKeyHeader, newUUID())
 	}
 	return request
 }
@@ -88,16 +214,27 @@ func (request *Request) SetHeaderContentType(contentType string) *Request {
 	return request
 }
 
-// GetQueryParamsEncode 方法用于获取 HTTP 请求的 Query 部分的 URL 编码字符串。
+// GetQueryParamsEncode 方法用于获取 HTTP 请求的 Query 部分的 URL 编码字符串, 参数与 url.Values 一样按名称排序。
 func (request *Request) GetQueryParamsEncode() string {
-	var parts []string
-	request.QueryParam.Range(func(key any, value any) bool {
-		k, _ := key.(string)
-		v, _ := value.(string)
-		parts = append(parts, fmt.Sprintf("%s=%s", url.QueryEscape(k), url.QueryEscape(v)))
+	var keys []string
+	request.QueryParam.Range(func(key any, _ any) bool {
+		if k, ok := key.(string); ok {
+			keys = append(keys, k)
+		}
 		return true
 	})
-	return strings.Join(parts, "&")
+	if len(keys) == 0 {
+		return ""
+	}
+	sort.Strings(keys)
+	buf := acquireBuffer()
+	defer releaseBuffer(buf)
+	for _, key := range keys {
+		if value, ok := request.QueryParam.Load(key); ok {
+			request.client.writeQueryParam(buf, key, value)
+		}
+	}
+	return buf.String()
 }
 
 // GetQueryParamsNopCloser 方法用于获取 HTTP 请求的 Query 部分的 ReadCloser。
@@ -148,26 +285,67 @@ func (request *Request) GetHeaderContentType() string {
 	return request.GetRequestHeader().Get("Content-Type")
 }
 
+// SetJSONMarshaler 方法用于设置当前请求编码 JSON 时使用的函数, 覆盖 Client 的 JSONMarshal, 不影响其他请求。
+// 它接收一个 func(v any) ([]byte, error) 类型的参数, 例如 jsoniter 或者关闭 HTML 转义的编码函数。
+func (request *Request) SetJSONMarshaler(marshal func(v any) ([]byte, error)) *Request {
+	request.jsonMarshal = marshal
+	return request
+}
+
+// SetJSONUnmarshaler 方法用于设置当前请求解码 JSON 响应时使用的函数, 覆盖 Client 的 JSONUnmarshal, 不影响其他请求。
+// 它接收一个 func(data []byte, v any) error 类型的参数。
+func (request *Request) SetJSONUnmarshaler(unmarshal func(data []byte, v any) error) *Request {
+	request.jsonUnmarshal = unmarshal
+	return request
+}
+
+// jsonMarshaler 方法用于获取当前请求编码 JSON 时使用的函数, 未设置时使用 Client 的 JSONMarshal。
+func (request *Request) jsonMarshaler() func(v any) ([]byte, error) {
+	if request.jsonMarshal != nil {
+		return request.jsonMarshal
+	}
+	return request.client.JSONMarshal
+}
+
+// jsonUnmarshaler 方法用于获取当前请求解码 JSON 时使用的函数, 未设置时使用 Client 的 JSONUnmarshal。
+func (request *Request) jsonUnmarshaler() func(data []byte, v any) error {
+	if request.jsonUnmarshal != nil {
+		return request.jsonUnmarshal
+	}
+	return request.client.JSONUnmarshal
+}
+
 func (request *Request) jsonToMap(jsonStr string) map[string]any {
 	var result map[string]any
-	err := request.client.JSONUnmarshal([]byte(jsonStr), &result)
+	err := safeCall("JSONUnmarshal", func() error {
+		return request.jsonUnmarshaler()([]byte(jsonStr), &result)
+	})
 	if err != nil {
-		request.client.LogError(err, jsonStr, "request.go", "jsonToMap")
+		request.LogError(err, jsonStr, "request.go", "jsonToMap")
 	}
 	return result
 }
-func (request *Request) mapToJson(params any) string {
-	jsonStr, err := request.client.JSONMarshal(params)
-	if err != nil {
-		request.client.LogError(err, params, "request.go", "mapToJson")
-	}
-	return string(jsonStr)
+
+// WithHost 方法用于把请求发送到另一个主机。它接收一个 string 类型的参数，该参数表示主机名 (例如 mirror.example.com:8080),
+// 或者带有协议的地址 (例如 https://mirror.example.com)。请求的路径和 Query 保持不变, 只替换 URL 的协议和主机部分。
+func (request *Request) WithHost(host string) *Request {
+	request.host = strings.TrimSuffix(host, "/")
+	return request
 }
-func (request *Request) structToJson(params any) string {
-	jsonStr, err := request.client.JSONMarshal(params)
+
+// applyHost 方法用于按 WithHost 的设置替换 URL 的协议和主机部分。
+func (request *Request) applyHost(u *url.URL) error {
+	if request.host == "" {
+		return nil
+	}
+	if !strings.Contains(request.host, "://") {
+		u.Host = request.host
+		return nil
+	}
+	target, err := url.Parse(request.host)
 	if err != nil {
-		request.client.LogError(err, params, "request.go", "structToJson")
+		return err
 	}
-	return string(jsonStr)
-
+	u.Scheme, u.Host = target.Scheme, target.Host
+	return nil
 }
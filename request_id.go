@@ -0,0 +1,75 @@
+package builder
+
+import "github.com/sirupsen/logrus"
+
+// defaultRequestIDHeader 为默认的请求 ID 头部名称
+const defaultRequestIDHeader = "X-Request-ID"
+
+// EnableRequestID 方法用于为每个请求生成唯一的请求 ID。它接收一个 string 类型的参数表示请求头名称 (为空时使用 X-Request-ID),
+// 以及一个生成请求 ID 的函数 (为 nil 时生成 UUID)。请求 ID 会写入请求头和该请求的所有日志, 并可以通过 Response.RequestID 获取。
+// 已经手动设置了该请求头的请求沿用原有的值。
+func (client *Client) EnableRequestID(headerName string, generator func() string) *Client {
+	if headerName == "" {
+		headerName = defaultRequestIDHeader
+	}
+	if generator == nil {
+		generator = newUUID
+	}
+	client.requestIDHeader = headerName
+	client.requestIDGenerator = generator
+	return client
+}
+
+// stampRequestID 方法用于在开启请求 ID 时为请求设置请求 ID。
+func (request *Request) stampRequestID() {
+	client := request.client
+	if client.requestIDHeader == "" {
+		return
+	}
+	if value, ok := request.Header.Load(client.requestIDHeader); ok {
+		request.requestID, _ = value.(string)
+		return
+	}
+	_ = safeCall("RequestIDGenerator", func() error {
+		request.requestID = client.requestIDGenerator()
+		return nil
+	})
+	if request.requestID != "" {
+		request.SetHeader(client.requestIDHeader, request.requestID)
+	}
+}
+
+// RequestID 方法用于获取请求的请求 ID, 未开启请求 ID 时返回空字符串。
+func (request *Request) RequestID() string {
+	return request.requestID
+}
+
+// RequestID 方法用于获取响应对应请求的请求 ID, 未开启请求 ID 时返回空字符串。
+func (response *Response) RequestID() string {
+	return response.RequestSource.requestID
+}
+
+// logFields 方法用于生成请求日志的公共字段。
+func (request *Request) logFields(fields logrus.Fields) logrus.Fields {
+	if request.requestID != "" {
+		fields["request_id"] = request.requestID
+	}
+	return fields
+}
+
+// LogError 方法用于记录当前请求的错误日志, 开启请求 ID 时日志会携带 request_id 字段。
+func (request *Request) LogError(err any, query any, fileName, funcName string) {
+	request.client.log.WithFields(request.logFields(logrus.Fields{
+		"query": query,
+		"func":  funcName,
+		"file":  fileName,
+	})).Error(err)
+}
+
+// LogInfo 方法用于记录当前请求的信息日志, 开启请求 ID 时日志会携带 request_id 字段。
+func (request *Request) LogInfo(err any, query any, funcName string) {
+	request.client.log.WithFields(request.logFields(logrus.Fields{
+		"query": query,
+		"func":  funcName,
+	})).Info(err)
+}
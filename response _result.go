@@ -1,17 +1,23 @@
 package builder
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/PuerkitoBio/goquery"
+	"github.com/catnovelapi/builder/pkg/assert"
+	"github.com/catnovelapi/builder/pkg/files"
 	"github.com/tidwall/gjson"
 	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
 	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
 	"golang.org/x/text/transform"
 	"io"
 	"net/http"
 	"reflect"
 	"strings"
+	"unicode/utf8"
 )
 
 // GetStatusCode 方法用于获取 HTTP 响应的状态码。
@@ -31,41 +37,155 @@ func (response *Response) GetProto() string {
 	return response.ResponseRaw.Proto
 }
 
-// GetByte 方法用于获取 HTTP 响应的字节结果。
+// GetByte 方法用于获取 HTTP 响应的字节结果。返回的切片与 Result 共享内存, 不能被修改。
 func (response *Response) GetByte() []byte {
-	// 如果响应体为空，直接返回 nil
-	if response.ResponseRaw.Body == nil {
-		return nil
-	}
-	// 如果响应体不为空，且 Result 不为空，则直接返回 Result
+	response.loadResult()
+	// 如果 Result 不为空，则直接返回 Result, Result 与已读取的响应体相同时直接返回响应体, 避免复制
 	if response.Result != "" {
+		if response.bodyRead && response.Result == string(response.body) {
+			return response.body
+		}
 		return []byte(response.Result)
 	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			response.RequestSource.client.LogError(err, "", "response.go", "GetByte")
-		}
-	}(response.ResponseRaw.Body)
-	body, ok := io.ReadAll(response.ResponseRaw.Body)
-	if ok != nil {
+	body, err := response.readBody()
+	if err != nil {
+		response.RequestSource.LogError(err, "", "response.go", "GetByte")
 		return nil
 	}
 	return body
 }
 
+// loadResult 方法用于在第一次访问响应体时生成 Result。需要在返回之前处理响应体的请求 (见 needsResult) 已经生成了 Result,
+// 其他请求在这里读取响应体, Result 直接引用响应体的内存, 不会复制。
+func (response *Response) loadResult() {
+	response.resultOnce.Do(func() {
+		if response.Result != "" {
+			return
+		}
+		body, err := response.readBody()
+		if err != nil {
+			response.RequestSource.LogError(err, "", "response.go", "readBody")
+		}
+		response.Result = bytesToString(body)
+	})
+}
+
+// readBody 方法用于读取并关闭原始响应体, 只会读取一次, 重复调用或者并发调用时返回第一次读取的结果。
+func (response *Response) readBody() ([]byte, error) {
+	response.bodyOnce.Do(func() {
+		// 响应体已经被设置 (例如来自缓存) 或者为空时不需要读取
+		if response.bodyRead || response.ResponseRaw.Body == nil {
+			response.bodyRead = true
+			return
+		}
+		response.bodyRead = true
+		defer func(Body io.ReadCloser) {
+			err := Body.Close()
+			if err != nil {
+				response.RequestSource.LogError(err, "", "response.go", "readBody")
+			}
+		}(response.ResponseRaw.Body)
+		body, err := io.ReadAll(response.ResponseRaw.Body)
+		if err != nil {
+			response.bodyErr = err
+			return
+		}
+		response.body = body
+		response.RequestSource.client.stats.bytesReceived.Add(int64(len(body)))
+	})
+	return response.body, response.bodyErr
+}
+
+// takeBody 方法用于获取以流的方式读取原始响应体的权利, 原始响应体还没有被读取时返回 true, 之后 readBody 返回空的响应体。
+func (response *Response) takeBody() bool {
+	taken := false
+	response.bodyOnce.Do(func() {
+		taken = !response.bodyRead && response.ResponseRaw.Body != nil
+		response.bodyRead = true
+	})
+	return taken
+}
+
+// Close 方法用于丢弃并关闭还没有读取的原始响应体, 只需要状态码或者响应头时调用可以立即释放连接, 之后访问响应体将返回空结果。
+// 响应体已经被读取时不做任何操作。没有调用 Close 也没有访问响应体的响应在被垃圾回收时关闭原始响应体。
+func (response *Response) Close() error {
+	if response.takeBody() {
+		discardResponse(response.ResponseRaw)
+	}
+	return nil
+}
+
+// bodyStream 方法用于获取读取响应体的 reader。请求通过 SetDoNotParseResponse 发送且响应体还没有被读取时返回读取原始响应体的 reader,
+// 否则返回读取已保存的响应体的 reader。
+// 返回的函数用于关闭原始响应体并统计读取的字节数。
+func (response *Response) bodyStream() (io.Reader, func()) {
+	if !response.RequestSource.doNotParse || !response.takeBody() {
+		return bytes.NewReader(response.GetByte()), func() {}
+	}
+	body := &countingReader{reader: response.ResponseRaw.Body}
+	return body, func() {
+		if err := response.ResponseRaw.Body.Close(); err != nil {
+			response.RequestSource.LogError(err, "", "response.go", "bodyStream")
+		}
+		response.RequestSource.client.stats.bytesReceived.Add(body.n)
+	}
+}
+
 // String 方法用于获取 HTTP 响应的字符串结果。
 func (response *Response) String() string {
+	response.loadResult()
+	if response.Result != "" {
+		return response.Result
+	}
 	return string(response.GetByte())
 }
 
 // Json 方法用于将 HTTP 响应的字符串结果解析为 JSON 对象。它接收一个 interface{} 类型的参数，该参数必须是指针类型。
+// 请求通过 SetJSONUnmarshaler 设置了解码函数时使用该函数解码。
 func (response *Response) Json(v any) error {
 	valueType := reflect.TypeOf(v)
 	if valueType.Kind() != reflect.Ptr {
 		return fmt.Errorf("DecodeJson:传入的对象必须是指针类型")
 	}
-	return json.NewDecoder(strings.NewReader(response.String())).Decode(v)
+	if unmarshal := response.RequestSource.jsonUnmarshal; unmarshal != nil {
+		return safeCall("JSONUnmarshal", func() error {
+			return unmarshal(response.GetByte(), v)
+		})
+	}
+	return json.NewDecoder(bytes.NewReader(response.GetByte())).Decode(v)
+}
+
+// JsonStream 方法用于把 HTTP 响应体以流的方式解析为 JSON 对象, 适合非常大的响应。它接收一个 interface{} 类型的参数，
+// 该参数必须是指针类型。请求通过 SetDoNotParseResponse 发送时直接从网络读取并解码, 不会在内存中保存完整的响应体,
+// 之后 String、GetByte 等方法将返回空结果; 其他请求等同于 Json。
+func (response *Response) JsonStream(v any) error {
+	if reflect.TypeOf(v).Kind() != reflect.Ptr {
+		return fmt.Errorf("JsonStream:传入的对象必须是指针类型")
+	}
+	if !response.RequestSource.doNotParse || !response.takeBody() {
+		return response.Json(v)
+	}
+	defer func(Body io.ReadCloser) {
+		if err := Body.Close(); err != nil {
+			response.RequestSource.LogError(err, "", "response.go", "JsonStream")
+		}
+	}(response.ResponseRaw.Body)
+	return json.NewDecoder(response.ResponseRaw.Body).Decode(v)
+}
+
+// JsonSelect 方法用于把 HTTP 响应中 gjson 路径选中的部分解析为 JSON 对象。它接收一个 string 类型的 gjson 路径
+// (例如 data.chapter_list) 和一个 interface{} 类型的参数，该参数必须是指针类型。
+func (response *Response) JsonSelect(path string, v any) error {
+	if reflect.TypeOf(v).Kind() != reflect.Ptr {
+		return fmt.Errorf("JsonSelect:传入的对象必须是指针类型")
+	}
+	result := response.GjsonGet(path)
+	if !result.Exists() {
+		return fmt.Errorf("JsonSelect:路径 %s 不存在", path)
+	}
+	return safeCall("JSONUnmarshal", func() error {
+		return response.RequestSource.jsonUnmarshaler()([]byte(result.Raw), v)
+	})
 }
 
 // StringGbk 方法用于将 HTTP 响应的字符串结果解码为 GBK 编码的字符串。
@@ -74,7 +194,7 @@ func (response *Response) StringGbk() string {
 	utf8BodyReader := transform.NewReader(strings.NewReader(response.String()), decoder)
 	utf8Body, err := io.ReadAll(utf8BodyReader)
 	if err != nil {
-		response.RequestSource.client.LogError(err, "", "response.go", "StringGbk")
+		response.RequestSource.LogError(err, "", "response.go", "StringGbk")
 		return ""
 	}
 	return string(utf8Body)
@@ -84,7 +204,7 @@ func (response *Response) StringGbk() string {
 func (response *Response) Html() *goquery.Document {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(response.String()))
 	if err != nil {
-		response.RequestSource.client.LogError(err, "", "response.go", "Html")
+		response.RequestSource.LogError(err, "", "response.go", "Html")
 		return nil
 	}
 	return doc
@@ -99,16 +219,70 @@ func (response *Response) HtmlGbk() *goquery.Document {
 	}
 	doc := goquery.NewDocumentFromNode(docs)
 	if err != nil {
-		response.RequestSource.client.LogError(err, "", "response.go", "HtmlGbk")
+		response.RequestSource.LogError(err, "", "response.go", "HtmlGbk")
 		//fmt.Println("解析HTML失败:", err)
 		return nil
 	}
 	return doc
 }
 
-// Gjson 方法用于将 HTTP 响应的字符串结果解析为 gjson.Result 对象。
+// SaveFile 方法用于把 HTTP 响应的字节结果保存到文件。它接收一个 string 类型的参数，该参数表示文件路径。
+// 文件以原子方式写入, 写入失败或者与 Request.SetExpectedChecksum 设置的校验和不一致时不会留下文件。
+// 请求通过 SetDoNotParseResponse 发送时直接从网络读取, 在写入的同时计算校验和, 不会在内存中保存完整的响应体,
+// 之后 String、GetByte 等方法将返回空结果。
+func (response *Response) SaveFile(path string) error {
+	body, closeBody := response.bodyStream()
+	defer closeBody()
+	reader := response.RequestSource.newChecksumReader(body)
+	if err := files.WriteAtomic(path, reader); err != nil {
+		response.RequestSource.LogError(err, path, "response.go", "SaveFile")
+		return err
+	}
+	return nil
+}
+
+// SaveFileUTF8 方法用于把 HTTP 响应的结果转换为 UTF-8 编码后保存到文件, 需要保留原始字节时请使用 SaveFile。
+// 它接收一个 string 类型的参数表示文件路径, 以及一个 bool 类型的参数表示是否在文件开头写入 UTF-8 BOM。
+// 原始编码根据响应体开头的 BOM、Content-Type 和 HTML 的 <meta charset> 检测, 原始内容中的 BOM 会被移除。
+func (response *Response) SaveFileUTF8(path string, bom bool) error {
+	body := response.GetByte()
+	encoding, name, _ := charset.DetermineEncoding(body, response.GetHeader().Get("Content-Type"))
+	if name == "utf-8" && !utf8.Valid(body) {
+		// 服务器声明的编码与内容不符时忽略 Content-Type, 根据 <meta charset> 和内容重新检测
+		encoding, _, _ = charset.DetermineEncoding(body, "")
+	}
+	reader := response.RequestSource.newChecksumReader(bytes.NewReader(body))
+	reader = transform.NewReader(reader, unicode.BOMOverride(encoding.NewDecoder()))
+	if bom {
+		reader = io.MultiReader(bytes.NewReader([]byte{0xEF, 0xBB, 0xBF}), reader)
+	}
+	if err := files.WriteAtomic(path, reader); err != nil {
+		response.RequestSource.LogError(err, path, "response.go", "SaveFileUTF8")
+		return err
+	}
+	return nil
+}
+
+// Gjson 方法用于将 HTTP 响应的字符串结果解析为 gjson.Result 对象。解析结果会被缓存,
+// 重复调用或者使用 GjsonGet 查询时不会重新解析, Result 被修改后会重新解析。
 func (response *Response) Gjson() gjson.Result {
-	return gjson.Parse(response.String())
+	response.loadResult()
+	response.gjsonMu.Lock()
+	defer response.gjsonMu.Unlock()
+	// Result 未被修改时两个字符串共享底层数据, 比较只需要常数时间
+	if response.gjsonParsed && response.gjsonSource == response.Result {
+		return response.gjsonResult
+	}
+	// Result 引用响应体的内存 (或者解码后的字符串), 直接解析不需要复制, 几 MB 以上的 JSON 响应也只解析一次
+	response.gjsonResult = gjson.Parse(response.Result)
+	response.gjsonSource, response.gjsonParsed = response.Result, true
+	return response.gjsonResult
+}
+
+// GjsonGet 方法用于按 gjson 路径查询 HTTP 响应的 JSON 结果。它接收一个 string 类型的参数，该参数表示 gjson 路径,
+// 例如 data.book_info.book_name。
+func (response *Response) GjsonGet(path string) gjson.Result {
+	return response.Gjson().Get(path)
 }
 
 // GetHeader 方法用于获取 HTTP 响应的 Header 部分。
@@ -120,6 +294,33 @@ func (response *Response) GetHeader() http.Header {
 func (response *Response) GetCookies() []*http.Cookie {
 	return response.ResponseRaw.Cookies()
 }
+
+// GetCookie 方法用于按名称获取 HTTP 响应设置的 Cookie。它接收一个 string 类型的参数，该参数表示 Cookie 的名称,
+// 同名的 Cookie 设置了多次时返回最后一个, 不存在时返回 nil。
+func (response *Response) GetCookie(name string) *http.Cookie {
+	var found *http.Cookie
+	for _, cookie := range response.GetCookies() {
+		if cookie.Name == name {
+			found = cookie
+		}
+	}
+	return found
+}
+
+// GetCookieValue 方法用于按名称获取 HTTP 响应设置的 Cookie 的值, 不存在时返回空字符串。
+func (response *Response) GetCookieValue(name string) string {
+	if cookie := response.GetCookie(name); cookie != nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// GetCookieString 方法用于获取 HTTP 响应的第一个 Set-Cookie 头部, 获取所有 Cookie 请使用 GetCookies 或 GetCookie。
 func (response *Response) GetCookieString() string {
 	return response.ResponseRaw.Header.Get("Set-Cookie")
 }
+
+// Expect 方法用于在测试中对 HTTP 响应进行链式断言, 例如 resp.Expect(t).Status(200).JsonPath("data.id", 42)。
+func (response *Response) Expect(t assert.TestingT) *assert.Expectation {
+	return assert.That(t, response)
+}
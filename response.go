@@ -2,12 +2,16 @@ package builder
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"github.com/tidwall/gjson"
+	"io"
 	"net/http"
 	"net/url"
-	"reflect"
+	"runtime"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -37,13 +41,26 @@ const (
 	jsonContentType = "application/json"
 
 	formContentType = "application/x-www-form-urlencoded"
+
+	idempotencyKeyHeader = "Idempotency-Key"
 )
 
 type Response struct {
 	Request       *http.Request
-	Result        string         // 响应体字符串结果
+	Result        string         // 响应体字符串结果, 第一次通过 String、GetByte、Json、Gjson 等方法访问响应体时生成
 	ResponseRaw   *http.Response // 指向 http.Response 的指针
 	RequestSource *Request       // 指向 Request 的指针
+	body          []byte         // body 用于存储已读取的原始响应体
+	bodyRead      bool           // bodyRead 用于标记原始响应体是否已被读取
+	bodyOnce      sync.Once      // bodyOnce 用于保证原始响应体只被读取一次
+	bodyErr       error          // bodyErr 用于存储读取原始响应体时的错误
+	resultOnce    sync.Once      // resultOnce 用于保证 Result 只生成一次
+	fromCache     bool           // fromCache 用于标记响应是否来自缓存
+	gjsonMu       sync.Mutex     // gjsonMu 用于保护 gjson 解析结果的缓存
+	gjsonResult   gjson.Result   // gjsonResult 用于缓存 Gjson 的解析结果
+	gjsonSource   string         // gjsonSource 用于存储解析时的 Result, 用于判断缓存是否失效
+	gjsonParsed   bool           // gjsonParsed 用于标记是否已经缓存了解析结果
+	retryErr      *Error         // retryErr 用于记录重试次数用完时最后一次响应仍然满足重试条件的错误, 传给 OnError 的回调函数
 }
 
 // newParseUrl 方法用于解析 URL。它接收一个 string 类型的参数，该参数表示 HTTP 请求的 Path 部分。
@@ -53,22 +70,29 @@ func (request *Request) newParseUrl(path string) (*url.URL, error) {
 
 	// Return an error if both the base URL and the path are empty
 	if baseURL == "" && path == "" {
-		err = fmt.Errorf("request Error: baseUrl and path are empty")
-		request.client.LogError(err, path, "response.go", "newParseUrl")
+		err = request.newError(KindInvalidRequest, 0, errors.New("baseUrl and path are empty"))
+		request.LogError(err, path, "response.go", "newParseUrl")
 		return nil, err
 	}
 
-	// Ensure path is properly prefixed with a "/"
-	if path != "" && !strings.HasPrefix(path, "/") {
-		path = "/" + path
-	}
-
 	fullURL := baseURL + path
-	request.URL, err = url.Parse(fullURL)
+	if isAbsoluteURL(path) {
+		// An absolute URL (e.g. a pagination link) is used as-is
+		fullURL = path
+	} else if path != "" && !strings.HasPrefix(path, "/") {
+		// Ensure path is properly prefixed with a "/"
+		fullURL = baseURL + "/" + path
+	}
+	u, err := url.Parse(fullURL)
+	if err == nil {
+		err = request.applyHost(u)
+	}
 	if err != nil {
-		request.client.LogError(err, fullURL, "response.go", "newParseUrl")
+		err = request.newError(KindInvalidRequest, 0, err)
+		request.LogError(err, fullURL, "response.go", "newParseUrl")
 		return nil, err
 	}
+	request.URL = u
 	// Set URL and append query parameters
 	return request.URL, nil
 }
@@ -82,117 +106,270 @@ func (request *Request) newRequestWithContext() (*http.Request, error) {
 	}()
 	newParamsEncode := request.GetQueryParamsEncode()
 	if newParamsEncode != "" {
-		if request.Method == MethodGet {
+		if request.Method == MethodGet || request.bodyFile != "" {
 			if request.URL.RawQuery != "" {
 				request.URL.RawQuery += "&"
 			}
 			request.URL.RawQuery += newParamsEncode
 		} else {
-			request.bodyBuf.WriteString(newParamsEncode)
+			if len(request.bodyBytes) > 0 && request.isFormBody() {
+				// 与 SetBodyFormString 设置的表单合并
+				request.bodyBytes = append(request.bodyBytes, '&')
+			}
+			request.bodyBytes = append(request.bodyBytes, newParamsEncode...)
 		}
 	}
 
-	req, err := http.NewRequestWithContext(request.ctx, request.Method, request.URL.String(), request.bodyBuf)
+	var bodySize int64
+	if request.bodyFile != "" {
+		var err error
+		if bodySize, err = request.statBodyFile(); err != nil {
+			err = request.newError(KindInvalidRequest, 0, err)
+			request.LogError(err, request.bodyFile, "response.go", "statBodyFile")
+			return nil, err
+		}
+	} else if len(request.client.bodyEncoders) > 0 && len(request.bodyBytes) > 0 {
+		encoded, err := request.encodeBody(request.bodyBytes)
+		if err != nil {
+			err = request.newError(KindInvalidRequest, 0, err)
+			request.LogError(err, request.Method, "response.go", "encodeBody")
+			return nil, err
+		}
+		request.bodyBytes = encoded
+	}
+	// 请求超时或被取消后 Transport 可能仍在读取请求体, 因此请求体直接编码到当前请求独占的 bodyBytes,
+	// 不使用 bufPool 中会被其他请求复用的缓冲区
+	var body io.Reader = http.NoBody
+	if len(request.bodyBytes) > 0 {
+		body = bytes.NewReader(request.bodyBytes)
+	}
+	req, err := http.NewRequestWithContext(request.ctx, request.Method, request.URL.String(), body)
 	if err != nil {
-		request.client.LogError(err, request.Method, "response.go", "http.NewRequestWithContext")
+		err = request.newError(KindInvalidRequest, 0, err)
+		request.LogError(err, request.Method, "response.go", "http.NewRequestWithContext")
 		return nil, err
 	}
+	if request.bodyFile != "" {
+		request.setBodyFile(req, bodySize)
+	}
+	request.setTransferEncoding(req)
 	// 设置请求头
 	req.Header = request.GetRequestHeader()
+	request.setAcceptEncoding(req)
+	request.applyFingerprint(req)
+	request.applyAutoReferer(req)
 	for _, v := range request.Cookies {
 		req.AddCookie(v)
 	}
 	return req, nil
 }
 
-func (request *Request) newResponse(method, path string) (*Response, error) {
-	var err error
-	var response *Response
+// buildRequest 方法用于根据请求的配置生成 *http.Request, 每次调用都重新生成请求体。
+func (request *Request) buildRequest() (*http.Request, error) {
+	if _, err := request.newParseUrl(request.path); err != nil {
+		return nil, err
+	}
+	request.bodyBytes = nil
+	if request.Body != nil {
+		if err := request.setBody(); err != nil {
+			err = request.newError(KindInvalidRequest, 0, err)
+			request.LogError(err, request.path, "response.go", "setBody")
+			return nil, err
+		}
+	}
+	return request.newRequestWithContext()
+}
+
+func (request *Request) newResponse(method, path string) (response *Response, err error) {
 	defer func() {
-		if request.client.GetClientDebug() {
+		if err != nil {
+			request.callErrorFuncs(err)
+		} else if response != nil && response.retryErr != nil {
+			request.callErrorFuncs(response.retryErr)
+		}
+	}()
+	defer func() {
+		if request.client.GetClientDebug() && response != nil {
 			request.client.log.WithFields(newFormatResponseLogText(response)).Debug("response debug")
 		}
 	}()
-	request.Method = method
-	if _, err = request.newParseUrl(path); err != nil {
+	request.Method, request.path = method, path
+	request.stampRequestID()
+	if request.NewRequest, err = request.buildRequest(); err != nil {
 		return nil, err
 	}
-	if request.bodyBuf == nil {
-		request.bodyBuf = &bytes.Buffer{}
+	if err = request.validateSpecRequest(); err != nil {
+		err = request.newError(KindInvalidRequest, 0, err)
+		request.LogError(err, path, "response.go", "validateSpecRequest")
+		return nil, err
 	}
-	if request.Body != nil {
-		request.setBody()
+	if err = request.checkRobots(); err != nil {
+		request.LogError(err, path, "response.go", "checkRobots")
+		return nil, err
+	}
+	request.emit(EventRequestQueued, 0, 0, nil)
+	release, err := request.acquireSlot()
+	if err != nil {
+		err = request.newError(KindUnknown, 0, err)
+		request.LogError(err, path, "response.go", "acquireSlot")
+		return nil, err
 	}
-	request.client.httpClientRaw.Jar.SetCookies(request.URL, request.Cookies)
-	request.NewRequest, err = request.newRequestWithContext()
+	defer release()
+	response, err = request.doWithCache()
 	if err != nil {
+		request.LogError(err, path, "response.go", "newDoRequest")
 		return nil, err
 	}
-	if request.client.GetClientRetryNumber() == 0 {
-		request.client.SetRetryCount(1)
+	request.recordReferer(response)
+	if !request.needsResult() {
+		// 响应体在第一次访问时才读取, 只检查状态码或者响应头的调用不会读取响应体; 调用者既没有访问响应体也没有调用 Close 时,
+		// 在响应被垃圾回收时关闭原始响应体, 避免连接一直被占用
+		runtime.SetFinalizer(response, (*Response).Close)
+		request.emit(EventDecodeFinished, 0, response.GetStatusCode(), nil)
+		return response, nil
+	}
+	body, err := response.readBody()
+	if err != nil {
+		request.LogError(err, path, "response.go", "readBody")
 	}
-	response, err = request.newDoRequest()
+	response.resultOnce.Do(func() {
+		response.Result, err = request.decodeResult(bytesToString(body))
+	})
+	request.emit(EventDecodeFinished, 0, response.GetStatusCode(), err)
 	if err != nil {
-		request.client.LogError(err, path, "response.go", "newDoRequest")
+		err = response.newDecodeError(err)
+		request.LogError(err, path, "response.go", "decodeResult")
 		return nil, err
 	}
-	if request.client.setResultFunc != nil {
-		response.Result, err = request.client.setResultFunc(response.String())
-		if err != nil || response.Result == "" {
-			request.client.LogError(err, path, "response.go", "setResultFunc")
-			response.Result = response.String()
-			return nil, err
-		}
-	} else {
-		response.Result = response.String()
+	if err = request.validateResponseSchema(response.Result); err != nil {
+		err = response.newDecodeError(err)
+		request.LogError(err, path, "response.go", "validateResponseSchema")
+		return nil, err
+	}
+	if err = response.validateSpecResponse(response.GetByte()); err != nil {
+		err = response.newDecodeError(err)
+		request.LogError(err, path, "response.go", "validateSpecResponse")
+		return nil, err
+	}
+	if err = response.checkEnvelope(); err != nil {
+		err = response.newDecodeError(err)
+		request.LogError(err, path, "response.go", "checkEnvelope")
+		return nil, err
 	}
 	return response, nil
 }
 
-func (request *Request) setBody() {
-	contentType := request.GetHeaderContentType()
-	switch body := request.Body.(type) {
-	case string:
-		if contentType == formContentType {
-			if gjson.Valid(body) {
-				request.SetQueryParams(request.jsonToMap(body))
-			}
-		} else {
-			request.bodyBuf = bytes.NewBufferString(body)
-		}
-	case map[string]string, map[string]interface{}:
-		b := request.mapToJson(body)
-		if contentType == formContentType {
-			request.SetQueryParams(request.jsonToMap(b))
-		} else {
-			request.bodyBuf = bytes.NewBufferString(b)
-		}
-	default:
-		kind := reflect.TypeOf(body).Kind()
-		if kind == reflect.Struct || kind == reflect.Ptr {
-			b := request.structToJson(body)
-			if contentType == formContentType {
-				request.SetQueryParams(request.jsonToMap(b))
-			} else {
-				request.bodyBuf = bytes.NewBufferString(b)
-			}
-		}
+// needsResult 方法用于判断是否需要在返回响应之前读取响应体并生成 Result: 设置了解码器、Schema、接口规范或者响应外层检查时,
+// 它们的错误需要由发送请求的方法返回。
+func (request *Request) needsResult() bool {
+	client := request.client
+	if request.doNotParse {
+		return false
 	}
+	return len(request.getResponseDecoders()) > 0 || request.responseSchema != "" || client.specValidator != nil ||
+		(client.envelopeCheck != nil && !request.skipEnvelope)
 }
 
 // newDoResponse 方法用于执行 HTTP 请求。它接收一个 Response 对象的指针，表示 HTTP 请求的响应。
 func (request *Request) newDoRequest() (*Response, error) {
 	var err error
 	var raw *http.Response
-	for i := 0; i < request.client.GetClientRetryNumber(); i++ {
-		raw, err = request.client.httpClientRaw.Do(request.NewRequest)
-		if err != nil {
-			request.client.LogError(err, fmt.Sprintf("retry:%v", i), "response.go", "httpClientRaw.Do")
+	var req *http.Request
+	count, policy := request.getRetryCount(), request.getRetryPolicy()
+	upload, download := request.getThrottles()
+	stats := &request.client.stats
+	stats.activeRequests.Add(1)
+	defer stats.activeRequests.Add(-1)
+	request.recordRequest()
+	if err = request.smoothBurst(); err != nil {
+		return nil, request.newError(KindUnknown, 0, err)
+	}
+	begin, attempts := time.Now(), 0
+	request.traceInfo = TraceInfo{}
+	defer func() {
+		request.logSlowRequest(time.Since(begin), attempts, raw, err)
+	}()
+	for i := 0; i < count; i++ {
+		attempts = i + 1
+		if err = request.waitCrawlDelay(request.NewRequest.Context()); err != nil {
+			return nil, request.newError(KindUnknown, i, err)
+		}
+		if req, err = request.attemptRequest(i); err != nil {
+			return nil, request.newError(KindInvalidRequest, i, err)
+		}
+		sendReq, traceResponse := request.traceVerbose(stats.traceRequest(request.traceEvents(request.traceInformational(req), i+1)))
+		sendReq, traceDone := request.traceTimings(sendReq, i+1)
+		start := time.Now()
+		raw, err = request.httpClient().Do(throttleRequest(sendReq, upload))
+		traceDone()
+		traceResponse(raw, err)
+		request.observeAttempt(req, raw, err, i, start)
+		if err == nil {
+			request.emit(EventResponseReceived, i+1, raw.StatusCode, nil)
+		}
+		throttleResponse(raw, download)
+		request.decompressResponse(raw)
+		// 请求已被取消、没有剩余的重试次数或者重试预算耗尽时不再重试
+		retryable := request.NewRequest.Context().Err() == nil && request.shouldRetry(policy, raw, err)
+		if i < count-1 && retryable && request.allowRetry() {
+			if err != nil {
+				request.LogError(err, fmt.Sprintf("retry:%v", i), "response.go", "httpClientRaw.Do")
+			} else {
+				discardResponse(raw)
+			}
+			stats.retries.Add(1)
+			if raw != nil {
+				request.emit(EventRetry, i+1, raw.StatusCode, err)
+			} else {
+				request.emit(EventRetry, i+1, 0, err)
+			}
 			continue
 		}
-		return &Response{RequestSource: request, ResponseRaw: raw, Request: request.NewRequest}, nil
+		if err != nil {
+			return nil, request.newError(KindUnknown, i+1, err)
+		}
+		response := &Response{RequestSource: request, ResponseRaw: raw, Request: req}
+		if retryable {
+			// 最后一次尝试仍然满足重试条件 (例如 503 或 429), 响应照常返回, 但对 OnError 而言请求最终失败
+			response.retryErr = request.newError(KindHTTP, i+1, errors.New("retries exhausted"))
+			response.retryErr.StatusCode = raw.StatusCode
+		}
+		return response, nil
 	}
-	return nil, fmt.Errorf("request Error: %s", err.Error())
+	return nil, request.newError(KindUnknown, count, err)
+}
+
+// httpClient 方法用于获取发送当前请求的 http.Client, 设置了请求的超时时间或者选择了指纹时使用复制的 http.Client。
+func (request *Request) httpClient() *http.Client {
+	if request.timeout <= 0 && request.fingerprint == nil {
+		return request.client.httpClientRaw
+	}
+	httpClient := *request.client.httpClientRaw
+	if request.timeout > 0 {
+		httpClient.Timeout = request.timeout
+	}
+	if request.fingerprint != nil {
+		httpClient.Transport = request.fingerprint
+	}
+	return &httpClient
+}
+
+// attemptRequest 方法用于获取第 attempt 次尝试要发送的 http.Request。
+// 请求体在发送后已被读取, 因此重试时会复制原请求并通过 GetBody 重新生成请求体。
+func (request *Request) attemptRequest(attempt int) (*http.Request, error) {
+	if attempt == 0 || request.NewRequest.Body == nil || request.NewRequest.Body == http.NoBody {
+		return request.NewRequest, nil
+	}
+	if request.NewRequest.GetBody == nil {
+		return nil, fmt.Errorf("request Error: request body can not be rewound for retry")
+	}
+	body, err := request.NewRequest.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	req := request.NewRequest.Clone(request.NewRequest.Context())
+	req.Body = body
+	return req, nil
 }
 
 // Get 方法用于创建一个 GET 请求。它接收一个 string 类型的参数，表示 HTTP 请求的路径。
@@ -229,3 +406,31 @@ func (request *Request) Head(url string) (*Response, error) {
 func (request *Request) Options(url string) (*Response, error) {
 	return request.newResponse(MethodOptions, url)
 }
+
+// Prepare 方法用于准备一个尚未发送的请求。它接收两个 string 类型的参数，分别表示 HTTP 请求的 Method 和路径。
+// 准备好的请求可以通过 Send 方法发送, 或者交给 Client.Batch 批量执行。
+func (request *Request) Prepare(method, path string) *Request {
+	request.Method = method
+	request.path = path
+	return request
+}
+
+// Build 方法用于生成通过 Prepare 准备好的请求将要发送的 *http.Request, 但不发送, 未指定 Method 时使用 GET。
+// 生成过程与 Send 相同, 包括 BaseURL、WithHost、查询参数的顺序、请求头、认证、Cookie 和请求体编码器,
+// 适合打印请求或者转换为 curl 命令。之后仍然可以调用 Send 发送请求。
+func (request *Request) Build() (*http.Request, error) {
+	if request.Method == "" {
+		request.Method = MethodGet
+	}
+	request.stampRequestID()
+	return request.buildRequest()
+}
+
+// Send 方法用于发送通过 Prepare 准备好的请求, 未指定 Method 时使用 GET。
+func (request *Request) Send() (*Response, error) {
+	method := request.Method
+	if method == "" {
+		method = MethodGet
+	}
+	return request.newResponse(method, request.path)
+}
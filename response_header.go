@@ -0,0 +1,44 @@
+package builder
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ContentType 方法用于获取 HTTP 响应的媒体类型, 不包括 charset 等参数, 例如 application/json。
+// Content-Type 无法解析时返回原始的头部值。
+func (response *Response) ContentType() string {
+	contentType := response.ResponseRaw.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return contentType
+}
+
+// ContentLength 方法用于获取 HTTP 响应的 Content-Length, 未知时返回 -1。
+func (response *Response) ContentLength() int64 {
+	return response.ResponseRaw.ContentLength
+}
+
+// LastModified 方法用于获取并解析 HTTP 响应的 Last-Modified 头部。
+func (response *Response) LastModified() (time.Time, error) {
+	value := response.ResponseRaw.Header.Get("Last-Modified")
+	if value == "" {
+		return time.Time{}, errors.New("response Error: no Last-Modified header")
+	}
+	return http.ParseTime(value)
+}
+
+// ETag 方法用于获取 HTTP 响应的 ETag 头部。
+func (response *Response) ETag() string {
+	return response.ResponseRaw.Header.Get("ETag")
+}
+
+// Location 方法用于获取 HTTP 响应的 Location 头部, 相对地址会基于请求的 URL 解析为完整的 URL。
+// 响应没有 Location 头部时返回 http.ErrNoLocation。
+func (response *Response) Location() (*url.URL, error) {
+	return response.ResponseRaw.Location()
+}
@@ -0,0 +1,111 @@
+package builder
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestResponseResultLazy 确认 Result 在第一次访问时生成, 并发的第一次访问不会产生数据竞争。
+func TestResponseResultLazy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":0,"data":{"name":"三体"}}`))
+	}))
+	defer server.Close()
+
+	response, err := NewClient().R().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Result != "" {
+		t.Fatalf("Result = %q before the body was accessed", response.Result)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = response.String()
+			_ = response.GetByte()
+			_ = response.GjsonGet("data.name")
+		}()
+	}
+	wg.Wait()
+	if want := `{"code":0,"data":{"name":"三体"}}`; response.Result != want {
+		t.Fatalf("Result = %q, want %q", response.Result, want)
+	}
+}
+
+// TestStatusOnlyDoesNotReadBody 确认只检查状态码的调用和 HEAD 请求不会读取响应体, 设置了解码器的请求在返回之前处理响应体。
+func TestStatusOnlyDoesNotReadBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "5")
+		if r.Method != MethodHead {
+			_, _ = w.Write([]byte("hello"))
+		}
+	}))
+	defer server.Close()
+	client := NewClient()
+
+	for _, method := range []string{MethodGet, MethodHead} {
+		response, err := client.R().Prepare(method, server.URL).Send()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.GetStatusCode() != http.StatusOK || response.bodyRead {
+			t.Errorf("%s: status %d, body read %v, want 200 and unread", method, response.GetStatusCode(), response.bodyRead)
+		}
+		if err = response.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := client.Stats().BytesReceived; got != 0 {
+		t.Errorf("BytesReceived = %d, want 0", got)
+	}
+
+	response, err := client.R().SetResponseDecoders(func(s string) (string, error) {
+		return strings.ToUpper(s), nil
+	}).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !response.bodyRead || response.Result != "HELLO" {
+		t.Errorf("decoded request: body read %v, Result %q, want read and %q", response.bodyRead, response.Result, "HELLO")
+	}
+}
+
+// TestRequestBuild 确认 Build 生成的请求与 Send 发送的请求相同, 并且之后仍然可以发送。
+func TestRequestBuild(t *testing.T) {
+	var sent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sent = r.Method + " " + r.URL.RequestURI() + " " + string(body)
+	}))
+	defer server.Close()
+
+	client := NewClient().SetBaseURL(server.URL).SetAuthorizationKey("Bearer abc")
+	request := client.R().SetQueryParam("page", "2").SetBody("title=book").Prepare(MethodPost, "/books?sort=new")
+	req, err := request.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	built := req.Method + " " + req.URL.RequestURI() + " " + string(data)
+	if got := req.Header.Get("Authorization"); got != "Bearer abc" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer abc")
+	}
+
+	if _, err = request.Send(); err != nil {
+		t.Fatal(err)
+	}
+	if built != sent {
+		t.Errorf("built request %q, sent %q", built, sent)
+	}
+}
@@ -0,0 +1,206 @@
+package builder
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RetryPolicy 类型用于判断一次请求尝试之后是否需要重试, response 和 err 为本次尝试的结果。
+type RetryPolicy func(response *http.Response, err error) bool
+
+// defaultRetryPolicy 为默认的重试策略, 只在请求出错时重试。
+func defaultRetryPolicy(_ *http.Response, err error) bool {
+	return err != nil
+}
+
+// SetRetryPolicy 方法用于设置客户端默认的重试策略。它接收一个 RetryPolicy 类型的参数，传入 nil 时恢复默认策略。
+func (client *Client) SetRetryPolicy(policy RetryPolicy) *Client {
+	client.retryPolicy = policy
+	return client
+}
+
+// SetRetryOnErrors 方法用于设置默认重试策略会重试的错误。它接收若干个 func(error) bool 类型的参数,
+// 请求出错且至少一个函数返回 true 时才会重试, 例如 SetRetryOnErrors(builder.IsConnectionReset, builder.IsUnexpectedEOF,
+// builder.IsTLSHandshakeTimeout, builder.IsProxyError), 这样 URL 协议不受支持这类永久性错误不会被重试。
+// 不传入参数时恢复为重试所有错误。通过 SetRetryPolicy 设置了重试策略时由该策略自行判断。
+func (client *Client) SetRetryOnErrors(matchers ...func(err error) bool) *Client {
+	client.retryOnErrors = matchers
+	return client
+}
+
+// retryOnErrorsPolicy 方法用于生成只在错误匹配 matchers 中任意一个函数时重试的重试策略。
+func retryOnErrorsPolicy(matchers []func(err error) bool) RetryPolicy {
+	return func(_ *http.Response, err error) bool {
+		if err == nil {
+			return false
+		}
+		for _, match := range matchers {
+			if match(err) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SetRetryCount 方法用于设置当前请求的重试次数, 覆盖客户端的 RetryCount。它接收一个 int 类型的参数，该参数表示重试次数。
+func (request *Request) SetRetryCount(count int) *Request {
+	if count <= 0 {
+		request.LogInfo("retry number must be greater than 0", count, "SetRetryCount")
+	} else {
+		request.retryCount = count
+	}
+	return request
+}
+
+// SetRetryPolicy 方法用于设置当前请求的重试策略, 覆盖客户端的重试策略。它接收一个 RetryPolicy 类型的参数。
+func (request *Request) SetRetryPolicy(policy RetryPolicy) *Request {
+	request.retryPolicy = policy
+	return request
+}
+
+// getRetryCount 方法用于获取当前请求生效的重试次数, 优先使用请求自身的设置, 至少为 1。
+func (request *Request) getRetryCount() int {
+	if request.retryCount > 0 {
+		return request.retryCount
+	}
+	if count := request.client.GetClientRetryNumber(); count > 0 {
+		return count
+	}
+	return 1
+}
+
+// getRetryPolicy 方法用于获取当前请求生效的重试策略, 优先使用请求自身的设置。
+func (request *Request) getRetryPolicy() RetryPolicy {
+	if request.retryPolicy != nil {
+		return request.retryPolicy
+	}
+	if request.client.retryPolicy != nil {
+		return request.client.retryPolicy
+	}
+	if len(request.client.retryOnErrors) > 0 {
+		return retryOnErrorsPolicy(request.client.retryOnErrors)
+	}
+	return defaultRetryPolicy
+}
+
+// shouldRetry 方法用于调用重试策略判断是否需要重试, 重试策略发生 panic 时记录日志并不再重试。
+func (request *Request) shouldRetry(policy RetryPolicy, raw *http.Response, err error) bool {
+	retry := false
+	if panicErr := safeCall("RetryPolicy", func() error {
+		retry = policy(raw, err)
+		return nil
+	}); panicErr != nil {
+		request.LogError(panicErr, request.NewRequest.URL.String(), "retry.go", "shouldRetry")
+		return false
+	}
+	return retry
+}
+
+// discardResponse 方法用于丢弃并关闭一个不再使用的响应, 使底层连接可以被复用。
+func discardResponse(raw *http.Response) {
+	if raw == nil || raw.Body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(raw.Body, 64*1024))
+	_ = raw.Body.Close()
+}
+
+// retryBudgetBuckets 为重试预算滑动窗口划分的桶数
+const retryBudgetBuckets = 10
+
+// retryBudgetMinRetries 为每个窗口内无论比例如何都允许的最少重试次数, 避免请求量很小时完全无法重试
+const retryBudgetMinRetries = 10
+
+// budgetBucket 类型用于统计一个时间桶内的请求数和重试数。
+type budgetBucket struct {
+	start    time.Time
+	requests int
+	retries  int
+}
+
+// retryBudget 类型用于限制滑动时间窗口内重试请求所占的比例。
+type retryBudget struct {
+	sync.Mutex
+	ratio   float64
+	width   time.Duration // width 为单个桶的时间跨度
+	buckets [retryBudgetBuckets]budgetBucket
+}
+
+// current 方法用于获取当前时间所在的桶, 过期的桶会被清空重用。
+func (budget *retryBudget) current(now time.Time) *budgetBucket {
+	start := now.Truncate(budget.width)
+	bucket := &budget.buckets[(start.UnixNano()/int64(budget.width))%retryBudgetBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = budgetBucket{start: start}
+	}
+	return bucket
+}
+
+// totals 方法用于统计滑动窗口内的请求总数和重试总数。
+func (budget *retryBudget) totals(now time.Time) (requests, retries int) {
+	oldest := now.Add(-budget.width * retryBudgetBuckets)
+	for _, bucket := range budget.buckets {
+		if bucket.start.After(oldest) {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+	return requests, retries
+}
+
+// recordRequest 方法用于记录一次新的请求 (不含重试)。
+func (budget *retryBudget) recordRequest() {
+	budget.Lock()
+	defer budget.Unlock()
+	budget.current(time.Now()).requests++
+}
+
+// allowRetry 方法用于判断当前是否还有重试预算, 允许时会同时记录这次重试。
+func (budget *retryBudget) allowRetry() bool {
+	budget.Lock()
+	defer budget.Unlock()
+	now := time.Now()
+	bucket := budget.current(now)
+	requests, retries := budget.totals(now)
+	if retries >= retryBudgetMinRetries && float64(retries+1) > budget.ratio*float64(requests) {
+		return false
+	}
+	bucket.retries++
+	return true
+}
+
+// SetRetryBudget 方法用于设置客户端的重试预算。它接收一个 float64 类型的参数表示重试请求数与请求数的最大比例,
+// 以及一个 time.Duration 类型的参数表示统计的滑动窗口。超出预算后请求失败时不再重试, 避免上游故障时引发重试风暴。
+// 每个窗口内总是允许少量重试; ratio 小于等于 0 时取消重试预算。
+func (client *Client) SetRetryBudget(ratio float64, window time.Duration) *Client {
+	if ratio <= 0 {
+		client.retryBudget = nil
+		return client
+	}
+	if window < retryBudgetBuckets {
+		client.LogInfo("retry budget window is too small", window, "SetRetryBudget")
+		return client
+	}
+	client.retryBudget = &retryBudget{ratio: ratio, width: window / retryBudgetBuckets}
+	return client
+}
+
+// recordRequest 方法用于在开启重试预算时记录一次新的请求。
+func (request *Request) recordRequest() {
+	if budget := request.client.retryBudget; budget != nil {
+		budget.recordRequest()
+	}
+}
+
+// allowRetry 方法用于判断客户端的重试预算是否允许再重试一次。
+func (request *Request) allowRetry() bool {
+	budget := request.client.retryBudget
+	if budget == nil || budget.allowRetry() {
+		return true
+	}
+	request.LogInfo("retry budget exhausted", request.NewRequest.URL.String(), "allowRetry")
+	return false
+}
@@ -0,0 +1,282 @@
+package builder
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RobotsMode 类型用于表示 robots.txt 检查的处理方式。
+type RobotsMode int
+
+const (
+	// RobotsOff 表示不检查 robots.txt, 为默认值
+	RobotsOff RobotsMode = iota
+
+	// RobotsWarn 表示请求被 robots.txt 禁止时只记录警告日志, 请求照常发送
+	RobotsWarn
+
+	// RobotsRefuse 表示拒绝发送被 robots.txt 禁止的请求, 并返回 ErrDisallowedByRobots
+	RobotsRefuse
+)
+
+// robotsTTL 为 robots.txt 的缓存时长
+const robotsTTL = 24 * time.Hour
+
+// ErrDisallowedByRobots 表示请求的路径被目标站点的 robots.txt 禁止。
+var ErrDisallowedByRobots = errors.New("robots Error: path is disallowed by robots.txt")
+
+// robotsRule 类型用于表示一条 Allow 或 Disallow 规则。
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// robotsGroup 类型用于表示 robots.txt 中针对一组 User-agent 的规则。
+type robotsGroup struct {
+	agents []string
+	rules  []robotsRule
+}
+
+// robotsRules 类型用于存储一个站点解析后的 robots.txt。
+type robotsRules struct {
+	groups      []*robotsGroup
+	disallowAll bool // disallowAll 表示 robots.txt 暂时无法获取, 按 RFC 9309 视为禁止访问所有路径
+	fetchedAt   time.Time
+}
+
+// disallowAllRules 表示 robots.txt 因服务器错误或网络错误无法获取时使用的规则, 不会被缓存。
+var disallowAllRules = &robotsRules{disallowAll: true}
+
+// parseRobots 方法用于解析 robots.txt 的内容。
+func parseRobots(r io.Reader) *robotsRules {
+	rules := &robotsRules{fetchedAt: time.Now()}
+	var group *robotsGroup
+	inRules := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			// 规则之后出现的 User-agent 开始一个新的分组, 连续的 User-agent 共享同一个分组
+			if group == nil || inRules {
+				group = &robotsGroup{}
+				rules.groups = append(rules.groups, group)
+				inRules = false
+			}
+			group.agents = append(group.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if group == nil {
+				continue
+			}
+			inRules = true
+			// 空的 Disallow 表示允许访问所有路径
+			if value != "" {
+				group.rules = append(group.rules, robotsRule{allow: key == "allow", pattern: value, re: compileRobotsPattern(value)})
+			}
+		}
+	}
+	return rules
+}
+
+// group 方法用于选择与 userAgent 匹配的分组, 优先选择匹配最长的名称, 否则使用 * 分组。
+func (rules *robotsRules) group(userAgent string) *robotsGroup {
+	userAgent = strings.ToLower(userAgent)
+	var best, wildcard *robotsGroup
+	bestLen := 0
+	for _, group := range rules.groups {
+		for _, agent := range group.agents {
+			if agent == "*" {
+				if wildcard == nil {
+					wildcard = group
+				}
+			} else if len(agent) > bestLen && strings.Contains(userAgent, agent) {
+				best, bestLen = group, len(agent)
+			}
+		}
+	}
+	if best != nil {
+		return best
+	}
+	return wildcard
+}
+
+// allowed 方法用于判断 userAgent 是否允许访问 path, 匹配最长的规则生效, 长度相同时 Allow 优先。
+func (rules *robotsRules) allowed(userAgent, path string) bool {
+	if rules.disallowAll {
+		return false
+	}
+	group := rules.group(userAgent)
+	if group == nil {
+		return true
+	}
+	allow, matchedLen := true, -1
+	for _, rule := range group.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if n := len(rule.pattern); n > matchedLen || (n == matchedLen && rule.allow) {
+			allow, matchedLen = rule.allow, n
+		}
+	}
+	return allow
+}
+
+// compileRobotsPattern 方法用于把 robots.txt 规则编译为正则表达式, 支持 * 通配符和 $ 结尾锚点。
+func compileRobotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// robotsCache 类型用于按站点缓存 robots.txt。
+type robotsCache struct {
+	sync.Mutex
+	sites    map[string]*robotsRules
+	inflight map[string]chan struct{} // inflight 用于记录正在获取 robots.txt 的站点, 获取完成时关闭对应的 channel
+}
+
+// SetRobotsMode 方法用于开启 robots.txt 检查。它接收一个 RobotsMode 类型的参数，表示请求被禁止时的处理方式。
+// 开启后客户端会按站点获取并缓存 robots.txt, 单个请求可以通过 Request.IgnoreRobots 跳过检查。
+func (client *Client) SetRobotsMode(mode RobotsMode) *Client {
+	client.robotsMode = mode
+	if client.robots == nil {
+		client.robots = &robotsCache{sites: map[string]*robotsRules{}, inflight: map[string]chan struct{}{}}
+	}
+	return client
+}
+
+// SetRobotsUserAgent 方法用于设置匹配 robots.txt 分组时使用的爬虫名称, 默认使用请求的 User-Agent。
+func (client *Client) SetRobotsUserAgent(userAgent string) *Client {
+	client.robotsUserAgent = userAgent
+	return client
+}
+
+// IgnoreRobots 方法用于让当前请求跳过 robots.txt 检查。
+func (request *Request) IgnoreRobots() *Request {
+	request.ignoreRobots = true
+	return request
+}
+
+// checkRobots 方法用于在发送请求前检查目标路径是否被 robots.txt 禁止。
+func (request *Request) checkRobots() error {
+	client := request.client
+	if client.robotsMode == RobotsOff || request.ignoreRobots {
+		return nil
+	}
+	userAgent := client.robotsUserAgent
+	if userAgent == "" {
+		userAgent = request.NewRequest.Header.Get("User-Agent")
+	}
+	rules, err := client.robots.get(request.NewRequest, client)
+	if err != nil {
+		return request.newError(KindUnknown, 0, err)
+	}
+	if rules.allowed(userAgent, request.NewRequest.URL.RequestURI()) {
+		return nil
+	}
+	if client.robotsMode == RobotsWarn {
+		request.LogInfo(ErrDisallowedByRobots.Error(), request.NewRequest.URL.String(), "checkRobots")
+		return nil
+	}
+	return ErrDisallowedByRobots
+}
+
+// get 方法用于获取请求所在站点的 robots.txt 规则, 缓存过期或不存在时重新获取。同一站点同时只有一个请求获取 robots.txt,
+// 其他请求等待它完成, 获取过程不持有缓存的锁。请求在等待或获取时被取消会返回 context 的错误。
+func (cache *robotsCache) get(req *http.Request, client *Client) (*robotsRules, error) {
+	site := req.URL.Scheme + "://" + req.URL.Host
+	for {
+		rules, done, leader := cache.lookup(site)
+		if rules != nil {
+			return rules, nil
+		}
+		if leader {
+			return cache.fetch(req, site, client, done)
+		}
+		// 等待正在进行的获取, 它没有缓存结果时 (例如被取消或遇到网络错误) 由某个等待的请求重新获取
+		select {
+		case <-done:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// lookup 方法用于查找未过期的 robots.txt 规则。未命中且没有正在进行的获取时把当前请求登记为 leader,
+// 返回的 channel 在 leader 完成时关闭; 已有正在进行的获取时返回它的 channel。
+func (cache *robotsCache) lookup(site string) (rules *robotsRules, done chan struct{}, leader bool) {
+	cache.Lock()
+	defer cache.Unlock()
+	if rules, ok := cache.sites[site]; ok && time.Since(rules.fetchedAt) < robotsTTL {
+		return rules, nil, false
+	}
+	if done, ok := cache.inflight[site]; ok {
+		return nil, done, false
+	}
+	done = make(chan struct{})
+	cache.inflight[site] = done
+	return nil, done, true
+}
+
+// fetch 方法用于由 leader 获取 robots.txt, 保存可以缓存的结果后取消登记并唤醒等待的请求。
+func (cache *robotsCache) fetch(req *http.Request, site string, client *Client, done chan struct{}) (*robotsRules, error) {
+	rules, err := fetchRobots(req, site, client)
+	cache.Lock()
+	if err == nil && rules != disallowAllRules {
+		cache.sites[site] = rules
+	}
+	delete(cache.inflight, site)
+	cache.Unlock()
+	close(done)
+	return rules, err
+}
+
+// fetchRobots 方法用于获取并解析站点的 robots.txt。robots.txt 不存在 (4xx) 时视为允许访问所有路径,
+// 服务器错误 (5xx 或 429) 和网络错误时按 RFC 9309 视为禁止访问所有路径, 并且不缓存, 下一个请求会重新获取。
+// 请求被取消时返回 context 的错误。
+func fetchRobots(req *http.Request, site string, client *Client) (*robotsRules, error) {
+	robotsReq, err := http.NewRequestWithContext(req.Context(), MethodGet, site+"/robots.txt", nil)
+	if err != nil {
+		client.LogError(err, site, "robots.go", "fetchRobots")
+		return &robotsRules{fetchedAt: time.Now()}, nil
+	}
+	robotsReq.Header.Set("User-Agent", req.Header.Get("User-Agent"))
+	raw, err := client.httpClientRaw.Do(robotsReq)
+	if err != nil {
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		client.LogError(err, site, "robots.go", "fetchRobots")
+		return disallowAllRules, nil
+	}
+	defer raw.Body.Close()
+	switch {
+	case raw.StatusCode >= http.StatusInternalServerError || raw.StatusCode == http.StatusTooManyRequests:
+		return disallowAllRules, nil
+	case raw.StatusCode != http.StatusOK:
+		return &robotsRules{fetchedAt: time.Now()}, nil
+	}
+	return parseRobots(io.LimitReader(raw.Body, 512*1024)), nil
+}
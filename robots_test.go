@@ -0,0 +1,87 @@
+package builder
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRobotsServerErrorNotCached 确认 robots.txt 返回 5xx 时禁止访问并且不缓存, 恢复后的请求重新获取并允许访问。
+func TestRobotsServerErrorNotCached(t *testing.T) {
+	var fetches, status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fetches.Add(1)
+			w.WriteHeader(int(status.Load()))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	client := NewClient().SetRetryCount(0).SetRobotsMode(RobotsRefuse)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.R().Get(server.URL + "/books"); !errors.Is(err, ErrDisallowedByRobots) {
+			t.Fatalf("request %d during 503: err = %v, want ErrDisallowedByRobots", i, err)
+		}
+	}
+	status.Store(http.StatusNotFound)
+	for i := 0; i < 2; i++ {
+		if _, err := client.R().Get(server.URL + "/books"); err != nil {
+			t.Fatalf("request %d after recovery: %v", i, err)
+		}
+	}
+	if got := fetches.Load(); got != 3 {
+		t.Errorf("robots.txt fetched %d times, want 3", got)
+	}
+}
+
+// TestRobotsFetchOutsideLock 确认同一站点的并发请求只获取一次 robots.txt, 并且获取过程不会阻塞其他站点。
+func TestRobotsFetchOutsideLock(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fetches.Add(1)
+			<-release
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+		}
+	}))
+	defer slow.Close()
+	var unblock sync.Once
+	defer unblock.Do(func() { close(release) })
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fast.Close()
+	client := NewClient().SetRetryCount(0).SetRobotsMode(RobotsRefuse)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = client.R().Get(slow.URL + "/private")
+		}(i)
+	}
+	for fetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := client.R().SetTimeout(2 * time.Second).Get(fast.URL); err != nil {
+		t.Fatalf("request to another site while robots.txt is loading: %v", err)
+	}
+	unblock.Do(func() { close(release) })
+	wg.Wait()
+	for i, err := range errs {
+		if !errors.Is(err, ErrDisallowedByRobots) {
+			t.Errorf("request %d: err = %v, want ErrDisallowedByRobots", i, err)
+		}
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("robots.txt fetched %d times, want 1", got)
+	}
+}
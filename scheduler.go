@@ -0,0 +1,141 @@
+package builder
+
+import (
+	"container/heap"
+	"golang.org/x/net/context"
+	"sync"
+)
+
+// Priority 类型用于表示请求的调度优先级, 数值越大越先被调度。
+type Priority int
+
+const (
+	// PriorityLow 用于后台批量抓取等低优先级请求
+	PriorityLow Priority = -1
+
+	// PriorityNormal 为请求的默认优先级
+	PriorityNormal Priority = 0
+
+	// PriorityHigh 用于面向用户的读取等高优先级请求
+	PriorityHigh Priority = 1
+)
+
+// waiter 类型用于表示一个等待调度的请求。
+type waiter struct {
+	priority Priority
+	seq      uint64 // seq 用于保证相同优先级的请求按到达顺序调度
+	ready    chan struct{}
+	index    int // index 为 waiter 在堆中的位置, -1 表示已被调度
+}
+
+// waiterHeap 类型为按优先级排序的等待队列。
+type waiterHeap []*waiter
+
+func (h waiterHeap) Len() int { return len(h) }
+func (h waiterHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h waiterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *waiterHeap) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+func (h *waiterHeap) Pop() any {
+	old := *h
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	w.index = -1
+	return w
+}
+
+// scheduler 类型为有并发上限的优先级调度器, 名额空出时总是优先分配给优先级最高的请求。
+type scheduler struct {
+	sync.Mutex
+	slots   int
+	seq     uint64
+	waiters waiterHeap
+}
+
+func newScheduler(maxConcurrent int) *scheduler {
+	return &scheduler{slots: maxConcurrent}
+}
+
+// acquire 方法用于按优先级获取一个执行名额, ctx 结束时放弃等待并返回 ctx 的错误。
+func (s *scheduler) acquire(ctx context.Context, priority Priority) error {
+	s.Lock()
+	if s.slots > 0 && len(s.waiters) == 0 {
+		s.slots--
+		s.Unlock()
+		return nil
+	}
+	s.seq++
+	w := &waiter{priority: priority, seq: s.seq, ready: make(chan struct{})}
+	heap.Push(&s.waiters, w)
+	s.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.Lock()
+		if w.index >= 0 {
+			heap.Remove(&s.waiters, w.index)
+			s.Unlock()
+			return ctx.Err()
+		}
+		s.Unlock()
+		// 名额已经分配给了该请求, 需要归还
+		s.release()
+		return ctx.Err()
+	}
+}
+
+// release 方法用于归还一个执行名额, 如果有请求在等待则直接交给优先级最高的请求。
+func (s *scheduler) release() {
+	s.Lock()
+	defer s.Unlock()
+	if len(s.waiters) > 0 {
+		w := heap.Pop(&s.waiters).(*waiter)
+		close(w.ready)
+		return
+	}
+	s.slots++
+}
+
+// EnablePriorityScheduler 方法用于开启优先级调度。它接收一个 int 类型的参数，该参数表示同时执行的最大请求数。
+// 开启后超出并发上限的请求会排队等待, 名额空出时优先调度通过 Request.SetPriority 设置了更高优先级的请求。
+func (client *Client) EnablePriorityScheduler(maxConcurrent int) *Client {
+	if maxConcurrent <= 0 {
+		client.LogInfo("max concurrent must be greater than 0", maxConcurrent, "EnablePriorityScheduler")
+	} else {
+		client.scheduler = newScheduler(maxConcurrent)
+	}
+	return client
+}
+
+// SetPriority 方法用于设置请求的调度优先级。它接收一个 Priority 类型的参数，仅在开启优先级调度后生效。
+func (request *Request) SetPriority(priority Priority) *Request {
+	request.priority = priority
+	return request
+}
+
+// acquireSlot 方法用于在开启优先级调度时获取执行名额, 返回的函数用于归还名额。
+func (request *Request) acquireSlot() (func(), error) {
+	s := request.client.scheduler
+	if s == nil {
+		return func() {}, nil
+	}
+	if err := s.acquire(request.ctx, request.priority); err != nil {
+		return nil, err
+	}
+	return s.release, nil
+}
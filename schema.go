@@ -0,0 +1,62 @@
+package builder
+
+import (
+	"github.com/catnovelapi/builder/pkg/jsonschema"
+	"sync"
+)
+
+// maxCompiledSchemas 为每个客户端缓存的已编译 JSON Schema 的最大数量, 达到上限时随机清除一个条目。
+const maxCompiledSchemas = 256
+
+// schemaCache 类型用于缓存已编译的 JSON Schema, 以 schema 字符串作为键。
+type schemaCache struct {
+	sync.Mutex
+	schemas map[string]*jsonschema.Schema
+}
+
+// compile 方法用于编译 JSON Schema, 相同的 schema 只会编译一次。缓存属于客户端, 最多保存 maxCompiledSchemas 个条目,
+// 动态生成 schema 的长期运行的程序不会无限占用内存。
+func (cache *schemaCache) compile(schemaJSON string) (*jsonschema.Schema, error) {
+	cache.Lock()
+	schema, ok := cache.schemas[schemaJSON]
+	cache.Unlock()
+	if ok {
+		return schema, nil
+	}
+	schema, err := jsonschema.Compile([]byte(schemaJSON))
+	if err != nil {
+		return nil, err
+	}
+	cache.Lock()
+	defer cache.Unlock()
+	if cache.schemas == nil {
+		cache.schemas = make(map[string]*jsonschema.Schema)
+	}
+	if len(cache.schemas) >= maxCompiledSchemas {
+		for key := range cache.schemas {
+			delete(cache.schemas, key)
+			break
+		}
+	}
+	cache.schemas[schemaJSON] = schema
+	return schema, nil
+}
+
+// SetResponseSchema 方法用于设置响应结果需要满足的 JSON Schema。它接收一个 string 类型的参数，该参数表示 JSON Schema 文档。
+// 响应结果 (经过解码管道之后) 不满足 schema 时, 请求返回 *jsonschema.ValidationError 类型的错误, 便于尽早发现上游接口的变化。
+func (request *Request) SetResponseSchema(schemaJSON string) *Request {
+	request.responseSchema = schemaJSON
+	return request
+}
+
+// validateResponseSchema 方法用于校验响应结果是否满足当前请求设置的 JSON Schema。
+func (request *Request) validateResponseSchema(result string) error {
+	if request.responseSchema == "" {
+		return nil
+	}
+	schema, err := request.client.schemas.compile(request.responseSchema)
+	if err != nil {
+		return err
+	}
+	return schema.Validate([]byte(result))
+}
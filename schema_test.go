@@ -0,0 +1,28 @@
+package builder
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestSchemaCacheBounded 确认已编译的 JSON Schema 按客户端缓存, 并且条目数不超过上限。
+func TestSchemaCacheBounded(t *testing.T) {
+	client := NewClient()
+	for i := 0; i < maxCompiledSchemas+10; i++ {
+		schemaJSON := fmt.Sprintf(`{"type":"object","required":["field%d"]}`, i)
+		if _, err := client.schemas.compile(schemaJSON); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(client.schemas.schemas); got != maxCompiledSchemas {
+		t.Errorf("cached schemas = %d, want %d", got, maxCompiledSchemas)
+	}
+	first, _ := client.schemas.compile(`{"type":"array"}`)
+	second, _ := client.schemas.compile(`{"type":"array"}`)
+	if first != second {
+		t.Error("the same schema was compiled twice")
+	}
+	if other := NewClient(); len(other.schemas.schemas) != 0 {
+		t.Errorf("new client has %d cached schemas, want 0", len(other.schemas.schemas))
+	}
+}
@@ -0,0 +1,127 @@
+package builder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSitemapDepth 为 Sitemap 跟随嵌套的 sitemap 索引的最大层数
+const maxSitemapDepth = 5
+
+// SitemapURL 类型用于存储 sitemap 中的一个 URL 条目。
+type SitemapURL struct {
+	Loc        string    // 页面地址
+	LastMod    time.Time // 页面最后修改时间, 未设置时为零值
+	ChangeFreq string    // 页面的更新频率, 例如 daily
+	Priority   float64   // 页面的优先级, 未设置时为 0.5
+}
+
+// sitemapDocument 类型用于解析 urlset 和 sitemapindex 两种 sitemap 文档。
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+// sitemapEntry 类型用于解析 sitemap 文档中的 url 和 sitemap 元素。
+type sitemapEntry struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+}
+
+// Sitemap 方法用于获取并解析 sitemap。它接收一个 string 类型的参数，该参数表示 sitemap 的地址 (例如 /sitemap.xml)。
+// sitemap 索引中引用的 sitemap 会被依次获取, 支持 gzip 压缩的 sitemap (例如 sitemap.xml.gz)。
+// 返回所有 URL 条目, 可以根据 LastMod 和 Priority 安排抓取顺序。
+func (client *Client) Sitemap(url string) ([]SitemapURL, error) {
+	visited := make(map[string]bool)
+	urls, err := client.fetchSitemap(url, 0, visited)
+	if err != nil {
+		client.LogError(err, url, "sitemap.go", "Sitemap")
+	}
+	return urls, err
+}
+
+// fetchSitemap 方法用于获取一个 sitemap 文档, 遇到 sitemap 索引时递归获取其中的 sitemap。
+func (client *Client) fetchSitemap(url string, depth int, visited map[string]bool) ([]SitemapURL, error) {
+	if visited[url] {
+		return nil, nil
+	}
+	visited[url] = true
+	response, err := client.R().Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if code := response.GetStatusCode(); code < 200 || code > 299 {
+		return nil, fmt.Errorf("sitemap %s: unexpected status %s", url, response.GetStatus())
+	}
+	body, err := gunzipSitemap(response.GetByte())
+	if err != nil {
+		return nil, fmt.Errorf("sitemap %s: %w", url, err)
+	}
+	var document sitemapDocument
+	if err = xml.Unmarshal(body, &document); err != nil {
+		return nil, fmt.Errorf("sitemap %s: %w", url, err)
+	}
+	var urls []SitemapURL
+	for _, entry := range document.URLs {
+		urls = append(urls, entry.sitemapURL())
+	}
+	if document.XMLName.Local == "sitemapindex" {
+		if depth >= maxSitemapDepth {
+			return nil, fmt.Errorf("sitemap %s: index nested too deeply", url)
+		}
+		for _, entry := range document.Sitemaps {
+			children, err := client.fetchSitemap(strings.TrimSpace(entry.Loc), depth+1, visited)
+			if err != nil {
+				return urls, err
+			}
+			urls = append(urls, children...)
+		}
+	}
+	return urls, nil
+}
+
+// sitemapURL 方法用于把 sitemap 中的 url 元素转换为 SitemapURL。
+func (entry sitemapEntry) sitemapURL() SitemapURL {
+	result := SitemapURL{
+		Loc:        strings.TrimSpace(entry.Loc),
+		LastMod:    parseSitemapTime(strings.TrimSpace(entry.LastMod)),
+		ChangeFreq: strings.TrimSpace(entry.ChangeFreq),
+		Priority:   0.5,
+	}
+	if priority, err := strconv.ParseFloat(strings.TrimSpace(entry.Priority), 64); err == nil {
+		result.Priority = priority
+	}
+	return result
+}
+
+// parseSitemapTime 方法用于解析 W3C Datetime 格式的 lastmod, 无法解析时返回零值。
+func parseSitemapTime(value string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04Z07:00", "2006-01-02T15:04:05", "2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// gunzipSitemap 方法用于解压 gzip 压缩的 sitemap, 未压缩时原样返回。
+func gunzipSitemap(body []byte) ([]byte, error) {
+	if len(body) < 2 || body[0] != 0x1f || body[1] != 0x8b {
+		return body, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,52 @@
+package builder
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// burstSmoother 类型用于把同时发出的一批请求随机分散到一个时间窗口内。
+type burstSmoother struct {
+	mu        sync.Mutex
+	window    time.Duration
+	lastStart time.Time // lastStart 为最近一个请求开始的时间
+}
+
+// delay 方法用于计算当前请求需要等待的时间。距离上一个请求开始不足一个窗口时, 请求被视为突发流量的一部分,
+// 在窗口内随机延迟; 否则立即发送。
+func (smoother *burstSmoother) delay() time.Duration {
+	smoother.mu.Lock()
+	defer smoother.mu.Unlock()
+	now := time.Now()
+	burst := !smoother.lastStart.IsZero() && now.Sub(smoother.lastStart) < smoother.window
+	smoother.lastStart = now
+	if !burst {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(smoother.window)))
+}
+
+// SetBurstSmoothing 方法用于平滑突发的请求。它接收一个 time.Duration 类型的参数，该参数表示时间窗口,
+// 设置后在短时间内集中发出的请求会在窗口内随机延迟发送, 例如同时发起 200 个章节请求时不会在同一瞬间到达服务器,
+// 以免被上游的 WAF 识别为攻击。0 表示关闭。命中缓存的请求不受影响。
+func (client *Client) SetBurstSmoothing(window time.Duration) *Client {
+	client.burstSmoother = nil
+	if window > 0 {
+		client.burstSmoother = &burstSmoother{window: window}
+	}
+	return client
+}
+
+// smoothBurst 方法用于在发送请求前按 SetBurstSmoothing 的设置等待。
+func (request *Request) smoothBurst() error {
+	smoother := request.client.burstSmoother
+	if smoother == nil {
+		return nil
+	}
+	delay := smoother.delay()
+	if delay <= 0 {
+		return nil
+	}
+	return waitInterval(request.NewRequest.Context(), time.Now(), delay)
+}
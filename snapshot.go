@@ -0,0 +1,89 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"github.com/catnovelapi/builder/pkg/assert"
+	"github.com/catnovelapi/builder/pkg/files"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SnapshotUpdateEnv 为更新快照文件的环境变量, 设置为 1 或 true 时 Snapshot 会覆盖已有的快照文件。
+const SnapshotUpdateEnv = "BUILDER_UPDATE_SNAPSHOTS"
+
+// Snapshot 方法用于在测试中把响应体与快照文件比较。它接收一个 assert.TestingT 类型的参数 (通常为 *testing.T)
+// 和一个 string 类型的参数，该参数表示快照文件的路径, 例如 testdata/chapter_list.json。
+// 快照文件不存在时写入当前的响应体; 存在时进行比较, 不一致时通过 t.Errorf 报告第一处不同的行。
+// JSON 响应体会先格式化为按键排序、缩进的形式, 避免字段顺序和空白造成的差异。
+// 使用 go test -update (需要测试包自行定义 update 标志) 或者设置环境变量 BUILDER_UPDATE_SNAPSHOTS=1 可以更新快照。
+func (response *Response) Snapshot(t assert.TestingT, path string) bool {
+	t.Helper()
+	actual := normalizeSnapshot(response.GetByte())
+	expected, err := os.ReadFile(path)
+	if os.IsNotExist(err) || (err == nil && updateSnapshots()) {
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = files.WriteAtomic(path, bytes.NewReader(actual))
+		}
+		if err != nil {
+			t.Errorf("snapshot %s: %v", path, err)
+			return false
+		}
+		return true
+	}
+	if err != nil {
+		t.Errorf("snapshot %s: %v", path, err)
+		return false
+	}
+	if line, want, got, ok := firstDifferentLine(expected, actual); !ok {
+		t.Errorf("snapshot %s mismatch at line %d:\n  want: %s\n   got: %s", path, line, want, got)
+		return false
+	}
+	return true
+}
+
+// updateSnapshots 方法用于判断是否需要更新快照文件。
+func updateSnapshots() bool {
+	if value := strings.ToLower(os.Getenv(SnapshotUpdateEnv)); value == "1" || value == "true" {
+		return true
+	}
+	if update := flag.Lookup("update"); update != nil {
+		return update.Value.String() == "true"
+	}
+	return false
+}
+
+// normalizeSnapshot 方法用于把 JSON 响应体格式化为按键排序、缩进的形式, 其他内容保持不变。
+func normalizeSnapshot(body []byte) []byte {
+	value, ok := normalizeJson(body)
+	if !ok {
+		return body
+	}
+	formatted, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return body
+	}
+	return append(formatted, '\n')
+}
+
+// firstDifferentLine 方法用于找到两段内容中第一处不同的行, 行号从 1 开始; 内容相同时 ok 为 true。
+func firstDifferentLine(expected, actual []byte) (line int, want, got string, ok bool) {
+	if bytes.Equal(expected, actual) {
+		return 0, "", "", true
+	}
+	wantLines := strings.Split(string(expected), "\n")
+	gotLines := strings.Split(string(actual), "\n")
+	for i := 0; ; i++ {
+		if i >= len(wantLines) || i >= len(gotLines) || wantLines[i] != gotLines[i] {
+			if i < len(wantLines) {
+				want = wantLines[i]
+			}
+			if i < len(gotLines) {
+				got = gotLines[i]
+			}
+			return i + 1, want, got, false
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// ContentTypeError 类型用于存储响应内容与期望类型不符时的信息, 例如 JSON 接口被 WAF 返回了 HTML 拦截页面。
+type ContentTypeError struct {
+	Expected   string // 期望的类型
+	Declared   string // 响应头部声明的 Content-Type
+	Detected   string // 根据响应体检测到的类型
+	StatusCode int    // HTTP 响应的状态码
+}
+
+// Error 方法用于获取错误的描述。
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("content type Error: expected %s, got %s (Content-Type %q, status %d)",
+		e.Expected, e.Detected, e.Declared, e.StatusCode)
+}
+
+// DetectContentType 方法用于根据响应体的内容检测响应的媒体类型, 例如 application/json、text/html、image/png。
+// 内容只能识别为 text/plain 或 application/octet-stream 这类通用类型时使用响应头部声明的媒体类型 (例如 text/csv、image/avif),
+// 只有内容与声明明确矛盾时 (例如声明为 JSON 的响应实际是 HTML 页面或者不是合法的 JSON) 才返回检测到的类型。
+// 响应体为空时返回头部声明的媒体类型。
+func (response *Response) DetectContentType() string {
+	declared, _, _ := mime.ParseMediaType(response.GetHeader().Get("Content-Type"))
+	body := response.GetByte()
+	if len(body) == 0 {
+		return declared
+	}
+	if response.IsJSON() {
+		return jsonContentType
+	}
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(body))
+	if declared == "" || (sniffed != "text/plain" && sniffed != "application/octet-stream") {
+		return sniffed
+	}
+	if matchMediaType(declared, jsonContentType) && !gjson.ValidBytes(body) {
+		return sniffed
+	}
+	return declared
+}
+
+// IsJSON 方法用于判断响应体是否为合法的 JSON 对象或数组, 不依赖响应头部的 Content-Type。
+func (response *Response) IsJSON() bool {
+	body := bytes.TrimSpace(response.GetByte())
+	if len(body) == 0 || (body[0] != '{' && body[0] != '[') {
+		return false
+	}
+	return gjson.ValidBytes(body)
+}
+
+// IsHTML 方法用于判断响应体是否为 HTML 文档, 判断方式与 DetectContentType 相同。
+func (response *Response) IsHTML() bool {
+	return response.DetectContentType() == "text/html"
+}
+
+// IsImage 方法用于判断响应体是否为图片, 判断方式与 DetectContentType 相同。
+func (response *Response) IsImage() bool {
+	return strings.HasPrefix(response.DetectContentType(), "image/")
+}
+
+// MustBe 方法用于确认响应体的实际类型。它接收一个 string 类型的参数，该参数表示期望的媒体类型,
+// 例如 application/json、text/html 或 image/* (匹配所有图片)。类型由 DetectContentType 根据响应体检测, 因此服务器返回与内容矛盾的
+// Content-Type 或者 WAF 在 JSON 接口上返回 HTML 拦截页面时都能发现, 不符合时返回 *ContentTypeError。
+func (response *Response) MustBe(contentType string) error {
+	expected, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("content type Error: invalid media type %q: %w", contentType, err)
+	}
+	detected := response.DetectContentType()
+	if matchMediaType(expected, detected) {
+		return nil
+	}
+	return &ContentTypeError{
+		Expected:   expected,
+		Declared:   response.GetHeader().Get("Content-Type"),
+		Detected:   detected,
+		StatusCode: response.GetStatusCode(),
+	}
+}
+
+// matchMediaType 方法用于判断检测到的媒体类型是否匹配期望的媒体类型, 期望的类型支持 type/* 和 +json 等后缀形式。
+func matchMediaType(expected, detected string) bool {
+	if expected == detected || expected == "*/*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(expected, "/*"); ok {
+		return strings.HasPrefix(detected, prefix+"/")
+	}
+	// application/problem+json 等结构化后缀的类型按后缀对应的类型检测
+	if detected == jsonContentType && (expected == "text/json" || strings.HasSuffix(expected, "+json")) {
+		return true
+	}
+	return detected == "text/xml" && (expected == xmlContentType || strings.HasSuffix(expected, "+xml"))
+}
@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		declared string
+		body     string
+		want     string
+	}{
+		{"json object", "text/plain", `{"code":0}`, jsonContentType},
+		{"csv falls back to declared", "text/csv; charset=utf-8", "id,title\n1,chapter", "text/csv"},
+		{"binary falls back to declared", "image/avif", "\x00\x00\x00\x1cftypavif", "image/avif"},
+		{"html on json endpoint", jsonContentType, "<!DOCTYPE html><html><body>blocked</body></html>", "text/html"},
+		{"invalid json", jsonContentType, "service unavailable", "text/plain"},
+		{"json scalar", jsonContentType, `"ok"`, jsonContentType},
+		{"no declared type", "", "plain text", "text/plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header()["Content-Type"] = []string{tt.declared}
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			response, err := NewClient().R().Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := response.DetectContentType(); got != tt.want {
+				t.Errorf("DetectContentType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMustBeGenericSniff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/blocked" {
+			w.Header().Set("Content-Type", jsonContentType)
+			_, _ = w.Write([]byte("<html><body>Access denied</body></html>"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		_, _ = w.Write([]byte("id,title\n1,chapter"))
+	}))
+	defer server.Close()
+	client := NewClient()
+
+	response, err := client.R().Get(server.URL + "/list.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = response.MustBe("text/csv"); err != nil {
+		t.Errorf("MustBe(text/csv) = %v, want nil", err)
+	}
+
+	response, err = client.R().Get(server.URL + "/blocked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var typeErr *ContentTypeError
+	if err = response.MustBe(jsonContentType); !errors.As(err, &typeErr) || typeErr.Detected != "text/html" {
+		t.Errorf("MustBe(application/json) = %v, want *ContentTypeError detecting text/html", err)
+	}
+}
@@ -0,0 +1,46 @@
+package builder
+
+import "net/http"
+
+// SpecValidator 接口用于按接口规范 (例如 OpenAPI 文档) 校验请求和响应, pkg/openapi 的 *openapi.Validator 实现了该接口。
+// ValidateRequest 在请求发送之前调用, body 为实际发送的请求体 (经过 SetBodyEncoder 设置的编码器之后),
+// 使用 SetBodyFile 发送文件时 body 为 nil; ValidateResponse 在读取响应体之后调用,
+// body 为经过解码管道之后的响应结果。
+type SpecValidator interface {
+	ValidateRequest(req *http.Request, body []byte) error
+	ValidateResponse(req *http.Request, resp *http.Response, body []byte) error
+}
+
+// SetSpecValidator 方法用于设置按接口规范校验请求和响应的 SpecValidator。它接收一个 SpecValidator 类型的参数，
+// 请求不符合规范时不会发送, 直接返回 KindInvalidRequest 类别的错误; 响应不符合规范时返回 KindDecode 类别的错误,
+// 便于在对接的接口与文档不一致时尽早发现问题。
+func (client *Client) SetSpecValidator(validator SpecValidator) *Client {
+	client.specValidator = validator
+	return client
+}
+
+// validateSpecRequest 方法用于在发送之前校验请求, 传给 SpecValidator 的请求体是一份拷贝, 使用 SetBodyFile 发送的文件不参与校验。
+func (request *Request) validateSpecRequest() error {
+	validator := request.client.specValidator
+	if validator == nil {
+		return nil
+	}
+	var body []byte
+	if request.bodyFile == "" {
+		body = append([]byte{}, request.bodyBytes...)
+	}
+	return safeCall("ValidateRequest", func() error {
+		return validator.ValidateRequest(request.NewRequest, body)
+	})
+}
+
+// validateSpecResponse 方法用于校验响应, body 为响应结果。
+func (response *Response) validateSpecResponse(body []byte) error {
+	validator := response.RequestSource.client.specValidator
+	if validator == nil {
+		return nil
+	}
+	return safeCall("ValidateResponse", func() error {
+		return validator.ValidateResponse(response.RequestSource.NewRequest, response.ResponseRaw, body)
+	})
+}
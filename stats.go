@@ -0,0 +1,67 @@
+package builder
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+)
+
+// Stats 类型用于存储 Client 自创建以来的连接和请求统计信息。
+type Stats struct {
+	ConnsOpened    int64 // ConnsOpened 为新建的连接数
+	ConnsReused    int64 // ConnsReused 为复用空闲连接的次数
+	DNSLookups     int64 // DNSLookups 为 DNS 查询次数
+	ActiveRequests int64 // ActiveRequests 为正在进行的请求数
+	Requests       int64 // Requests 为发送到网络的请求数, 包括重试
+	Retries        int64 // Retries 为重试次数
+	BytesSent      int64 // BytesSent 为发送的请求体字节数
+	BytesReceived  int64 // BytesReceived 为接收的响应体字节数
+}
+
+// clientStats 类型用于在请求过程中原子地累计统计信息。
+type clientStats struct {
+	connsOpened    atomic.Int64
+	connsReused    atomic.Int64
+	dnsLookups     atomic.Int64
+	activeRequests atomic.Int64
+	requests       atomic.Int64
+	retries        atomic.Int64
+	bytesSent      atomic.Int64
+	bytesReceived  atomic.Int64
+}
+
+// Stats 方法用于获取 Client 的连接和请求统计信息的快照, 可用于长期运行的爬虫服务的容量规划。
+func (client *Client) Stats() Stats {
+	stats := &client.stats
+	return Stats{
+		ConnsOpened:    stats.connsOpened.Load(),
+		ConnsReused:    stats.connsReused.Load(),
+		DNSLookups:     stats.dnsLookups.Load(),
+		ActiveRequests: stats.activeRequests.Load(),
+		Requests:       stats.requests.Load(),
+		Retries:        stats.retries.Load(),
+		BytesSent:      stats.bytesSent.Load(),
+		BytesReceived:  stats.bytesReceived.Load(),
+	}
+}
+
+// traceRequest 方法用于为一次发送到网络的请求添加统计连接和 DNS 查询的 httptrace.ClientTrace。
+func (stats *clientStats) traceRequest(req *http.Request) *http.Request {
+	stats.requests.Add(1)
+	if req.ContentLength > 0 {
+		stats.bytesSent.Add(req.ContentLength)
+	}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			stats.dnsLookups.Add(1)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				stats.connsReused.Add(1)
+			} else {
+				stats.connsOpened.Add(1)
+			}
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
@@ -0,0 +1,98 @@
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestJsonStreamDoNotParse 确认 SetDoNotParseResponse 发送的请求由 JsonStream 直接从网络解码, 不保存完整的响应体。
+func TestJsonStreamDoNotParse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", jsonContentType)
+		_, _ = w.Write([]byte(`{"chapters":[{"id":1},{"id":2}]}`))
+	}))
+	defer server.Close()
+	client := NewClient().EnableConditionalRequest()
+
+	response, err := client.R().SetDoNotParseResponse().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var catalog struct {
+		Chapters []struct{ ID int } `json:"chapters"`
+	}
+	if err = response.JsonStream(&catalog); err != nil {
+		t.Fatal(err)
+	}
+	if len(catalog.Chapters) != 2 || catalog.Chapters[1].ID != 2 {
+		t.Errorf("decoded %+v, want two chapters", catalog)
+	}
+	if response.body != nil || response.String() != "" {
+		t.Errorf("streamed body was buffered: %q", response.String())
+	}
+	if client.Cache().Len() != 0 {
+		t.Errorf("streamed response was cached")
+	}
+
+	// 没有设置 SetDoNotParseResponse 时 JsonStream 等同于 Json, 响应体仍然可以访问
+	response, err = NewClient().R().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = response.JsonStream(&catalog); err != nil {
+		t.Fatal(err)
+	}
+	if response.String() == "" {
+		t.Error("JsonStream consumed the body of a normal request")
+	}
+}
+
+// TestSaveFileStreamChecksum 确认 SetDoNotParseResponse 发送的请求由 SaveFile 直接从网络写入文件并计算校验和。
+func TestSaveFileStreamChecksum(t *testing.T) {
+	content := strings.Repeat("book package ", 1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+	sum := sha256.Sum256([]byte(content))
+	dir := t.TempDir()
+	client := NewClient()
+
+	path := filepath.Join(dir, "book.pkg")
+	response, err := client.R().SetDoNotParseResponse().SetExpectedChecksum("sha256", hex.EncodeToString(sum[:])).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = response.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("saved %d bytes, want %d", len(data), len(content))
+	}
+	if response.body != nil {
+		t.Error("streamed body was buffered")
+	}
+	if got := client.Stats().BytesReceived; got != int64(len(content)) {
+		t.Errorf("BytesReceived = %d, want %d", got, len(content))
+	}
+
+	path = filepath.Join(dir, "bad.pkg")
+	response, err = client.R().SetDoNotParseResponse().SetExpectedChecksum("sha256", strings.Repeat("00", sha256.Size)).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var checksumErr *ChecksumError
+	if err = response.SaveFile(path); !errors.As(err, &checksumErr) {
+		t.Fatalf("SaveFile error = %v, want *ChecksumError", err)
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Error("file exists after checksum mismatch")
+	}
+}
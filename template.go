@@ -0,0 +1,38 @@
+package builder
+
+import "fmt"
+
+// RegisterTemplate 方法用于注册请求模板。它接收一个 string 类型的参数表示模板名称, 以及一个 func(*Request) 类型的参数,
+// 该函数用于配置请求, 例如设置路径、签名参数和 Header。之后可以通过 T 创建按模板配置好的请求,
+// 常用的请求 (章节签名、搜索、登录等) 只需要定义一次。同名的模板会被替换。
+func (client *Client) RegisterTemplate(name string, fn func(*Request)) *Client {
+	client.Lock()
+	defer client.Unlock()
+	templates := make(map[string]func(*Request), len(client.templates)+1)
+	for key, value := range client.templates {
+		templates[key] = value
+	}
+	templates[name] = fn
+	client.templates = templates
+	return client
+}
+
+// T 方法用于按 RegisterTemplate 注册的模板创建一个新的请求。它接收一个 string 类型的参数，该参数表示模板名称,
+// 返回的请求可以继续修改, 不会影响模板。模板不存在时记录错误并返回一个未经配置的请求。
+func (client *Client) T(name string) *Request {
+	request := client.R()
+	client.RLock()
+	fn, ok := client.templates[name]
+	client.RUnlock()
+	if !ok {
+		client.LogError(fmt.Errorf("template Error: template %q is not registered", name), name, "template.go", "T")
+		return request
+	}
+	if err := safeCall("RegisterTemplate", func() error {
+		fn(request)
+		return nil
+	}); err != nil {
+		client.LogError(err, name, "template.go", "T")
+	}
+	return request
+}
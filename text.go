@@ -0,0 +1,99 @@
+package builder
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Text 方法用于从 HTML 响应中提取可读的纯文本。它接收一个 string 类型的参数，该参数表示 CSS 选择器,
+// 为空时提取整个文档, 多个匹配的元素之间使用空行分隔。<br> 和 <p>、<div> 等块级元素会被转换为换行,
+// script、style 等不可见元素会被移除, 每行内连续的空白字符会被合并为一个空格, 空行会被移除。
+func (response *Response) Text(selector string) string {
+	doc := response.Html()
+	if doc == nil {
+		return ""
+	}
+	selection := doc.Selection
+	if selector != "" {
+		selection = doc.Find(selector)
+	}
+	var blocks []string
+	selection.Each(func(_ int, s *goquery.Selection) {
+		var builder strings.Builder
+		for _, node := range s.Nodes {
+			writeNodeText(&builder, node)
+		}
+		if text := collapseText(builder.String()); text != "" {
+			blocks = append(blocks, text)
+		}
+	})
+	return strings.Join(blocks, "\n\n")
+}
+
+// writeNodeText 方法用于把 HTML 节点中的文本写入 builder, 块级元素前后写入换行。
+func writeNodeText(builder *strings.Builder, node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		builder.WriteString(node.Data)
+		return
+	case html.CommentNode, html.DoctypeNode:
+		return
+	case html.ElementNode:
+		switch node.DataAtom {
+		case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Head, atom.Iframe, atom.Svg:
+			return
+		case atom.Br:
+			builder.WriteByte('\n')
+			return
+		}
+	}
+	block := isBlockElement(node)
+	if block {
+		builder.WriteByte('\n')
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writeNodeText(builder, child)
+	}
+	if block {
+		builder.WriteByte('\n')
+	}
+}
+
+// isBlockElement 方法用于判断 HTML 节点是否为需要单独成行的块级元素。
+func isBlockElement(node *html.Node) bool {
+	if node.Type != html.ElementNode {
+		return false
+	}
+	switch node.DataAtom {
+	case atom.P, atom.Div, atom.Li, atom.Ul, atom.Ol, atom.Dl, atom.Dt, atom.Dd, atom.Tr, atom.Table,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Blockquote, atom.Pre, atom.Hr,
+		atom.Section, atom.Article, atom.Header, atom.Footer, atom.Nav, atom.Aside, atom.Main:
+		return true
+	}
+	return false
+}
+
+// collapseText 方法用于合并每行内连续的空白字符 (包括 &nbsp; 和全角空格) 并移除空行。
+func collapseText(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		line = strings.Join(strings.FieldsFunc(line, isTextSpace), " ")
+		if line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// isTextSpace 方法用于判断字符是否为需要合并的空白字符, 换行已经在 collapseText 中处理。
+func isTextSpace(r rune) bool {
+	switch r {
+	case ' ', '\t', '\r', '\f', '\v', '\u00a0', '\u3000':
+		return true
+	}
+	return false
+}
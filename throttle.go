@@ -0,0 +1,187 @@
+package builder
+
+import (
+	"golang.org/x/net/context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Throttle 类型是令牌桶限速器, 令牌按固定的速率产生, 令牌桶满时不再增加。Client 使用它限制上传和下载的带宽 (每个字节一个令牌),
+// 也可以通过 GetBandwidthThrottles 取得 Client 使用的 Throttle, 让磁盘写入等非 HTTP 的操作与 Client 共享同一个速率。
+// Throttle 可以被多个 goroutine 同时使用。
+type Throttle struct {
+	mu     sync.Mutex
+	rate   float64 // rate 为每秒产生的令牌数
+	burst  float64 // burst 为令牌桶的容量
+	tokens float64
+	last   time.Time
+}
+
+// throttleChunk 为一次读写的最大字节数, 避免一次读取大量数据后长时间等待。
+const throttleChunk = 32 * 1024
+
+// NewThrottle 方法用于创建一个 Throttle。它接收两个 int64 类型的参数，分别表示每秒产生的令牌数和令牌桶的容量,
+// 容量小于等于 0 时使用一秒产生的令牌数。新建的令牌桶是满的, 速率小于等于 0 时不限制。
+func NewThrottle(rate, burst int64) *Throttle {
+	if burst <= 0 {
+		burst = rate
+	}
+	return &Throttle{rate: float64(rate), burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// newThrottle 方法用于创建一个每秒允许 rate 个字节的 Throttle, 令牌桶的容量为一秒的流量。
+func newThrottle(rate int64) *Throttle {
+	return NewThrottle(rate, rate)
+}
+
+// refill 方法用于按经过的时间补充令牌, 调用方需要持有锁。
+func (t *Throttle) refill(now time.Time) {
+	t.tokens += now.Sub(t.last).Seconds() * t.rate
+	if t.tokens > t.burst {
+		t.tokens = t.burst
+	}
+	t.last = now
+}
+
+// reserve 方法用于取出 n 个令牌, 令牌不足时返回需要等待的时间。
+func (t *Throttle) reserve(n int) time.Duration {
+	if t.rate <= 0 {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refill(time.Now())
+	t.tokens -= float64(n)
+	if t.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-t.tokens / t.rate * float64(time.Second))
+}
+
+// Wait 方法用于取出一个令牌, 令牌不足时等待。它接收一个 context.Context 类型的参数, ctx 结束时提前返回 ctx 的错误。
+func (t *Throttle) Wait(ctx context.Context) error {
+	return t.WaitN(ctx, 1)
+}
+
+// WaitN 方法用于取出 n 个令牌, 令牌不足时等待, 例如写入 n 个字节之前调用。它接收一个 context.Context 类型的参数和一个 int 类型的参数，
+// ctx 结束时提前返回 ctx 的错误, 已经预约的令牌不会归还。n 可以大于令牌桶的容量, 此时等待相应更长的时间。
+func (t *Throttle) WaitN(ctx context.Context, n int) error {
+	delay := t.reserve(n)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Allow 方法用于在不等待的情况下取出一个令牌。它返回一个 bool 类型的参数, 令牌不足时返回 false 且不消耗令牌。
+func (t *Throttle) Allow() bool {
+	return t.AllowN(1)
+}
+
+// AllowN 方法用于在不等待的情况下取出 n 个令牌。它接收一个 int 类型的参数, 令牌不足时返回 false 且不消耗令牌。
+func (t *Throttle) AllowN(n int) bool {
+	if t.rate <= 0 {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refill(time.Now())
+	if t.tokens < float64(n) {
+		return false
+	}
+	t.tokens -= float64(n)
+	return true
+}
+
+// throttledReader 类型用于限制读取速度。
+type throttledReader struct {
+	reader   io.ReadCloser
+	throttle *Throttle
+	ctx      context.Context
+}
+
+// Read 方法用于读取数据, 并按限速等待。
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > throttleChunk {
+		p = p[:throttleChunk]
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if waitErr := r.throttle.WaitN(r.ctx, n); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return n, err
+}
+
+// Close 方法用于关闭被限速的 reader。
+func (r *throttledReader) Close() error {
+	return r.reader.Close()
+}
+
+// SetBandwidthLimit 方法用于限制 Client 所有请求的总带宽。它接收一个 int64 类型的参数，该参数表示每秒允许上传和下载的字节数,
+// 上传和下载分别计算, 0 表示不限制。适用于不希望后台的批量下载占满网络的场景。
+func (client *Client) SetBandwidthLimit(bytesPerSec int64) *Client {
+	client.uploadThrottle, client.downloadThrottle = nil, nil
+	if bytesPerSec > 0 {
+		client.uploadThrottle, client.downloadThrottle = newThrottle(bytesPerSec), newThrottle(bytesPerSec)
+	}
+	return client
+}
+
+// SetBandwidthThrottles 方法用于设置 Client 上传和下载使用的 Throttle。它接收两个 *Throttle 类型的参数，
+// 每个字节消耗一个令牌, nil 表示不限制。多个 Client 使用同一个 Throttle 时共享同一个带宽。
+func (client *Client) SetBandwidthThrottles(upload, download *Throttle) *Client {
+	client.uploadThrottle, client.downloadThrottle = upload, download
+	return client
+}
+
+// GetBandwidthThrottles 方法用于获取 Client 上传和下载使用的 Throttle, 未限制带宽时为 nil。
+func (client *Client) GetBandwidthThrottles() (upload, download *Throttle) {
+	return client.uploadThrottle, client.downloadThrottle
+}
+
+// SetBandwidthLimit 方法用于限制当前请求的带宽, 覆盖客户端的设置。它接收一个 int64 类型的参数，
+// 该参数表示每秒允许上传和下载的字节数, 0 表示不限制。
+func (request *Request) SetBandwidthLimit(bytesPerSec int64) *Request {
+	request.bandwidthLimit = &bytesPerSec
+	return request
+}
+
+// getThrottles 方法用于获取当前请求上传和下载使用的 Throttle, 不限速时返回 nil。
+func (request *Request) getThrottles() (upload, download *Throttle) {
+	if request.bandwidthLimit == nil {
+		return request.client.uploadThrottle, request.client.downloadThrottle
+	}
+	if *request.bandwidthLimit <= 0 {
+		return nil, nil
+	}
+	return newThrottle(*request.bandwidthLimit), newThrottle(*request.bandwidthLimit)
+}
+
+// throttleRequest 方法用于限制 http.Request 请求体的上传速度, 修改的是 req 的副本。
+func throttleRequest(req *http.Request, upload *Throttle) *http.Request {
+	if upload == nil || req.Body == nil || req.Body == http.NoBody {
+		return req
+	}
+	throttled := req.WithContext(req.Context())
+	throttled.Body = &throttledReader{reader: req.Body, throttle: upload, ctx: req.Context()}
+	return throttled
+}
+
+// throttleResponse 方法用于限制 http.Response 响应体的下载速度。
+func throttleResponse(raw *http.Response, download *Throttle) {
+	if download == nil || raw == nil || raw.Body == nil || raw.Body == http.NoBody {
+		return
+	}
+	raw.Body = &throttledReader{reader: raw.Body, throttle: download, ctx: raw.Request.Context()}
+}
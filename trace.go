@@ -0,0 +1,175 @@
+package builder
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TraceInfo 类型用于存储开启 EnableTrace 后最近一次发送到网络的请求的耗时信息。
+type TraceInfo struct {
+	DNSLookup    time.Duration // DNS 查询耗时
+	TCPConnTime  time.Duration // 建立 TCP 连接耗时
+	TLSHandshake time.Duration // TLS 握手耗时
+	ConnTime     time.Duration // 获取连接的总耗时, 包括 DNS 查询、建立连接和 TLS 握手, 复用连接时接近 0
+	ServerTime   time.Duration // 从写完请求到收到响应第一个字节的耗时
+	TotalTime    time.Duration // 从开始发送请求到收到响应头的总耗时
+	IsConnReused bool          // 是否复用了已有的连接
+	RemoteAddr   string        // 服务器的地址
+	RemoteIP     string        // 响应来自的服务器 IP, 域名解析出多个 IP 时可以确定实际连接的是哪一个
+	FailedAddrs  []string      // 本次尝试中连接失败后被跳过的地址
+	Attempt      int           // 第几次尝试, 从 1 开始
+}
+
+// traceTimer 类型用于在 httptrace 的回调中记录各阶段的时间点, 回调可能在不同的 goroutine 中执行。
+type traceTimer struct {
+	mu                               sync.Mutex
+	start, dnsStart, dnsDone         time.Time
+	connectStart, connectDone        time.Time
+	tlsStart, tlsDone                time.Time
+	gotConn, wroteRequest, firstByte time.Time
+	reused                           bool
+	remoteAddr                       string
+	failedAddrs                      []string
+}
+
+// EnableTrace 方法用于为客户端的所有请求开启耗时追踪, 开启后可以通过 Response.TraceInfo 获取各阶段的耗时。
+func (client *Client) EnableTrace() *Client {
+	client.trace = true
+	return client
+}
+
+// EnableTrace 方法用于为当前请求开启耗时追踪, 开启后可以通过 Response.TraceInfo 获取各阶段的耗时。
+func (request *Request) EnableTrace() *Request {
+	request.trace = true
+	return request
+}
+
+// TraceInfo 方法用于获取请求最后一次尝试的耗时信息, 未开启 EnableTrace 时返回零值。
+func (response *Response) TraceInfo() TraceInfo {
+	return response.RequestSource.traceInfo
+}
+
+// traceTimings 方法用于在开启追踪时为请求添加记录耗时的 httptrace.ClientTrace, 返回的函数用于在请求结束后生成 TraceInfo。
+func (request *Request) traceTimings(req *http.Request, attempt int) (*http.Request, func()) {
+	if !request.trace && !request.client.trace {
+		return req, func() {}
+	}
+	timer := &traceTimer{start: time.Now()}
+	record := func(t *time.Time) {
+		timer.mu.Lock()
+		*t = time.Now()
+		timer.mu.Unlock()
+	}
+	trace := &httptrace.ClientTrace{
+		DNSStart:     func(httptrace.DNSStartInfo) { record(&timer.dnsStart) },
+		DNSDone:      func(httptrace.DNSDoneInfo) { record(&timer.dnsDone) },
+		ConnectStart: func(string, string) { record(&timer.connectStart) },
+		ConnectDone: func(_, addr string, err error) {
+			timer.mu.Lock()
+			timer.connectDone = time.Now()
+			if err != nil {
+				timer.failedAddrs = append(timer.failedAddrs, addr)
+			}
+			timer.mu.Unlock()
+		},
+		TLSHandshakeStart: func() { record(&timer.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { record(&timer.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			timer.mu.Lock()
+			timer.gotConn, timer.reused = time.Now(), info.Reused
+			if info.Conn != nil {
+				timer.remoteAddr = info.Conn.RemoteAddr().String()
+			}
+			timer.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { record(&timer.wroteRequest) },
+		GotFirstResponseByte: func() { record(&timer.firstByte) },
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), func() {
+		request.traceInfo = timer.info(attempt)
+	}
+}
+
+// info 方法用于根据记录的时间点生成 TraceInfo, 未发生的阶段耗时为 0。
+func (timer *traceTimer) info(attempt int) TraceInfo {
+	timer.mu.Lock()
+	defer timer.mu.Unlock()
+	since := func(from, to time.Time) time.Duration {
+		if from.IsZero() || to.IsZero() {
+			return 0
+		}
+		return to.Sub(from)
+	}
+	return TraceInfo{
+		DNSLookup:    since(timer.dnsStart, timer.dnsDone),
+		TCPConnTime:  since(timer.connectStart, timer.connectDone),
+		TLSHandshake: since(timer.tlsStart, timer.tlsDone),
+		ConnTime:     since(timer.start, timer.gotConn),
+		ServerTime:   since(timer.wroteRequest, timer.firstByte),
+		TotalTime:    time.Since(timer.start),
+		IsConnReused: timer.reused,
+		RemoteAddr:   timer.remoteAddr,
+		RemoteIP:     remoteIP(timer.remoteAddr),
+		FailedAddrs:  timer.failedAddrs,
+		Attempt:      attempt,
+	}
+}
+
+// remoteIP 方法用于从 host:port 形式的地址中取出 IP。
+func remoteIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// SetSlowRequestThreshold 方法用于设置慢请求的阈值。它接收一个 time.Duration 类型的参数，发送请求 (包括重试) 的耗时
+// 超过阈值时记录一条 Warn 级别的日志, 与是否开启 Debug 无关; 开启 EnableTrace 时日志会包含各阶段的耗时。0 表示关闭。
+func (client *Client) SetSlowRequestThreshold(threshold time.Duration) *Client {
+	client.slowThreshold = threshold
+	return client
+}
+
+// logSlowRequest 方法用于在请求耗时超过 SetSlowRequestThreshold 设置的阈值时记录日志。
+func (request *Request) logSlowRequest(elapsed time.Duration, attempts int, raw *http.Response, err error) {
+	threshold := request.client.slowThreshold
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+	fields := logrus.Fields{
+		"Method":    request.Method,
+		"URL":       request.URL.String(),
+		"Duration":  elapsed.String(),
+		"Threshold": threshold.String(),
+		"Attempts":  attempts,
+	}
+	if raw != nil {
+		fields["Code"] = raw.StatusCode
+	}
+	if err != nil {
+		fields["Error"] = err.Error()
+	}
+	if info := request.traceInfo; info.Attempt > 0 {
+		fields["Trace"] = logrus.Fields{
+			"DNSLookup":    info.DNSLookup.String(),
+			"TCPConnTime":  info.TCPConnTime.String(),
+			"TLSHandshake": info.TLSHandshake.String(),
+			"ConnTime":     info.ConnTime.String(),
+			"ServerTime":   info.ServerTime.String(),
+			"TotalTime":    info.TotalTime.String(),
+			"IsConnReused": info.IsConnReused,
+			"RemoteAddr":   info.RemoteAddr,
+		}
+		if len(info.FailedAddrs) > 0 {
+			fields["Trace"].(logrus.Fields)["FailedAddrs"] = info.FailedAddrs
+		}
+	}
+	request.client.log.WithFields(request.logFields(fields)).Warn("slow request")
+}
@@ -0,0 +1,29 @@
+package builder
+
+import "net/http"
+
+// SetContentLength 方法用于显式设置 HTTP 请求的 Content-Length。它接收一个 int64 类型的参数，该参数表示请求体的字节数,
+// 设置后请求体不会使用分块传输。Content-Length 与实际的请求体长度不一致时请求返回错误。
+func (request *Request) SetContentLength(n int64) *Request {
+	request.contentLength, request.forceChunked = &n, false
+	return request
+}
+
+// ForceChunked 方法用于强制以分块传输 (Transfer-Encoding: chunked) 的方式发送请求体, 即使请求体的长度已知。
+// 分块传输只适用于 HTTP/1.1, 使用 HTTP/2 时请求体总是以数据帧发送。
+func (request *Request) ForceChunked() *Request {
+	request.contentLength, request.forceChunked = nil, true
+	return request
+}
+
+// setTransferEncoding 方法用于按 SetContentLength 和 ForceChunked 的设置修改 http.Request 的传输方式。
+func (request *Request) setTransferEncoding(req *http.Request) {
+	switch {
+	case request.contentLength != nil:
+		req.ContentLength = *request.contentLength
+		req.TransferEncoding = nil
+	case request.forceChunked && req.Body != nil && req.Body != http.NoBody:
+		req.ContentLength = -1
+		req.TransferEncoding = []string{"chunked"}
+	}
+}
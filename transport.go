@@ -0,0 +1,221 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GetHTTPClient 方法用于获取 Client 底层使用的 *http.Client, 可以交给需要标准 *http.Client 的第三方库使用。
+// 对返回值的修改会影响 Client 之后发送的请求。
+func (client *Client) GetHTTPClient() *http.Client {
+	return client.httpClientRaw
+}
+
+// SetHTTPClient 方法用于替换 Client 底层使用的 *http.Client。它接收一个 *http.Client 类型的参数，
+// 如果该参数没有设置 Jar, 则沿用原来的 CookieJar。
+func (client *Client) SetHTTPClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		client.LogError(errors.New("http client is nil"), nil, "transport.go", "SetHTTPClient")
+		return client
+	}
+	if httpClient.Jar == nil {
+		httpClient.Jar = client.httpClientRaw.Jar
+	}
+	httpClient.Jar = client.wrapJar(httpClient.Jar)
+	if t, _ := httpClient.Transport.(*http.Transport); t == nil || t != client.GetTransport() {
+		client.dialer = nil
+	}
+	client.httpClientRaw = httpClient
+	return client
+}
+
+// GetTransport 方法用于获取 Client 底层使用的 *http.Transport, 如果通过 SetTransport 设置了其他类型的 RoundTripper 则返回 nil。
+func (client *Client) GetTransport() *http.Transport {
+	transport, _ := client.httpClientRaw.Transport.(*http.Transport)
+	return transport
+}
+
+// SetTransport 方法用于替换 Client 底层使用的 http.RoundTripper。它接收一个 http.RoundTripper 类型的参数，
+// 设置为 *http.Transport 以外的类型后, SetProxy 等修改连接配置的方法将不再生效。
+func (client *Client) SetTransport(transport http.RoundTripper) *Client {
+	if transport == nil {
+		client.LogError(errors.New("transport is nil"), nil, "transport.go", "SetTransport")
+		return client
+	}
+	// 新的 Transport 不再使用 Client 管理的 net.Dialer
+	if t, _ := transport.(*http.Transport); t == nil || t != client.GetTransport() {
+		client.dialer = nil
+	}
+	client.httpClientRaw.Transport = transport
+	return client
+}
+
+// transport 方法用于获取可以修改的 *http.Transport, 当前的 RoundTripper 不是 *http.Transport 时记录错误并返回 nil。
+func (client *Client) transport(funcName string) *http.Transport {
+	transport := client.GetTransport()
+	if transport == nil {
+		client.LogError(errors.New("transport is not *http.Transport"), nil, "transport.go", funcName)
+	}
+	return transport
+}
+
+// SetTimeoutDuration 方法用于设置 HTTP 请求的整体超时时间。它接收一个 time.Duration 类型的参数，
+// 该参数表示从建立连接到读取完响应体的最长时间, 0 表示不限制。
+func (client *Client) SetTimeoutDuration(timeout time.Duration) *Client {
+	client.timeout = int(timeout / time.Second)
+	client.httpClientRaw.Timeout = timeout
+	return client
+}
+
+// SetDialTimeout 方法用于设置建立 TCP 连接的超时时间。它接收一个 time.Duration 类型的参数，该参数表示超时时间。
+func (client *Client) SetDialTimeout(timeout time.Duration) *Client {
+	if client.dialer == nil {
+		client.LogError(errors.New("dialer is not managed by builder"), timeout, "transport.go", "SetDialTimeout")
+		return client
+	}
+	client.dialer.Timeout = timeout
+	return client
+}
+
+// SetTLSHandshakeTimeout 方法用于设置 TLS 握手的超时时间。它接收一个 time.Duration 类型的参数，该参数表示超时时间。
+func (client *Client) SetTLSHandshakeTimeout(timeout time.Duration) *Client {
+	if transport := client.transport("SetTLSHandshakeTimeout"); transport != nil {
+		transport.TLSHandshakeTimeout = timeout
+	}
+	return client
+}
+
+// SetResponseHeaderTimeout 方法用于设置发送请求后等待响应头的超时时间。它接收一个 time.Duration 类型的参数，
+// 该参数表示超时时间, 不包括读取响应体的时间。
+func (client *Client) SetResponseHeaderTimeout(timeout time.Duration) *Client {
+	if transport := client.transport("SetResponseHeaderTimeout"); transport != nil {
+		transport.ResponseHeaderTimeout = timeout
+	}
+	return client
+}
+
+// SetMaxIdleConns 方法用于设置所有主机的最大空闲连接数。它接收一个 int 类型的参数，0 表示不限制。
+func (client *Client) SetMaxIdleConns(n int) *Client {
+	if transport := client.transport("SetMaxIdleConns"); transport != nil {
+		transport.MaxIdleConns = n
+	}
+	return client
+}
+
+// SetMaxIdleConnsPerHost 方法用于设置每个主机的最大空闲连接数。它接收一个 int 类型的参数，
+// 默认值为 GOMAXPROCS+1, 高并发请求同一个主机时应适当调大。
+func (client *Client) SetMaxIdleConnsPerHost(n int) *Client {
+	if transport := client.transport("SetMaxIdleConnsPerHost"); transport != nil {
+		transport.MaxIdleConnsPerHost = n
+	}
+	return client
+}
+
+// SetMaxConnsPerHost 方法用于设置每个主机的最大连接数, 包括正在使用的和空闲的连接。它接收一个 int 类型的参数，0 表示不限制。
+func (client *Client) SetMaxConnsPerHost(n int) *Client {
+	if transport := client.transport("SetMaxConnsPerHost"); transport != nil {
+		transport.MaxConnsPerHost = n
+	}
+	return client
+}
+
+// SetIdleConnTimeout 方法用于设置空闲连接在关闭前保留的最长时间。它接收一个 time.Duration 类型的参数，0 表示不限制。
+func (client *Client) SetIdleConnTimeout(timeout time.Duration) *Client {
+	if transport := client.transport("SetIdleConnTimeout"); transport != nil {
+		transport.IdleConnTimeout = timeout
+	}
+	return client
+}
+
+// DisableKeepAlives 方法用于关闭 HTTP 长连接, 关闭后每个请求都会建立新的连接。
+// 适用于会根据长连接识别或限制客户端的目标站点。
+func (client *Client) DisableKeepAlives() *Client {
+	if transport := client.transport("DisableKeepAlives"); transport != nil {
+		transport.DisableKeepAlives = true
+	}
+	return client
+}
+
+// SetLocalAddr 方法用于设置建立连接时使用的本地 IP 地址。它接收一个或多个 string 类型的参数，该参数表示本地 IP 地址,
+// 设置多个地址时新建的连接按顺序轮流使用这些地址, 用于在多个出口 IP 之间分配流量。
+func (client *Client) SetLocalAddr(ips ...string) *Client {
+	addrs := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		parsed := net.ParseIP(strings.TrimSpace(ip))
+		if parsed == nil {
+			client.LogError(fmt.Errorf("invalid local ip %q", ip), ip, "transport.go", "SetLocalAddr")
+			return client
+		}
+		addrs = append(addrs, parsed)
+	}
+	return client.setLocalAddrs(addrs, "SetLocalAddr")
+}
+
+// SetInterface 方法用于把连接绑定到指定的网卡。它接收一个 string 类型的参数，该参数表示网卡名称,
+// 网卡上的所有 IP 地址都会作为本地地址轮流使用。
+func (client *Client) SetInterface(name string) *Client {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		client.LogError(err, name, "transport.go", "SetInterface")
+		return client
+	}
+	ifaceAddrs, err := iface.Addrs()
+	if err != nil {
+		client.LogError(err, name, "transport.go", "SetInterface")
+		return client
+	}
+	var addrs []net.IP
+	for _, addr := range ifaceAddrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLinkLocalUnicast() {
+			addrs = append(addrs, ipNet.IP)
+		}
+	}
+	if len(addrs) == 0 {
+		client.LogError(fmt.Errorf("interface %s has no usable ip address", name), name, "transport.go", "SetInterface")
+		return client
+	}
+	return client.setLocalAddrs(addrs, "SetInterface")
+}
+
+// setLocalAddrs 方法用于设置轮流使用的本地地址, 并让 Transport 通过 dialContext 建立连接。
+func (client *Client) setLocalAddrs(addrs []net.IP, funcName string) *Client {
+	transport := client.transport(funcName)
+	if transport == nil {
+		return client
+	}
+	if client.dialer == nil {
+		client.LogError(errors.New("dialer is not managed by builder"), nil, "transport.go", funcName)
+		return client
+	}
+	client.Lock()
+	client.localAddrs = addrs
+	client.Unlock()
+	transport.DialContext = client.dialContext
+	return client
+}
+
+// dialContext 方法用于建立连接, 设置了本地地址时按顺序轮流使用, 并只连接与本地地址相同协议族的远程地址。
+// 域名解析出多个 IP 时由 dialFailover 按照 net.Dialer 的方式尝试。
+func (client *Client) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	client.RLock()
+	addrs := client.localAddrs
+	client.RUnlock()
+	if len(addrs) == 0 {
+		return client.dialFailover(ctx, client.dialer, network, address)
+	}
+	ip := addrs[(client.localAddrIndex.Add(1)-1)%uint64(len(addrs))]
+	dialer := *client.dialer
+	dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	if network == "tcp" {
+		network = "tcp6"
+		if ip.To4() != nil {
+			network = "tcp4"
+		}
+	}
+	return client.dialFailover(ctx, &dialer, network, address)
+}
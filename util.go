@@ -0,0 +1,57 @@
+package builder
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// maxPooledBufferSize 为放回 bufPool 的缓冲区的最大容量, 避免个别大请求体长期占用内存
+const maxPooledBufferSize = 64 * 1024
+
+// bufPool 用于复用请求体等临时使用的 bytes.Buffer
+var bufPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// acquireBuffer 方法用于从 bufPool 获取一个空的 bytes.Buffer。
+func acquireBuffer() *bytes.Buffer {
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// releaseBuffer 方法用于把 bytes.Buffer 放回 bufPool, 调用之后不能再使用 buf 及其 Bytes 返回的切片。
+func releaseBuffer(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufPool.Put(buf)
+}
+
+// newUUID 方法用于生成一个随机的 UUID (版本 4) 字符串。
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("builder: failed to read random bytes: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // 版本 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 变体
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// isAbsoluteURL 方法用于判断路径是否为带有 http 或 https 协议的完整 URL。
+func isAbsoluteURL(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// bytesToString 方法用于在不复制的情况下把 b 转换为 string, 调用者需要保证之后不会再修改 b。
+func bytesToString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return unsafe.String(&b[0], len(b))
+}
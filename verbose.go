@@ -0,0 +1,109 @@
+package builder
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// verboseWriter 类型用于输出 curl 风格的请求跟踪信息, 并保证并发请求的每一段输出不会交错。
+type verboseWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// write 方法用于输出一行或多行跟踪信息。
+func (v *verboseWriter) write(lines []string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	_, _ = io.WriteString(v.w, strings.Join(lines, "\n")+"\n")
+}
+
+// SetVerbose 方法用于输出 curl -v 风格的逐行请求跟踪。它接收一个 io.Writer 类型的参数，该参数表示输出位置, nil 表示关闭。
+// 跟踪信息包括连接、TLS 握手、以 > 开头的请求行和请求头、以 < 开头的状态行和响应头以及耗时,
+// 与 SetDebug 输出的结构化 JSON 日志相互独立, 便于交互式调试时查看。
+func (client *Client) SetVerbose(w io.Writer) *Client {
+	client.verbose = nil
+	if w != nil {
+		client.verbose = &verboseWriter{w: w}
+	}
+	return client
+}
+
+// traceVerbose 方法用于在开启 SetVerbose 时输出请求并跟踪连接过程, 返回的函数用于在收到响应后输出响应。
+func (request *Request) traceVerbose(req *http.Request) (*http.Request, func(*http.Response, error)) {
+	verbose := request.client.verbose
+	if verbose == nil {
+		return req, func(*http.Response, error) {}
+	}
+	start := time.Now()
+	elapsed := func() string {
+		return time.Since(start).Round(time.Microsecond).String()
+	}
+	trace := &httptrace.ClientTrace{
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			if info.Err != nil {
+				verbose.write([]string{fmt.Sprintf("* DNS lookup failed: %v (%s)", info.Err, elapsed())})
+				return
+			}
+			verbose.write([]string{fmt.Sprintf("* DNS resolved %s to %v (%s)", req.URL.Hostname(), info.Addrs, elapsed())})
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err != nil {
+				verbose.write([]string{fmt.Sprintf("* Failed to connect to %s: %v (%s)", addr, err, elapsed())})
+				return
+			}
+			verbose.write([]string{fmt.Sprintf("* Connected to %s (%s) (%s)", req.URL.Host, addr, elapsed())})
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if err != nil {
+				verbose.write([]string{fmt.Sprintf("* TLS handshake failed: %v (%s)", err, elapsed())})
+				return
+			}
+			verbose.write([]string{fmt.Sprintf("* TLS handshake done: %s, %s, ALPN %q (%s)",
+				tls.VersionName(state.Version), tls.CipherSuiteName(state.CipherSuite), state.NegotiatedProtocol, elapsed())})
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				verbose.write([]string{fmt.Sprintf("* Re-using connection to %s (%s)", req.URL.Host, elapsed())})
+			}
+		},
+		GotFirstResponseByte: func() {
+			verbose.write([]string{fmt.Sprintf("* First response byte (%s)", elapsed())})
+		},
+	}
+	lines := []string{fmt.Sprintf("> %s %s %s", req.Method, req.URL.RequestURI(), req.Proto), "> Host: " + req.URL.Host}
+	lines = append(append(lines, verboseHeader(">", req.Header)...), ">")
+	verbose.write(lines)
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), func(raw *http.Response, err error) {
+		if err != nil {
+			verbose.write([]string{fmt.Sprintf("* Request failed: %v (%s)", err, elapsed())})
+			return
+		}
+		lines := []string{fmt.Sprintf("< %s %s", raw.Proto, raw.Status)}
+		lines = append(append(lines, verboseHeader("<", raw.Header)...), "<", fmt.Sprintf("* Response headers received (%s)", elapsed()))
+		verbose.write(lines)
+	}
+}
+
+// verboseHeader 方法用于把头部按名称排序后格式化为带有前缀的行。
+func verboseHeader(prefix string, header http.Header) []string {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var lines []string
+	for _, key := range keys {
+		for _, value := range header[key] {
+			lines = append(lines, fmt.Sprintf("%s %s: %s", prefix, key, value))
+		}
+	}
+	return lines
+}